@@ -19,6 +19,19 @@ func (a Address) String() string {
 	return fmt.Sprintf("%d:%d.%d", a.Net, a.SubUni>>4, a.SubUni&0x0f)
 }
 
+// ParseAddress parses an address in the net:subnet.universe form returned by String
+func ParseAddress(s string) (Address, error) {
+	var n, sub, uni int
+	var rest string
+	if c, _ := fmt.Sscanf(s, "%d:%d.%d%s", &n, &sub, &uni, &rest); c != 3 {
+		return Address{}, fmt.Errorf("invalid address %q, expected net:subnet.universe", s)
+	}
+	if n < 0 || n > 0x7f || sub < 0 || sub > 0x0f || uni < 0 || uni > 0x0f {
+		return Address{}, fmt.Errorf("address %q out of range 0-127:0-15.0-15", s)
+	}
+	return Address{Net: uint8(n), SubUni: uint8(sub<<4 | uni)}, nil
+}
+
 // Integer returns the integer representation of Address
 func (a Address) Integer() int {
 	return int(uint16(a.Net)<<8 | uint16(a.SubUni))
@@ -58,39 +71,163 @@ type NodeConfig struct {
 	Status1 code.Status1
 	Status2 code.Status2
 
+	// BaseAddress holds the Net and Sub-Net switches of the node, SubUni is the
+	// Sub-Net switch itself (0-15) and not shifted into the high nibble like
+	// the Port-Address of a port.
 	BaseAddress Address
 	InputPorts  []InputPort
 	OutputPorts []OutputPort
 }
 
 // ArtPollReplyFromConfig will return a ArtPollReplyPacket from the NodeConfig
-// TODO: make this a more complete packet by adding the other NodeConfig fields
+// When the node has more than 4 ports, only the first page is returned. Use
+// ArtPollRepliesFromConfig to get the replies for all pages.
 func ArtPollReplyFromConfig(c NodeConfig) *packet.ArtPollReplyPacket {
-	p := &packet.ArtPollReplyPacket{
-		OpCode:      code.OpPollReply,
-		Port:        packet.ArtNetPort,
-		Oem:         c.OEM,
-		VersionInfo: c.Version,
-		UBEAVersion: c.BiosVersion,
-		Style:       c.Type,
-		BindIndex:   c.BindIndex,
-		Status1:     c.Status1,
-		Status2:     c.Status2,
-		NetSwitch:   c.BaseAddress.Net,
-		SubSwitch:   c.BaseAddress.SubUni,
-		NumPorts:    c.NumberOfPorts(),
-		PortTypes:   c.PortTypes(),
-	}
-
-	copy(p.IPAddress[0:4], c.IP.To4())
-	copy(p.ESTAmanufacturer[0:2], c.Manufacturer)
-	copy(p.ShortName[0:18], c.Name)
-	copy(p.LongName[0:64], c.Description)
-	copy(p.NodeReport[0:64], c.Report)
-	copy(p.BindIP[0:4], c.BindIP.To4())
-	copy(p.Macaddress[0:6], c.Ethernet)
-
-	return p
+	return ArtPollRepliesFromConfig(c)[0]
+}
+
+// ArtPollRepliesFromConfig will return an ArtPollReplyPacket for every page of the NodeConfig.
+// A single ArtPollReply holds at most 4 ports which share the Net and Sub-Net of their
+// Port-Address. Nodes with more ports announce themselves as bound nodes, one page per
+// reply, each identified by its BindIndex. This method assumes that NodeConfig is validated.
+func ArtPollRepliesFromConfig(c NodeConfig) []*packet.ArtPollReplyPacket {
+	pages := c.pages()
+	replies := make([]*packet.ArtPollReplyPacket, 0, len(pages))
+	for _, pg := range pages {
+		p := &packet.ArtPollReplyPacket{
+			OpCode:      code.OpPollReply,
+			Port:        packet.ArtNetPort,
+			Oem:         c.OEM,
+			VersionInfo: c.Version,
+			UBEAVersion: c.BiosVersion,
+			Style:       c.Type,
+			BindIndex:   pg.bindIndex,
+			Status1:     c.Status1,
+			Status2:     c.Status2,
+			NetSwitch:   pg.net,
+			SubSwitch:   pg.subNet,
+			NumPorts:    uint16(len(pg.slots)),
+		}
+
+		for i, slot := range pg.slots {
+			if slot.input >= 0 {
+				port := c.InputPorts[slot.input]
+				p.PortTypes[i] = p.PortTypes[i].WithInput(true).WithType(port.Type.Type())
+				p.GoodInput[i] = port.Status
+				p.SwIn[i] = port.Address.SubUni & 0x0f
+			}
+			if slot.output >= 0 {
+				port := c.OutputPorts[slot.output]
+				p.PortTypes[i] = p.PortTypes[i].WithOutput(true).WithType(port.Type.Type())
+				p.GoodOutput[i] = port.Status
+				p.SwOut[i] = port.Address.SubUni & 0x0f
+			}
+		}
+
+		bindIP := c.BindIP
+		if len(pages) > 1 && len(bindIP.To4()) == 0 {
+			// bound nodes share the IP of the root device
+			bindIP = c.IP
+		}
+
+		copy(p.IPAddress[0:4], c.IP.To4())
+		copy(p.ESTAmanufacturer[0:2], c.Manufacturer)
+		copy(p.ShortName[0:18], c.Name)
+		copy(p.LongName[0:64], c.Description)
+		copy(p.NodeReport[0:64], c.Report)
+		copy(p.BindIP[0:4], bindIP.To4())
+		copy(p.Macaddress[0:6], c.Ethernet)
+
+		replies = append(replies, p)
+	}
+
+	return replies
+}
+
+// portSlot is a single port in an ArtPollReply, it holds the index of the
+// input and/or output port of the NodeConfig. The index is -1 if unused.
+type portSlot struct {
+	input  int
+	output int
+}
+
+// page is the part of a NodeConfig announced in a single ArtPollReply
+type page struct {
+	bindIndex uint8
+	net       uint8
+	subNet    uint8
+	slots     []portSlot
+}
+
+// pages splits the ports of the NodeConfig into pages of at most 4 ports. Ports are
+// grouped by the Net and Sub-Net of their Port-Address, an input and output port with
+// the same index always share a page. This method assumes that NodeConfig is validated.
+func (c NodeConfig) pages() []page {
+	var pages []page
+	index := make(map[Address]int)
+
+	for i := 0; i < len(c.InputPorts) || i < len(c.OutputPorts); i++ {
+		slot := portSlot{input: -1, output: -1}
+		var address Address
+		if i < len(c.InputPorts) {
+			slot.input = i
+			address = c.InputPorts[i].Address
+		}
+		if i < len(c.OutputPorts) {
+			slot.output = i
+			address = c.OutputPorts[i].Address
+		}
+
+		key := Address{Net: address.Net, SubUni: address.SubUni & 0xf0}
+		pg, ok := index[key]
+		if !ok || len(pages[pg].slots) == 4 {
+			pages = append(pages, page{
+				net:    address.Net,
+				subNet: address.SubUni >> 4,
+			})
+			pg = len(pages) - 1
+			index[key] = pg
+		}
+		pages[pg].slots = append(pages[pg].slots, slot)
+	}
+
+	if len(pages) == 0 {
+		// a node without ports still announces itself
+		return []page{{
+			bindIndex: c.BindIndex,
+			net:       c.BaseAddress.Net,
+			subNet:    c.BaseAddress.SubUni & 0x0f,
+		}}
+	}
+
+	if len(pages) == 1 {
+		pages[0].bindIndex = c.BindIndex
+		return pages
+	}
+	for i := range pages {
+		// a value of 1 means root device
+		pages[i].bindIndex = uint8(i + 1)
+	}
+	return pages
+}
+
+// page returns the page with the given BindIndex. A node with a single
+// page answers to any BindIndex.
+func (c NodeConfig) page(bindIndex uint8) (page, bool) {
+	pages := c.pages()
+	if len(pages) == 1 {
+		return pages[0], true
+	}
+	if bindIndex == 0 {
+		// zero is used by controllers not aware of binding
+		bindIndex = 1
+	}
+	for _, pg := range pages {
+		if pg.bindIndex == bindIndex {
+			return pg, true
+		}
+	}
+	return page{}, false
 }
 
 // NumberOfPorts returns the count of node ports. This method assumes that
@@ -124,20 +261,18 @@ func (c NodeConfig) PortTypes() [4]code.PortType {
 // The main objective of this method is to check if the in- and output-ports configured
 // by the user can be announced on the Art-Net network. It checks:
 //
-//   - At max 4 in- and/or outputs are supported per node.
 //   - If a port supports in- and output at the same time the protocol type has to be
 //     the same for the input port of the same index as the output port.
+//   - An input and output port with the same index are announced in the same ArtPollReply,
+//     so their Port-Address must have the same Net and Sub-Net.
+//   - The ports fit in 255 pages, as every page is identified by its BindIndex.
+//
+// Nodes with more than 4 in- and/or outputs are announced as bound nodes.
 func (c NodeConfig) validate() error {
-	if len(c.InputPorts) > 4 {
-		return fmt.Errorf("validation error: more than 4 input ports configured (%d) for the node, this isn't supported by the library", len(c.InputPorts))
-	}
-	if len(c.OutputPorts) > 4 {
-		return fmt.Errorf("validation error: more than 4 output ports configured (%d) for the node, this isn't supported by the library", len(c.InputPorts))
+	if len(c.InputPorts) > 255*4 || len(c.OutputPorts) > 255*4 {
+		return fmt.Errorf("validation error: more than %d ports configured for the node, this isn't supported by the protocol", 255*4)
 	}
-	for i := 0; i < 4; i++ {
-		if len(c.InputPorts) <= i || len(c.OutputPorts) <= i {
-			continue
-		}
+	for i := 0; i < len(c.InputPorts) && i < len(c.OutputPorts); i++ {
 		if c.InputPorts[i].Type.Type() != c.OutputPorts[i].Type.Type() {
 			return fmt.Errorf(
 				"validation error: the type (%s) of input port %d has a different type (%s) than output port %d, input and output ports with the same index must have the same type",
@@ -147,6 +282,16 @@ func (c NodeConfig) validate() error {
 				i+1,
 			)
 		}
+		in, out := c.InputPorts[i].Address, c.OutputPorts[i].Address
+		if in.Net != out.Net || in.SubUni&0xf0 != out.SubUni&0xf0 {
+			return fmt.Errorf(
+				"validation error: the address (%s) of input port %d has a different Net or Sub-Net than the address (%s) of output port %d, input and output ports with the same index must share them",
+				in, i+1, out, i+1,
+			)
+		}
+	}
+	if pages := len(c.pages()); pages > 255 {
+		return fmt.Errorf("validation error: the ports of the node are announced in %d pages, more than the 255 BindIndexes supported by the protocol", pages)
 	}
 	return nil
 }
@@ -170,17 +315,18 @@ func ConfigFromArtPollReply(p packet.ArtPollReplyPacket) NodeConfig {
 		Status1:      p.Status1,
 		Status2:      p.Status2,
 		BaseAddress: Address{
-			Net:    p.NetSwitch,
-			SubUni: p.SubSwitch,
+			Net:    p.NetSwitch & 0x7f,
+			SubUni: p.SubSwitch & 0x0f,
 		},
 	}
+	subNet := nodeConfig.BaseAddress.SubUni << 4
 
 	for i := 0; i < int(p.NumPorts) && i < 4; i++ {
 		if p.PortTypes[i].Output() {
 			nodeConfig.OutputPorts = append(nodeConfig.OutputPorts, OutputPort{
 				Address: Address{
 					Net:    nodeConfig.BaseAddress.Net,
-					SubUni: nodeConfig.BaseAddress.SubUni | p.SwOut[i],
+					SubUni: subNet | p.SwOut[i]&0x0f,
 				},
 				Type:   p.PortTypes[i],
 				Status: p.GoodOutput[i],
@@ -190,7 +336,7 @@ func ConfigFromArtPollReply(p packet.ArtPollReplyPacket) NodeConfig {
 			nodeConfig.InputPorts = append(nodeConfig.InputPorts, InputPort{
 				Address: Address{
 					Net:    nodeConfig.BaseAddress.Net,
-					SubUni: nodeConfig.BaseAddress.SubUni | p.SwIn[i],
+					SubUni: subNet | p.SwIn[i]&0x0f,
 				},
 				Type:   p.PortTypes[i],
 				Status: p.GoodInput[i],
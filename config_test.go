@@ -0,0 +1,195 @@
+package artnet
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/jsimonetti/go-artnet/packet"
+	"github.com/jsimonetti/go-artnet/packet/code"
+)
+
+func TestArtPollRepliesFromConfig(t *testing.T) {
+	dmx := new(code.PortType).WithType("DMX512").WithOutput(true)
+
+	outputs := func(addresses ...Address) (ports []OutputPort) {
+		for _, a := range addresses {
+			ports = append(ports, OutputPort{Address: a, Type: dmx})
+		}
+		return
+	}
+
+	tests := []struct {
+		name  string
+		c     NodeConfig
+		pages []NodeConfig
+	}{
+		{
+			name: "SinglePage",
+			c: NodeConfig{
+				IP:          net.IP{2, 0, 0, 1},
+				OutputPorts: outputs(Address{Net: 1, SubUni: 0x21}, Address{Net: 1, SubUni: 0x22}),
+			},
+			pages: []NodeConfig{
+				{
+					BindIndex:   0,
+					OutputPorts: outputs(Address{Net: 1, SubUni: 0x21}, Address{Net: 1, SubUni: 0x22}),
+				},
+			},
+		},
+		{
+			name: "SixPorts",
+			c: NodeConfig{
+				IP: net.IP{2, 0, 0, 1},
+				OutputPorts: outputs(
+					Address{SubUni: 0x00}, Address{SubUni: 0x01}, Address{SubUni: 0x02},
+					Address{SubUni: 0x03}, Address{SubUni: 0x04}, Address{SubUni: 0x05},
+				),
+			},
+			pages: []NodeConfig{
+				{
+					BindIndex:   1,
+					OutputPorts: outputs(Address{SubUni: 0x00}, Address{SubUni: 0x01}, Address{SubUni: 0x02}, Address{SubUni: 0x03}),
+				},
+				{
+					BindIndex:   2,
+					OutputPorts: outputs(Address{SubUni: 0x04}, Address{SubUni: 0x05}),
+				},
+			},
+		},
+		{
+			name: "SplitBySubNet",
+			c: NodeConfig{
+				IP:          net.IP{2, 0, 0, 1},
+				OutputPorts: outputs(Address{SubUni: 0x00}, Address{SubUni: 0x10}, Address{SubUni: 0x01}),
+			},
+			pages: []NodeConfig{
+				{
+					BindIndex:   1,
+					OutputPorts: outputs(Address{SubUni: 0x00}, Address{SubUni: 0x01}),
+				},
+				{
+					BindIndex:   2,
+					OutputPorts: outputs(Address{SubUni: 0x10}),
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.c.validate(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			replies := ArtPollRepliesFromConfig(tt.c)
+			if want, got := len(tt.pages), len(replies); want != got {
+				t.Fatalf("unexpected number of pages:\n- want: %d\n-  got: %d", want, got)
+			}
+
+			for i, p := range replies {
+				cfg := ConfigFromArtPollReply(*p)
+				if want, got := tt.pages[i].BindIndex, cfg.BindIndex; want != got {
+					t.Fatalf("page %d: unexpected BindIndex:\n- want: %d\n-  got: %d", i, want, got)
+				}
+				if want, got := tt.pages[i].OutputPorts, cfg.OutputPorts; !reflect.DeepEqual(want, got) {
+					t.Fatalf("page %d: unexpected output ports:\n- want: %v\n-  got: %v", i, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigFromArtPollReplyAddress(t *testing.T) {
+	dmx := new(code.PortType).WithType("DMX512")
+	p := packet.ArtPollReplyPacket{
+		NetSwitch: 1,
+		SubSwitch: 2,
+		NumPorts:  1,
+		PortTypes: [4]code.PortType{dmx.WithInput(true).WithOutput(true)},
+		SwIn:      [4]uint8{4},
+		SwOut:     [4]uint8{3},
+	}
+
+	// the base address holds the switches as they are, the ports their Port-Address
+	cfg := ConfigFromArtPollReply(p)
+	if want, got := (Address{Net: 1, SubUni: 2}), cfg.BaseAddress; want != got {
+		t.Fatalf("unexpected base address:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := (Address{Net: 1, SubUni: 0x23}), cfg.OutputPorts[0].Address; want != got {
+		t.Fatalf("unexpected output address:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := (Address{Net: 1, SubUni: 0x24}), cfg.InputPorts[0].Address; want != got {
+		t.Fatalf("unexpected input address:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// a node without ports announces its base address
+	reply := ArtPollReplyFromConfig(NodeConfig{BaseAddress: cfg.BaseAddress})
+	if want, got := [2]uint8{1, 2}, [2]uint8{reply.NetSwitch, reply.SubSwitch}; want != got {
+		t.Fatalf("unexpected switches:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		s  string
+		a  Address
+		ok bool
+	}{
+		{s: "0:0.0", a: Address{}, ok: true},
+		{s: "1:2.3", a: Address{Net: 1, SubUni: 0x23}, ok: true},
+		{s: "127:15.15", a: Address{Net: 127, SubUni: 0xff}, ok: true},
+		{s: "128:0.0"},
+		{s: "0:16.0"},
+		{s: "0:0.1x"},
+		{s: "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			a, err := ParseAddress(tt.s)
+			if want, got := tt.ok, err == nil; want != got {
+				t.Fatalf("unexpected parse result:\n- want: %v\n-  got: %v (%v)", want, got, err)
+			}
+			if want, got := tt.a, a; want != got {
+				t.Fatalf("unexpected address:\n- want: %v\n-  got: %v", want, got)
+			}
+			if tt.ok && a.String() != tt.s {
+				t.Fatalf("unexpected round trip:\n- want: %s\n-  got: %s", tt.s, a)
+			}
+		})
+	}
+}
+
+func TestValidatePages(t *testing.T) {
+	dmx := new(code.PortType).WithType("DMX512").WithOutput(true)
+
+	// every port in its own Net and Sub-Net needs a page of its own
+	outputs := func(n int) (ports []OutputPort) {
+		for i := 0; i < n; i++ {
+			ports = append(ports, OutputPort{
+				Address: Address{Net: uint8(i / 16), SubUni: uint8(i%16) << 4},
+				Type:    dmx,
+			})
+		}
+		return
+	}
+
+	tests := []struct {
+		name  string
+		ports int
+		ok    bool
+	}{
+		{name: "MaxPages", ports: 255, ok: true},
+		{name: "TooManyPages", ports: 256},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NodeConfig{IP: net.IP{2, 0, 0, 1}, OutputPorts: outputs(tt.ports)}.validate()
+			if want, got := tt.ok, err == nil; want != got {
+				t.Fatalf("unexpected validation result:\n- want: %v\n-  got: %v (%v)", want, got, err)
+			}
+		})
+	}
+}
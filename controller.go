@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"net"
+	"sort"
 	"sync"
 	"time"
 
@@ -19,60 +20,151 @@ var defaultBroadcastAddr = net.UDPAddr{
 // we poll for new nodes every 3 seconds
 var pollInterval = 3 * time.Second
 
-// ControlledNode hols the configuration of a node we control
+// universes with more than 40 subscribed nodes are broadcast, as the specification allows
+var defaultBroadcastThreshold = 40
+
+// unchanged data is sent again every 900ms, the specification recommends 800ms to 1000ms
+var defaultKeepAlive = 900 * time.Millisecond
+
+// ControlledNode hols the configuration of a node we control. A bound node
+// announces its ports in multiple pages, Node holds the ports of all pages.
 type ControlledNode struct {
 	LastSeen   time.Time
 	Node       NodeConfig
 	UDPAddress net.UDPAddr
 
-	Sequence  uint8
-	DMXBuffer map[Address]*dmxBuffer
-	nodeLock  sync.Mutex
+	// Pages holds the ArtPollReply pages of the node ordered by BindIndex
+	Pages []NodePage
 }
 
-type dmxBuffer struct {
-	Data       [512]byte
-	LastUpdate time.Time
-	Stale      bool
+// NodePage holds a single ArtPollReply page of a (bound) node
+type NodePage struct {
+	LastSeen   time.Time
+	Node       NodeConfig
+	UDPAddress net.UDPAddr
 }
 
-// setDMXBuffer will update the buffer on a universe address
-func (cn *ControlledNode) setDMXBuffer(dmx [512]byte, address Address) error {
-	cn.nodeLock.Lock()
-	defer cn.nodeLock.Unlock()
+// rootIP returns the IP identifying the device a page belongs to. Bound nodes
+// use the IP of the root device as BindIP.
+func rootIP(cfg NodeConfig) net.IP {
+	if ip := cfg.BindIP.To4(); ip != nil && !ip.Equal(net.IPv4zero) {
+		return ip
+	}
+	return cfg.IP
+}
 
-	var buf *dmxBuffer
-	var ok bool
+// updatePage adds or replaces the page with the BindIndex of cfg
+// and rebuilds the ports of the node from all pages
+func (cn *ControlledNode) updatePage(cfg NodeConfig, now time.Time) {
+	page := NodePage{
+		LastSeen:   now,
+		Node:       cfg,
+		UDPAddress: net.UDPAddr{IP: cfg.IP, Port: packet.ArtNetPort},
+	}
 
-	if buf, ok = cn.DMXBuffer[address]; !ok {
-		return fmt.Errorf("unknown address for controlled node")
+	i := sort.Search(len(cn.Pages), func(i int) bool {
+		return cn.Pages[i].Node.BindIndex >= cfg.BindIndex
+	})
+	if i < len(cn.Pages) && cn.Pages[i].Node.BindIndex == cfg.BindIndex {
+		cn.Pages[i] = page
+	} else {
+		cn.Pages = append(cn.Pages, NodePage{})
+		copy(cn.Pages[i+1:], cn.Pages[i:])
+		cn.Pages[i] = page
 	}
+	cn.rebuild()
+}
 
-	buf.Data = dmx
-	buf.Stale = true
+// expirePages removes the pages not seen since staleAfter
+// and reports if any page is left
+func (cn *ControlledNode) expirePages(now time.Time, staleAfter time.Duration) bool {
+	pages := cn.Pages[:0]
+	for _, page := range cn.Pages {
+		if page.LastSeen.Add(staleAfter).Before(now) {
+			continue
+		}
+		pages = append(pages, page)
+	}
+	cn.Pages = pages
+	if len(cn.Pages) > 0 {
+		cn.rebuild()
+	}
+	return len(cn.Pages) > 0
+}
 
-	return nil
+// rebuild merges the pages into the config of the node
+func (cn *ControlledNode) rebuild() {
+	cfg := cn.Pages[0].Node
+	cfg.InputPorts = nil
+	cfg.OutputPorts = nil
+	for _, page := range cn.Pages {
+		cfg.InputPorts = append(cfg.InputPorts, page.Node.InputPorts...)
+		cfg.OutputPorts = append(cfg.OutputPorts, page.Node.OutputPorts...)
+		if page.LastSeen.After(cn.LastSeen) {
+			cn.LastSeen = page.LastSeen
+		}
+	}
+	cn.Node = cfg
+	cn.UDPAddress = cn.Pages[0].UDPAddress
 }
 
-// dmxUpdate will create an ArtDMXPacket and marshal it into bytes
-func (cn *ControlledNode) dmxUpdate(address Address) (b []byte, err error) {
-	var buf *dmxBuffer
-	var ok bool
+// udpAddress returns the address of the page with an output port for address
+func (cn *ControlledNode) udpAddress(address Address) net.UDPAddr {
+	for _, page := range cn.Pages {
+		for _, port := range page.Node.OutputPorts {
+			if port.Address == address {
+				return page.UDPAddress
+			}
+		}
+	}
+	return cn.UDPAddress
+}
 
-	cn.nodeLock.Lock()
-	defer cn.nodeLock.Unlock()
+// dmxBuffer holds the DMX output of a single universe
+type dmxBuffer struct {
+	// Data holds the data written to the universe, it is the base below all mixer layers
+	Data Frame
+	// changed holds the time each channel of Data was last changed
+	changed [512]time.Time
+
+	// layers holds the mixer layers ordered by priority, Output holds the mix that is sent
+	layers   []*Layer
+	Output   Frame
+	mixDirty bool
+
+	// address is the address of the universe, masters scale its output. Only the
+	// intensity channels are scaled by the grand master and blackout. nil means none,
+	// the blackout then turns off all channels.
+	address   Address
+	masters   *masters
+	intensity *[512]bool
 
-	if buf, ok = cn.DMXBuffer[address]; !ok {
-		return nil, fmt.Errorf("unknown address for controlled node")
+	LastUpdate time.Time
+	Stale      bool
+	Sequence   uint8
+}
+
+// set sets a channel of the base data
+func (buf *dmxBuffer) set(ch int, v uint8, now time.Time) {
+	if buf.Data[ch] != v {
+		buf.Data[ch] = v
+		buf.changed[ch] = now
+		buf.mixDirty = true
 	}
+}
 
-	cn.Sequence++
+// dmxUpdate will create an ArtDMXPacket for the universe and marshal it into bytes
+func (buf *dmxBuffer) dmxUpdate(address Address) (b []byte, err error) {
+	// the sequence runs from 1 to 255, 0 disables sequencing
+	buf.Sequence++
+	if buf.Sequence == 0 {
+		buf.Sequence = 1
+	}
 	p := &packet.ArtDMXPacket{
-		Sequence: cn.Sequence,
+		Sequence: buf.Sequence,
 		SubUni:   address.SubUni,
 		Net:      address.Net,
-		Length:   uint16(len(cn.DMXBuffer)),
-		Data:     buf.Data,
+		Data:     [512]byte(buf.Output),
 	}
 	b, err = p.MarshalBinary()
 	return
@@ -84,11 +176,24 @@ type Controller struct {
 	cNode *Node
 
 	// Nodes is a slice of nodes that are seen by this controller
-	Nodes         []*ControlledNode
-	OutputAddress map[Address]*ControlledNode
-	InputAddress  map[Address]*ControlledNode
+	Nodes []*ControlledNode
+	// OutputAddress holds the nodes subscribed to a universe address
+	OutputAddress map[Address][]*ControlledNode
+	InputAddress  map[Address][]*ControlledNode
 	nodeLock      sync.Mutex
 
+	// universes holds the DMX buffer of every universe we output
+	universes map[Address]*dmxBuffer
+	// fader runs the fades on the universes
+	fader *fader
+	// masters holds the grand master, blackout and submasters
+	masters *masters
+
+	// frameHooks are called at the start of every frame
+	frameHooks  map[int]FrameFn
+	frameHookID int
+	frameLock   sync.Mutex
+
 	broadcastAddr net.UDPAddr
 
 	shutdownCh chan struct{}
@@ -96,21 +201,45 @@ type Controller struct {
 	maxFPS int
 	log    Logger
 
+	// matchMAC also compares the MAC address to identify a node
+	matchMAC bool
+
+	// syncOutput sends an ArtSync after the universes of a frame have been sent
+	syncOutput bool
+
+	// broadcastThreshold is the number of subscribed nodes above which a universe is broadcast
+	broadcastThreshold int
+	// broadcastUnknown broadcasts universes without any subscribed nodes
+	broadcastUnknown bool
+	// keepAlive is the interval at which unchanged data is sent again
+	keepAlive time.Duration
+
 	pollTicker *time.Ticker
 	gcTicker   *time.Ticker
 }
 
-// NewController return a Controller
+// NewController return a Controller. Options that fail are logged and ignored,
+// use SetOption to handle their errors.
 func NewController(name string, ip net.IP, log Logger, opts ...Option) *Controller {
 	c := &Controller{
-		cNode:         NewNode(name, code.StController, ip, log),
-		log:           log,
-		maxFPS:        1000,
-		broadcastAddr: defaultBroadcastAddr,
+		cNode:              NewNode(name, code.StController, ip, log),
+		log:                log,
+		maxFPS:             1000,
+		broadcastAddr:      defaultBroadcastAddr,
+		broadcastThreshold: defaultBroadcastThreshold,
+		keepAlive:          defaultKeepAlive,
+		OutputAddress:      make(map[Address][]*ControlledNode),
+		InputAddress:       make(map[Address][]*ControlledNode),
+		universes:          make(map[Address]*dmxBuffer),
+		fader:              newFader(),
+		masters:            newMasters(),
+		frameHooks:         make(map[int]FrameFn),
 	}
 
 	for _, opt := range opts {
-		c.SetOption(opt)
+		if err := c.SetOption(opt); err != nil {
+			c.log.With(Fields{"err": err}).Error("ignoring invalid controller option")
+		}
 	}
 
 	return c
@@ -118,8 +247,6 @@ func NewController(name string, ip net.IP, log Logger, opts ...Option) *Controll
 
 // Start will start this controller
 func (c *Controller) Start() error {
-	c.OutputAddress = make(map[Address]*ControlledNode)
-	c.InputAddress = make(map[Address]*ControlledNode)
 	c.shutdownCh = make(chan struct{})
 	c.cNode.log = c.log.With(Fields{"type": "Node"})
 	c.log = c.log.With(Fields{"type": "Controller"})
@@ -150,58 +277,20 @@ func (c *Controller) Stop() {
 
 // pollLoop will routinely poll for new nodes
 func (c *Controller) pollLoop() {
-	artPoll := &packet.ArtPollPacket{
-		TalkToMe: new(code.TalkToMe).WithReplyOnChange(true),
-		Priority: code.DpAll,
-	}
-
-	// create an ArtPoll packet to send out periodically
-	b, err := artPoll.MarshalBinary()
-	if err != nil {
-		c.log.With(Fields{"err": err}).Error("error creating ArtPoll packet")
-		return
-	}
-
-	// send ArtPollPacket
-	c.cNode.sendCh <- netPayload{
-		address: c.broadcastAddr,
-		data:    b,
-	}
-	c.cNode.pollCh <- packet.ArtPollPacket{}
+	c.poll()
 
 	// loop until shutdown
 	for {
 		select {
 		case <-c.pollTicker.C:
-			// send ArtPollPacket
-			c.cNode.sendCh <- netPayload{
-				address: c.broadcastAddr,
-				data:    b,
-			}
-			c.cNode.pollCh <- packet.ArtPollPacket{}
+			c.poll()
 
 		case <-c.gcTicker.C:
 			// clean up old nodes
 			c.gcNode()
 
 		case p := <-c.cNode.pollReplyCh:
-			cfg := ConfigFromArtPollReply(p)
-
-			if cfg.Type != code.StNode && cfg.Type != code.StController {
-				// we don't care for ArtNet devices other then nodes and controllers for now @todo
-				continue
-			}
-
-			if cfg.Type == code.StController && len(cfg.OutputPorts) == 0 {
-				// we don't care for controllers which do not have output ports for now // @todo
-				// otherwise we simply treat controllers like nodes unless controller to controller
-				// communication is implemented according to Art-Net specification
-				continue
-			}
-
-			if err := c.updateNode(cfg); err != nil {
-				c.log.With(Fields{"err": err}).Error("error updating node")
-			}
+			c.handlePollReply(p)
 
 		case <-c.shutdownCh:
 			return
@@ -209,79 +298,133 @@ func (c *Controller) pollLoop() {
 	}
 }
 
-// SendDMXToAddress will set the DMXBuffer for a destination address
-// and update the node
-func (c *Controller) SendDMXToAddress(dmx [512]byte, address Address) {
-	c.log.With(Fields{"address": address.String()}).Debug("received update channels")
+// poll broadcasts an ArtPoll and announces the controller itself
+func (c *Controller) poll() {
+	artPoll := &packet.ArtPollPacket{
+		TalkToMe: new(code.TalkToMe).WithReplyOnChange(true),
+		Priority: code.DpAll,
+	}
+	b, err := artPoll.MarshalBinary()
+	if err != nil {
+		c.log.With(Fields{"err": err}).Error("error creating ArtPoll packet")
+		return
+	}
 
-	c.nodeLock.Lock()
-	defer c.nodeLock.Unlock()
+	c.cNode.transport.send(c.broadcastAddr, b)
+	c.cNode.transport.pollReply()
+}
 
-	var cn *ControlledNode
-	var ok bool
+// handlePollReply adds or updates the node that sent an ArtPollReply
+func (c *Controller) handlePollReply(p packet.ArtPollReplyPacket) {
+	cfg := ConfigFromArtPollReply(p)
 
-	if cn, ok = c.OutputAddress[address]; !ok {
-		c.log.With(Fields{"address": address.String()}).Error("could not find node for address")
+	if cfg.Type != code.StNode && cfg.Type != code.StController {
+		// we don't care for ArtNet devices other then nodes and controllers for now @todo
 		return
 	}
-	err := cn.setDMXBuffer(dmx, address)
-	if err != nil {
-		c.log.With(Fields{"err": err, "address": address.String()}).Error("error setting buffer on address")
+
+	if cfg.Type == code.StController && len(cfg.OutputPorts) == 0 {
+		// we don't care for controllers which do not have output ports for now // @todo
+		// otherwise we simply treat controllers like nodes unless controller to controller
+		// communication is implemented according to Art-Net specification
 		return
 	}
-}
 
-// dmxUpdateLoop will periodically update nodes until shutdown
-func (c *Controller) dmxUpdateLoop() {
-	fpsInterval := time.Duration(c.maxFPS)
-	ticker := time.NewTicker(time.Second / fpsInterval)
+	if err := c.updateNode(cfg); err != nil {
+		c.log.With(Fields{"err": err}).Error("error updating node")
+	}
+}
 
-	forceUpdate := 250 * time.Millisecond
+// SendDMXToAddress will set the DMX buffer for a destination address
+// and update all nodes subscribed to it
+func (c *Controller) SendDMXToAddress(dmx [512]byte, address Address) error {
+	c.log.With(Fields{"address": address.String()}).Debug("received update channels")
 
-	update := func(node *ControlledNode, address Address, now time.Time) error {
-		// get an ArtDMXPacket for this node
-		b, err := node.dmxUpdate(address)
-		if err != nil {
-			return err
-		}
-		node.DMXBuffer[address].LastUpdate = now
-		node.DMXBuffer[address].Stale = false
+	u, err := c.Universe(address)
+	if err != nil {
+		return err
+	}
+	return u.Update(func(f *Frame) error {
+		*f = dmx
+		return nil
+	})
+}
 
-		c.cNode.sendCh <- netPayload{
-			address: node.UDPAddress,
-			data:    b,
+// destinations returns the addresses to send the data of a universe to. The
+// subscribed nodes are sent unicast, unless there are more than broadcastThreshold
+// of them, or none at all and broadcastUnknown is set.
+func (c *Controller) destinations(address Address, nodes []*ControlledNode) []net.UDPAddr {
+	if len(nodes) == 0 {
+		if c.broadcastUnknown {
+			return []net.UDPAddr{c.broadcastAddr}
 		}
 		return nil
 	}
+	if c.broadcastThreshold > 0 && len(nodes) > c.broadcastThreshold {
+		return []net.UDPAddr{c.broadcastAddr}
+	}
+
+	dst := make([]net.UDPAddr, 0, len(nodes))
+	for _, node := range nodes {
+		dst = append(dst, node.udpAddress(address))
+	}
+	return dst
+}
+
+// FrameFn is called at the start of every frame, before the universes are sent out
+type FrameFn func(now time.Time)
+
+// OnFrame registers fn to be called at the start of every frame, on the same clock the
+// universes are sent out with. Hooks are called in the order they were registered.
+// The returned function removes the hook.
+func (c *Controller) OnFrame(fn FrameFn) (remove func()) {
+	c.frameLock.Lock()
+	defer c.frameLock.Unlock()
+
+	c.frameHookID++
+	id := c.frameHookID
+	c.frameHooks[id] = fn
+
+	return func() {
+		c.frameLock.Lock()
+		defer c.frameLock.Unlock()
+		delete(c.frameHooks, id)
+	}
+}
+
+// runFrameHooks calls the frame hooks in the order they were registered
+func (c *Controller) runFrameHooks(now time.Time) {
+	c.frameLock.Lock()
+	ids := make([]int, 0, len(c.frameHooks))
+	for id := range c.frameHooks {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	hooks := make([]FrameFn, len(ids))
+	for i, id := range ids {
+		hooks[i] = c.frameHooks[id]
+	}
+	c.frameLock.Unlock()
+
+	for _, fn := range hooks {
+		fn(now)
+	}
+}
+
+// frameInterval returns the time between two frames
+func (c *Controller) frameInterval() time.Duration {
+	return time.Second / time.Duration(c.maxFPS)
+}
+
+// dmxUpdateLoop will periodically update nodes until shutdown
+func (c *Controller) dmxUpdateLoop() {
+	ticker := time.NewTicker(c.frameInterval())
 
 	// loop until shutdown
 	for {
 		select {
 		case <-ticker.C:
-			now := time.Now()
-			// send DMX buffer update
-			c.nodeLock.Lock()
-			for address, node := range c.OutputAddress {
-				if node.DMXBuffer[address] == nil {
-					node.DMXBuffer[address] = &dmxBuffer{}
-				}
-				// only update if it has been X seconds
-				if node.DMXBuffer[address].Stale && node.DMXBuffer[address].LastUpdate.Before(now.Add(-fpsInterval)) {
-					err := update(node, address, now)
-					if err != nil {
-						c.log.With(Fields{"err": err, "address": address.String()}).Error("error getting buffer for address")
-						continue
-					}
-				}
-				if node.DMXBuffer[address].LastUpdate.Before(now.Add(-forceUpdate)) {
-					err := update(node, address, now)
-					if err != nil {
-						c.log.With(Fields{"err": err, "address": address.String()}).Error("error getting buffer for address")
-						continue
-					}
-				}
-			}
-			c.nodeLock.Unlock()
+			c.frame(time.Now())
 
 		case <-c.shutdownCh:
 			return
@@ -289,63 +432,167 @@ func (c *Controller) dmxUpdateLoop() {
 	}
 }
 
-// updateNode will add a Node to the list of known nodes
-// this assumes that there are no universe address collisions
-// in the future we should probably be prepared to handle that too
+// frame runs the frame hooks and fades, and sends the universes that are due at now
+func (c *Controller) frame(now time.Time) {
+	fpsInterval := c.frameInterval()
+	sent := false
+	// let the frame hooks update the universes
+	c.runFrameHooks(now)
+	// send DMX buffer update
+	c.nodeLock.Lock()
+	// advance the fades to this frame
+	c.fader.step(now, c.universe)
+	for address, buf := range c.universes {
+		// mix the layers into the output
+		buf.remix(now)
+
+		dst := c.destinations(address, c.OutputAddress[address])
+		if len(dst) == 0 {
+			continue
+		}
+		// send changed data at most once per frame, and unchanged data
+		// once every keep-alive interval
+		if (buf.Stale && buf.LastUpdate.Before(now.Add(-fpsInterval))) ||
+			buf.LastUpdate.Before(now.Add(-c.keepAlive)) {
+			// get an ArtDMXPacket for this universe
+			b, err := buf.dmxUpdate(address)
+			if err != nil {
+				c.log.With(Fields{"err": err, "address": address.String()}).Error("error getting buffer for address")
+				continue
+			}
+			buf.LastUpdate = now
+			buf.Stale = false
+
+			// and send it to every destination
+			for _, addr := range dst {
+				c.cNode.transport.send(addr, b)
+			}
+			sent = true
+		}
+	}
+	c.nodeLock.Unlock()
+
+	if sent && c.syncOutput {
+		// transfer all universes of this frame to the outputs at once
+		artSync, err := (&packet.ArtSyncPacket{}).MarshalBinary()
+		if err != nil {
+			c.log.With(Fields{"err": err}).Error("error creating ArtSync packet")
+			return
+		}
+		c.cNode.transport.send(c.broadcastAddr, artSync)
+	}
+}
+
+// updateNode will add a Node to the list of known nodes. Pages of a bound node
+// are identified by the IP of the root device and their BindIndex, and are
+// grouped into a single node. Multiple nodes may subscribe to the same universe.
 func (c *Controller) updateNode(cfg NodeConfig) error {
 	c.nodeLock.Lock()
 	defer c.nodeLock.Unlock()
 
+	now := c.cNode.clock.Now()
+	ip := rootIP(cfg)
+
 	for i := range c.Nodes {
-		if bytes.Equal(cfg.IP, c.Nodes[i].Node.IP) {
-			// update this node, since we already know about it
-			c.log.With(Fields{"node": cfg.Name, "ip": cfg.IP.String()}).Debug("updated node")
-			// remove references to this node from the output map
-			for _, port := range c.Nodes[i].Node.OutputPorts {
-				delete(c.OutputAddress, port.Address)
-			}
-			for _, port := range c.Nodes[i].Node.InputPorts {
-				delete(c.InputAddress, port.Address)
-			}
-			c.Nodes[i].Node = cfg
-			c.Nodes[i].LastSeen = time.Now()
-			// add references to this node to the output map
-			for _, port := range c.Nodes[i].Node.OutputPorts {
-				c.OutputAddress[port.Address] = c.Nodes[i]
-			}
-			for _, port := range c.Nodes[i].Node.InputPorts {
-				c.InputAddress[port.Address] = c.Nodes[i]
-			}
-			return nil
+		if !ip.Equal(rootIP(c.Nodes[i].Node)) {
+			continue
+		}
+		if c.matchMAC && !sameMAC(cfg.Ethernet, c.Nodes[i].Node.Ethernet) {
+			// another device has taken over this IP, forget the old one
+			c.log.With(Fields{"node": c.Nodes[i].Node.Name, "ip": ip.String()}).Debug("replaced node")
+			c.forgetNode(i)
+			break
 		}
-	}
 
-	// create an empty DMX buffer. This will blackout the node entirely
-	buf := make(map[Address]*dmxBuffer)
-	for _, port := range cfg.OutputPorts {
-		buf[port.Address] = &dmxBuffer{}
+		// update this node, since we already know about it
+		c.log.With(Fields{"node": cfg.Name, "ip": ip.String(), "bindIndex": cfg.BindIndex}).Debug("updated node")
+		// remove references to this node from the output map
+		c.unmapNode(c.Nodes[i])
+		c.Nodes[i].updatePage(cfg, now)
+		// add references to this node to the output map
+		c.mapNode(c.Nodes[i])
+		return nil
 	}
 
 	// new node, add it to our known nodes
-	c.log.With(Fields{"node": cfg.Name, "ip": cfg.IP.String()}).Debug("added node")
-	node := &ControlledNode{
-		Node:       cfg,
-		DMXBuffer:  buf,
-		LastSeen:   time.Now(),
-		Sequence:   0,
-		UDPAddress: net.UDPAddr{IP: cfg.IP, Port: packet.ArtNetPort},
-	}
+	c.log.With(Fields{"node": cfg.Name, "ip": ip.String(), "bindIndex": cfg.BindIndex}).Debug("added node")
+	node := &ControlledNode{}
+	node.updatePage(cfg, now)
 	c.Nodes = append(c.Nodes, node)
 
 	// add references to this node to the output map
+	c.mapNode(node)
+
+	return nil
+}
+
+// mapNode subscribes the node to the universes of its ports
+func (c *Controller) mapNode(node *ControlledNode) {
+	for _, port := range node.Node.OutputPorts {
+		c.OutputAddress[port.Address] = addNode(c.OutputAddress[port.Address], node)
+		c.universe(port.Address)
+	}
+	for _, port := range node.Node.InputPorts {
+		c.InputAddress[port.Address] = addNode(c.InputAddress[port.Address], node)
+	}
+}
+
+// unmapNode unsubscribes the node from the universes of its ports
+func (c *Controller) unmapNode(node *ControlledNode) {
 	for _, port := range node.Node.OutputPorts {
-		c.OutputAddress[port.Address] = node
+		c.OutputAddress[port.Address] = removeNode(c.OutputAddress[port.Address], node)
+		if len(c.OutputAddress[port.Address]) == 0 {
+			delete(c.OutputAddress, port.Address)
+		}
 	}
 	for _, port := range node.Node.InputPorts {
-		c.InputAddress[port.Address] = node
+		c.InputAddress[port.Address] = removeNode(c.InputAddress[port.Address], node)
+		if len(c.InputAddress[port.Address]) == 0 {
+			delete(c.InputAddress, port.Address)
+		}
 	}
+}
 
-	return nil
+// addNode adds node to the set of nodes, if it is not in there already
+func addNode(nodes []*ControlledNode, node *ControlledNode) []*ControlledNode {
+	for _, n := range nodes {
+		if n == node {
+			return nodes
+		}
+	}
+	return append(nodes, node)
+}
+
+// removeNode removes node from the set of nodes
+func removeNode(nodes []*ControlledNode, node *ControlledNode) []*ControlledNode {
+	for i, n := range nodes {
+		if n == node {
+			return append(nodes[:i:i], nodes[i+1:]...)
+		}
+	}
+	return nodes
+}
+
+// forgetNode removes the node with index i from the list of known nodes
+func (c *Controller) forgetNode(i int) {
+	c.unmapNode(c.Nodes[i])
+	c.Nodes = append(c.Nodes[:i], c.Nodes[i+1:]...)
+}
+
+// sameMAC compares two MAC addresses, an unknown (zero) MAC matches any
+func sameMAC(a, b net.HardwareAddr) bool {
+	zero := func(mac net.HardwareAddr) bool {
+		for _, b := range mac {
+			if b != 0 {
+				return false
+			}
+		}
+		return true
+	}
+	if zero(a) || zero(b) {
+		return true
+	}
+	return bytes.Equal(a, b)
 }
 
 // deleteNode will delete a Node from the list of known nodes
@@ -353,25 +600,21 @@ func (c *Controller) deleteNode(node NodeConfig) error {
 	c.nodeLock.Lock()
 	defer c.nodeLock.Unlock()
 
+	ip := rootIP(node)
 	for i := range c.Nodes {
-		if bytes.Equal(node.IP, c.Nodes[i].Node.IP) {
+		if ip.Equal(rootIP(c.Nodes[i].Node)) {
 			// node found, remove it from the list
-			// remove references to this node from the output map
-			for _, port := range c.Nodes[i].Node.OutputPorts {
-				delete(c.OutputAddress, port.Address)
-			}
-			for _, port := range c.Nodes[i].Node.InputPorts {
-				delete(c.InputAddress, port.Address)
-			}
-			c.Nodes = append(c.Nodes[:i], c.Nodes[i+1:]...)
+			c.forgetNode(i)
+			return nil
 		}
 	}
 
-	return fmt.Errorf("no known node with this ip known, ip: %s", node.IP)
+	return fmt.Errorf("no known node with this ip known, ip: %s", ip)
 }
 
 // gcNode will remove stale Nodes from the list of known nodes
-// it will loop through the list of nodes and remove nodes older then X seconds
+// it will loop through the list of nodes and remove pages older then X seconds
+// a node is removed when none of its pages are left
 func (c *Controller) gcNode() {
 	c.nodeLock.Lock()
 	defer c.nodeLock.Unlock()
@@ -379,23 +622,19 @@ func (c *Controller) gcNode() {
 	// nodes are stale after 5 missed ArtPoll's
 	//staleAfter, _ := time.ParseDuration(fmt.Sprintf("%ds", 5*pollInterval))
 	staleAfter := 7 * time.Second
-
-start:
-	for i := range c.Nodes {
-		if c.Nodes[i].LastSeen.Add(staleAfter).Before(time.Now()) {
-			// it has been more then X seconds since we saw this node. remove it now.
-			c.log.With(Fields{"node": c.Nodes[i].Node.Name, "ip": c.Nodes[i].Node.IP.String()}).Debug("remove stale node")
-
-			// remove references to this node from the output map
-			for _, port := range c.Nodes[i].Node.OutputPorts {
-				delete(c.OutputAddress, port.Address)
-			}
-			for _, port := range c.Nodes[i].Node.InputPorts {
-				delete(c.InputAddress, port.Address)
-			}
-			// remove node
-			c.Nodes = append(c.Nodes[:i], c.Nodes[i+1:]...)
-			goto start
+	now := c.cNode.clock.Now()
+
+	for i := 0; i < len(c.Nodes); i++ {
+		node := c.Nodes[i]
+		c.unmapNode(node)
+		if node.expirePages(now, staleAfter) {
+			c.mapNode(node)
+			continue
 		}
+
+		// it has been more then X seconds since we saw this node. remove it now.
+		c.log.With(Fields{"node": node.Node.Name, "ip": node.Node.IP.String()}).Debug("remove stale node")
+		c.Nodes = append(c.Nodes[:i], c.Nodes[i+1:]...)
+		i--
 	}
 }
@@ -0,0 +1,418 @@
+package artnet
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jsimonetti/go-artnet/packet"
+	"github.com/jsimonetti/go-artnet/packet/code"
+	"github.com/sirupsen/logrus"
+)
+
+// boundNode returns the configs announced by a node with six output ports in two
+// pages, the second page is announced from ip2 with the IP of the first page as BindIP
+func boundNode(t *testing.T, ip1, ip2 net.IP, mac net.HardwareAddr) []NodeConfig {
+	dmx := new(code.PortType).WithType("DMX512").WithOutput(true)
+	cfg := NodeConfig{
+		Name:     "bound",
+		Type:     code.StNode,
+		IP:       ip1,
+		Ethernet: mac,
+	}
+	for i := 0; i < 6; i++ {
+		cfg.OutputPorts = append(cfg.OutputPorts, OutputPort{Address: Address{SubUni: uint8(i)}, Type: dmx})
+	}
+
+	var pages []NodeConfig
+	for i, p := range ArtPollRepliesFromConfig(cfg) {
+		if i > 0 {
+			copy(p.IPAddress[:], ip2.To4())
+		}
+		reply, err := packet.Unmarshal(marshal(t, p))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		pages = append(pages, ConfigFromArtPollReply(*reply.(*packet.ArtPollReplyPacket)))
+	}
+	return pages
+}
+
+// update passes the configs to the controller as if their ArtPollReplies arrived
+func update(t *testing.T, c *Controller, cfgs ...NodeConfig) {
+	for _, cfg := range cfgs {
+		if err := c.updateNode(cfg); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+// newTestController returns a controller set up to keep track of nodes and send
+// DMX without being started
+func newTestController(opts ...Option) *Controller {
+	c := NewController("controller", net.IP{2, 0, 0, 1}, NewDefaultLogger(), opts...)
+	c.OutputAddress = make(map[Address][]*ControlledNode)
+	c.InputAddress = make(map[Address][]*ControlledNode)
+	c.universes = make(map[Address]*dmxBuffer)
+	c.shutdownCh = make(chan struct{})
+	c.cNode.sendCh = make(chan netPayload, 10)
+	return c
+}
+
+func TestControllerBoundNode(t *testing.T) {
+	c := newTestController()
+	root := net.IP{2, 0, 0, 10}
+	second := net.IP{2, 0, 0, 11}
+	pages := boundNode(t, root, second, nil)
+	if want, got := 2, len(pages); want != got {
+		t.Fatalf("unexpected pages:\n- want: %d\n-  got: %d", want, got)
+	}
+
+	// the pages are grouped by the IP of the root device, whatever order they arrive in
+	update(t, c, pages[1], pages[0])
+	if want, got := 1, len(c.Nodes); want != got {
+		t.Fatalf("unexpected nodes:\n- want: %d\n-  got: %d", want, got)
+	}
+	node := c.Nodes[0]
+	if want, got := []uint8{1, 2}, []uint8{node.Pages[0].Node.BindIndex, node.Pages[1].Node.BindIndex}; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected BindIndexes:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := 6, len(node.Node.OutputPorts); want != got {
+		t.Fatalf("unexpected output ports:\n- want: %d\n-  got: %d", want, got)
+	}
+
+	// universes are sent to the page with the output port
+	if want, got := second, node.udpAddress(Address{SubUni: 5}).IP; !want.Equal(got) {
+		t.Fatalf("unexpected destination:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := root, node.udpAddress(Address{SubUni: 1}).IP; !want.Equal(got) {
+		t.Fatalf("unexpected destination:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// a page that stops replying expires, the node stays while a page is left
+	node.Pages[1].LastSeen = node.Pages[1].LastSeen.Add(-time.Minute)
+	c.gcNode()
+	if want, got := 1, len(c.Nodes); want != got {
+		t.Fatalf("unexpected nodes:\n- want: %d\n-  got: %d", want, got)
+	}
+	if want, got := 1, len(node.Pages); want != got {
+		t.Fatalf("unexpected pages after expiry:\n- want: %d\n-  got: %d", want, got)
+	}
+	if want, got := 4, len(node.Node.OutputPorts); want != got {
+		t.Fatalf("unexpected output ports after expiry:\n- want: %d\n-  got: %d", want, got)
+	}
+	if _, ok := c.OutputAddress[Address{SubUni: 5}]; ok {
+		t.Fatal("expected universe of the expired page to be unsubscribed")
+	}
+
+	node.Pages[0].LastSeen = node.Pages[0].LastSeen.Add(-time.Minute)
+	c.gcNode()
+	if want, got := 0, len(c.Nodes); want != got {
+		t.Fatalf("unexpected nodes:\n- want: %d\n-  got: %d", want, got)
+	}
+}
+
+func TestControllerMatchMAC(t *testing.T) {
+	ip := net.IP{2, 0, 0, 10}
+	mac1 := net.HardwareAddr{0, 1, 2, 3, 4, 5}
+	mac2 := net.HardwareAddr{0, 1, 2, 3, 4, 6}
+
+	tests := []struct {
+		name     string
+		matchMAC bool
+		// pages is the number of pages known after the second device replied
+		pages int
+	}{
+		{name: "Disabled", pages: 2},
+		{name: "Enabled", matchMAC: true, pages: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestController(MatchMAC(tt.matchMAC))
+
+			// the second page of the first device is followed by the first page of
+			// another device taking over the IP
+			update(t, c, boundNode(t, ip, ip, mac1)[1], boundNode(t, ip, ip, mac2)[0])
+			if want, got := 1, len(c.Nodes); want != got {
+				t.Fatalf("unexpected nodes:\n- want: %d\n-  got: %d", want, got)
+			}
+			if want, got := tt.pages, len(c.Nodes[0].Pages); want != got {
+				t.Fatalf("unexpected pages:\n- want: %d\n-  got: %d", want, got)
+			}
+		})
+	}
+}
+
+func TestControllerFanOut(t *testing.T) {
+	c := newTestController(MaxFPS(40))
+
+	// three nodes subscribe to 0:0.1, the last one to 0:0.2 as well
+	dmx := new(code.PortType).WithType("DMX512").WithOutput(true)
+	var ips []net.IP
+	for i := 0; i < 3; i++ {
+		ip := net.IP{2, 0, 0, byte(10 + i)}
+		cfg := NodeConfig{
+			Name:        "node",
+			Type:        code.StNode,
+			IP:          ip,
+			OutputPorts: []OutputPort{{Address: Address{SubUni: 1}, Type: dmx}},
+		}
+		if i == 2 {
+			cfg.OutputPorts = append(cfg.OutputPorts, OutputPort{Address: Address{SubUni: 2}, Type: dmx})
+		}
+		update(t, c, cfg)
+		ips = append(ips, ip)
+	}
+	if want, got := 3, len(c.OutputAddress[Address{SubUni: 1}]); want != got {
+		t.Fatalf("unexpected subscribers:\n- want: %d\n-  got: %d", want, got)
+	}
+
+	go c.dmxUpdateLoop()
+	defer close(c.shutdownCh)
+
+	// frame waits for the ArtDmx of 0:0.1 sent to every subscriber and returns their
+	// destinations and sequences, with the sequences of those sent for 0:0.2
+	frame := func(other int) (dst []net.IP, sequences, others []uint8) {
+		for len(dst) < len(ips) || len(others) < other {
+			select {
+			case s := <-c.cNode.sendCh:
+				p, err := packet.Unmarshal(s.data)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				dmx := p.(*packet.ArtDMXPacket)
+				if dmx.SubUni != 1 {
+					others = append(others, dmx.Sequence)
+					continue
+				}
+				dst = append(dst, s.address.IP)
+				sequences = append(sequences, dmx.Sequence)
+			case <-time.After(time.Second):
+				t.Fatal("timeout waiting for ArtDmx")
+			}
+		}
+		return
+	}
+
+	tests := []struct {
+		name      string
+		sequences []uint8
+		other     []uint8
+	}{
+		{name: "KeepAlive", sequences: []uint8{1, 1, 1}, other: []uint8{1}},
+		{name: "Changed", sequences: []uint8{2, 2, 2}},
+		{name: "ChangedAgain", sequences: []uint8{3, 3, 3}},
+	}
+	for i, tt := range tests {
+		if i > 0 {
+			if err := c.SendDMXToAddress([512]byte{uint8(i)}, Address{SubUni: 1}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		dst, sequences, other := frame(len(tt.other))
+		if !reflect.DeepEqual(ips, dst) {
+			t.Fatalf("%s: unexpected destinations:\n- want: %v\n-  got: %v", tt.name, ips, dst)
+		}
+		// every node gets the same frame, with the sequence of the universe
+		if want, got := tt.sequences, sequences; !reflect.DeepEqual(want, got) {
+			t.Fatalf("%s: unexpected sequences:\n- want: %v\n-  got: %v", tt.name, want, got)
+		}
+		// 0:0.2 is unchanged and only sent again after a keep-alive interval, which
+		// may fall in the later frames
+		if want, got := tt.other, other; want != nil && !reflect.DeepEqual(want, got) {
+			t.Fatalf("%s: unexpected sequences of 0:0.2:\n- want: %v\n-  got: %v", tt.name, want, got)
+		}
+	}
+}
+
+func TestControllerDestinations(t *testing.T) {
+	address := Address{SubUni: 1}
+
+	nodes := func(n int) (nodes []*ControlledNode) {
+		for i := 0; i < n; i++ {
+			nodes = append(nodes, &ControlledNode{
+				UDPAddress: net.UDPAddr{IP: net.IP{2, 0, 0, byte(i + 1)}, Port: 6454},
+			})
+		}
+		return
+	}
+
+	unicast := func(n int) (dst []net.UDPAddr) {
+		for _, node := range nodes(n) {
+			dst = append(dst, node.UDPAddress)
+		}
+		return
+	}
+
+	broadcast := []net.UDPAddr{defaultBroadcastAddr}
+
+	tests := []struct {
+		name      string
+		threshold int
+		unknown   bool
+		nodes     []*ControlledNode
+		dst       []net.UDPAddr
+	}{
+		{
+			name:      "NoSubscribers",
+			threshold: 40,
+		},
+		{
+			name:      "BroadcastUnknown",
+			threshold: 40,
+			unknown:   true,
+			dst:       broadcast,
+		},
+		{
+			name:      "Unicast",
+			threshold: 2,
+			nodes:     nodes(2),
+			dst:       unicast(2),
+		},
+		{
+			name:      "OverThreshold",
+			threshold: 2,
+			nodes:     nodes(3),
+			dst:       broadcast,
+		},
+		{
+			name:  "NoThreshold",
+			nodes: nodes(3),
+			dst:   unicast(3),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Controller{
+				broadcastAddr:      defaultBroadcastAddr,
+				broadcastThreshold: tt.threshold,
+				broadcastUnknown:   tt.unknown,
+			}
+
+			if want, got := tt.dst, c.destinations(address, tt.nodes); !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected destinations:\n- want: %v\n-  got: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestControllerSyncOutput(t *testing.T) {
+	start := time.Unix(1760000000, 0)
+	c := NewController("controller", net.IP{2, 0, 0, 1}, NewDefaultLogger(), MaxFPS(40), SyncOutput(true))
+	r, err := NewControllerReplay(c, start)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	node := net.UDPAddr{IP: net.IP{2, 0, 0, 10}, Port: packet.ArtNetPort}
+	if err := r.Receive(start, node, defaultBroadcastAddr, marshal(t, ArtPollReplyFromConfig(NodeConfig{
+		Name: "node",
+		Type: code.StNode,
+		IP:   node.IP,
+		OutputPorts: []OutputPort{
+			{Address: Address{SubUni: 1}, Type: new(code.PortType).WithType("DMX512").WithOutput(true)},
+			{Address: Address{SubUni: 2}, Type: new(code.PortType).WithType("DMX512").WithOutput(true)},
+		},
+	}))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the universes of the first frame are followed by a single ArtSync
+	r.Sent()
+	r.Advance(25 * time.Millisecond)
+	var dmx, sync int
+	for i, p := range sentPackets(t, r) {
+		switch p.(type) {
+		case *packet.ArtDMXPacket:
+			dmx++
+			if sync > 0 {
+				t.Fatalf("unexpected ArtDmx after ArtSync at %d", i)
+			}
+		case *packet.ArtSyncPacket:
+			sync++
+		}
+	}
+	if want, got := 2, dmx; want != got {
+		t.Fatalf("unexpected ArtDmx packets:\n- want: %d\n-  got: %d", want, got)
+	}
+	if want, got := 1, sync; want != got {
+		t.Fatalf("unexpected ArtSync packets:\n- want: %d\n-  got: %d", want, got)
+	}
+
+	// an idle frame sends nothing
+	r.Advance(25 * time.Millisecond)
+	if packets := sentPackets(t, r); len(packets) != 0 {
+		t.Fatalf("expected nothing to be sent, got %v", packets)
+	}
+
+	// a changed universe is sent with an ArtSync
+	u, _ := c.Universe(Address{SubUni: 2})
+	if err := u.SetChannel(1, 0xff); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r.Advance(25 * time.Millisecond)
+	packets := sentPackets(t, r)
+	if len(packets) != 2 {
+		t.Fatalf("expected ArtDmx and ArtSync, got %v", packets)
+	}
+	if _, ok := packets[1].(*packet.ArtSyncPacket); !ok {
+		t.Fatalf("expected ArtSync, got %v", packets[1])
+	}
+}
+
+func TestNewControllerOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		keepAlive time.Duration
+		threshold int
+		unknown   bool
+		err       bool
+	}{
+		{
+			name:      "Defaults",
+			keepAlive: defaultKeepAlive,
+			threshold: defaultBroadcastThreshold,
+		},
+		{
+			name:      "Options",
+			opts:      []Option{KeepAlive(800 * time.Millisecond), BroadcastThreshold(2), BroadcastUnknown(true)},
+			keepAlive: 800 * time.Millisecond,
+			threshold: 2,
+			unknown:   true,
+		},
+		{
+			name:      "InvalidKeepAlive",
+			opts:      []Option{KeepAlive(5 * time.Second), BroadcastThreshold(2)},
+			keepAlive: defaultKeepAlive,
+			threshold: 2,
+			err:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			log := logrus.New()
+			log.Out = &out
+
+			c := NewController("controller", net.IP{2, 0, 0, 1}, NewLogger(logrus.NewEntry(log)), tt.opts...)
+			if want, got := tt.keepAlive, c.keepAlive; want != got {
+				t.Fatalf("unexpected keep-alive:\n- want: %v\n-  got: %v", want, got)
+			}
+			if want, got := tt.threshold, c.broadcastThreshold; want != got {
+				t.Fatalf("unexpected broadcast threshold:\n- want: %d\n-  got: %d", want, got)
+			}
+			if want, got := tt.unknown, c.broadcastUnknown; want != got {
+				t.Fatalf("unexpected broadcast unknown:\n- want: %v\n-  got: %v", want, got)
+			}
+			if want, got := tt.err, strings.Contains(out.String(), "keep-alive interval 5s out of range"); want != got {
+				t.Fatalf("unexpected error logged:\n- want: %v\n-  got: %v (%q)", want, got, out.String())
+			}
+		})
+	}
+}
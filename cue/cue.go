@@ -0,0 +1,185 @@
+// Package cue plays back cue lists on the universes of an artnet.Controller.
+//
+// A cue list holds cues with channel and fixture values. Going to a cue fades the
+// output from its current state to the state of the cue, using the fade-in time
+// for values going up and the fade-out time for values going down. Playback runs
+// on the frame clock of the controller.
+package cue
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+	"github.com/jsimonetti/go-artnet/fixture"
+)
+
+// Channel identifies a DMX channel, from 1 to 512, of a universe
+type Channel struct {
+	Universe artnet.Address
+	Channel  int
+}
+
+// FixtureValue sets an attribute of a patched fixture to a raw value
+type FixtureValue struct {
+	Fixture   string
+	Attribute string
+	Value     uint32
+}
+
+// Cue holds the values and timing of a single cue
+type Cue struct {
+	Number float64
+	Name   string
+
+	// Channels holds the DMX values of channels
+	Channels map[Channel]uint8
+
+	// Fixtures holds the values of fixture attributes
+	Fixtures []FixtureValue
+
+	// FadeIn is used for values going up, FadeOut for values going down. Both
+	// start after Delay.
+	FadeIn  time.Duration
+	FadeOut time.Duration
+	Delay   time.Duration
+
+	// Follow starts the next cue this long after this cue started, zero disables it
+	Follow time.Duration
+
+	// AutoFollow starts the next cue as soon as the fades of this cue are complete
+	AutoFollow bool
+}
+
+// List is a cue list
+type List struct {
+	Name string
+
+	// Cues holds the cues in ascending order of their number
+	Cues []*Cue
+
+	// Tracking keeps the values of earlier cues for channels a cue does not set.
+	// Without tracking those channels go to zero.
+	Tracking bool
+}
+
+// Index returns the index of the cue with the given number
+func (l *List) Index(number float64) (int, bool) {
+	for i, c := range l.Cues {
+		if c.Number == number {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// param is a value faded by the player, a single channel or the channels of a
+// fixture attribute. Multi-byte attributes are faded as a whole, so a 16 bit pan
+// moves smoothly from 0x00ff to 0x0100 instead of through 0x0180.
+type param struct {
+	universe artnet.Address
+	// channels holds the channels of the value, the coarse channel first
+	channels [4]int
+	size     int
+}
+
+// states returns the complete output state of every cue
+func (l *List) states(patch *fixture.Patch) ([]map[param]uint32, error) {
+	if !sort.SliceIsSorted(l.Cues, func(i, j int) bool { return l.Cues[i].Number < l.Cues[j].Number }) {
+		return nil, fmt.Errorf("cue list %q: cues are not in ascending order", l.Name)
+	}
+
+	values := make([]map[Channel]uint8, len(l.Cues))
+	used := make(map[Channel]bool)
+	params := make(map[Channel]param)
+	for i, c := range l.Cues {
+		v, err := c.values(patch, params)
+		if err != nil {
+			return nil, fmt.Errorf("cue list %q: cue %v: %v", l.Name, c.Number, err)
+		}
+		for ch := range v {
+			used[ch] = true
+		}
+		values[i] = v
+	}
+
+	states := make([]map[param]uint32, len(l.Cues))
+	var last map[Channel]uint8
+	for i := range l.Cues {
+		state := make(map[Channel]uint8, len(used))
+		for ch := range used {
+			if l.Tracking && i > 0 {
+				state[ch] = last[ch]
+			} else {
+				state[ch] = 0
+			}
+		}
+		for ch, v := range values[i] {
+			state[ch] = v
+		}
+		states[i] = combine(state, params)
+		last = state
+	}
+	return states, nil
+}
+
+// combine combines the channel values of a state into the values of its params.
+// Channels that are not part of a fixture attribute are params of their own.
+func combine(state map[Channel]uint8, params map[Channel]param) map[param]uint32 {
+	values := make(map[param]uint32, len(state))
+	for ch := range state {
+		p, ok := params[ch]
+		if !ok {
+			p = param{universe: ch.Universe, channels: [4]int{ch.Channel}, size: 1}
+		}
+		var v uint32
+		for _, c := range p.channels[:p.size] {
+			v = v<<8 | uint32(state[Channel{Universe: p.universe, Channel: c}])
+		}
+		values[p] = v
+	}
+	return values
+}
+
+// values resolves the channel and fixture values of the cue into channel values,
+// and adds the channels of multi-byte fixture attributes to params
+func (c *Cue) values(patch *fixture.Patch, params map[Channel]param) (map[Channel]uint8, error) {
+	values := make(map[Channel]uint8, len(c.Channels))
+	for ch, v := range c.Channels {
+		if ch.Channel < 1 || ch.Channel > 512 {
+			return nil, fmt.Errorf("channel %d out of range 1-512", ch.Channel)
+		}
+		values[ch] = v
+	}
+
+	for _, fv := range c.Fixtures {
+		if patch == nil {
+			return nil, fmt.Errorf("fixture values without a patch")
+		}
+		f, ok := patch.Fixture(fv.Fixture)
+		if !ok {
+			return nil, fmt.Errorf("fixture %q not patched", fv.Fixture)
+		}
+		dmx, err := f.ChannelValues(fv.Attribute, fv.Value)
+		if err != nil {
+			return nil, err
+		}
+		for ch, v := range dmx {
+			values[Channel{Universe: f.Universe, Channel: ch}] = v
+		}
+
+		attribute, _ := f.Type.Channel(fv.Attribute)
+		if attribute.Resolution() == 1 {
+			continue
+		}
+		p := param{universe: f.Universe, size: attribute.Resolution()}
+		for i, off := range attribute.Offsets {
+			p.channels[i] = f.Address + off
+		}
+		for _, ch := range p.channels[:p.size] {
+			params[Channel{Universe: f.Universe, Channel: ch}] = p
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,289 @@
+package cue
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+	"github.com/jsimonetti/go-artnet/fixture"
+)
+
+// State reports the playback state of a cue list
+type State struct {
+	// Index is the index of the current cue, -1 before the first cue
+	Index  int
+	Number float64
+	Name   string
+
+	// Progress is the progress of the fades into the current cue, from 0 to 1
+	Progress float64
+	Fading   bool
+	Paused   bool
+}
+
+// Player plays back a cue list on the universes of a controller
+type Player struct {
+	c      *artnet.Controller
+	list   *List
+	states []map[param]uint32
+
+	universes map[artnet.Address]*artnet.Universe
+	remove    func()
+
+	lock    sync.Mutex
+	index   int
+	output  map[param]uint32
+	from    map[param]uint32
+	elapsed time.Duration
+	last    time.Time
+	fading  bool
+	paused  bool
+}
+
+// NewPlayer returns a player for the cue list. Fixture values are resolved using
+// the patch, which may be nil if the cues only hold channel values.
+func NewPlayer(c *artnet.Controller, patch *fixture.Patch, list *List) (*Player, error) {
+	states, err := list.states(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Player{
+		c:         c,
+		list:      list,
+		states:    states,
+		universes: make(map[artnet.Address]*artnet.Universe),
+		index:     -1,
+		output:    make(map[param]uint32),
+	}
+	for _, state := range states {
+		for pa := range state {
+			if _, ok := p.universes[pa.universe]; ok {
+				continue
+			}
+			u, err := c.Universe(pa.universe)
+			if err != nil {
+				return nil, err
+			}
+			p.universes[pa.universe] = u
+		}
+	}
+	return p, nil
+}
+
+// Start starts playback on the frame clock of the controller
+func (p *Player) Start() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.remove == nil {
+		p.remove = p.c.OnFrame(p.step)
+	}
+}
+
+// Stop stops playback, the output is left as it is
+func (p *Player) Stop() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.remove != nil {
+		p.remove()
+		p.remove = nil
+	}
+}
+
+// Go starts the next cue
+func (p *Player) Go() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.index+1 >= len(p.list.Cues) {
+		return fmt.Errorf("cue list %q: no cue after the last cue", p.list.Name)
+	}
+	p.transition(p.index + 1)
+	return nil
+}
+
+// Back starts the previous cue
+func (p *Player) Back() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.index < 1 {
+		return fmt.Errorf("cue list %q: no cue before the first cue", p.list.Name)
+	}
+	p.transition(p.index - 1)
+	return nil
+}
+
+// Goto starts the cue with the given number
+func (p *Player) Goto(number float64) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	i, ok := p.list.Index(number)
+	if !ok {
+		return fmt.Errorf("cue list %q has no cue %v", p.list.Name, number)
+	}
+	p.transition(i)
+	return nil
+}
+
+// Pause holds the fades and follow times of the current cue
+func (p *Player) Pause() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.paused = true
+}
+
+// Resume continues a paused cue
+func (p *Player) Resume() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.paused = false
+}
+
+// State returns the playback state
+func (p *Player) State() State {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	s := State{
+		Index:  p.index,
+		Fading: p.fading,
+		Paused: p.paused,
+	}
+	if p.index >= 0 {
+		c := p.list.Cues[p.index]
+		s.Number = c.Number
+		s.Name = c.Name
+		s.Progress = p.progress()
+	}
+	return s
+}
+
+// transition starts fading from the current data of the universes into cue i.
+// The caller must hold the lock.
+func (p *Player) transition(i int) {
+	p.index = i
+	frames := make(map[artnet.Address]artnet.Frame, len(p.universes))
+	for address, u := range p.universes {
+		frames[address] = u.Frame()
+	}
+	p.from = make(map[param]uint32, len(p.states[i]))
+	for pa := range p.states[i] {
+		var v uint32
+		for _, ch := range pa.channels[:pa.size] {
+			v = v<<8 | uint32(frames[pa.universe][ch-1])
+		}
+		p.from[pa] = v
+		p.output[pa] = v
+	}
+	p.elapsed = 0
+	p.last = time.Time{}
+	p.fading = true
+	p.paused = false
+}
+
+// duration returns the time from the start of the current cue until its fades are complete
+func (p *Player) duration() time.Duration {
+	c := p.list.Cues[p.index]
+	fade := time.Duration(0)
+	for pa, to := range p.states[p.index] {
+		from := p.from[pa]
+		if to > from && c.FadeIn > fade {
+			fade = c.FadeIn
+		}
+		if to < from && c.FadeOut > fade {
+			fade = c.FadeOut
+		}
+	}
+	return c.Delay + fade
+}
+
+// progress returns the overall progress of the current cue
+func (p *Player) progress() float64 {
+	if !p.fading {
+		return 1
+	}
+	d := p.duration()
+	if d <= 0 {
+		return 1
+	}
+	return math.Min(1, float64(p.elapsed)/float64(d))
+}
+
+// step advances playback to now and writes the output of fading channels
+func (p *Player) step(now time.Time) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.index < 0 {
+		return
+	}
+	if p.last.IsZero() {
+		p.last = now
+	}
+	if !p.paused {
+		p.elapsed += now.Sub(p.last)
+	}
+	p.last = now
+
+	c := p.list.Cues[p.index]
+	if p.fading {
+		changed := make(map[artnet.Address]map[int]uint8)
+		for pa, to := range p.states[p.index] {
+			from := p.from[pa]
+			fade := c.FadeIn
+			if to < from {
+				fade = c.FadeOut
+			}
+
+			x := 1.0
+			if fade > 0 {
+				x = float64(p.elapsed-c.Delay) / float64(fade)
+			} else if p.elapsed < c.Delay {
+				x = 0
+			}
+			x = math.Max(0, math.Min(1, x))
+
+			v := uint32(math.Round(float64(from) + (float64(to)-float64(from))*x))
+			if v != p.output[pa] || p.elapsed == 0 {
+				p.output[pa] = v
+				if changed[pa.universe] == nil {
+					changed[pa.universe] = make(map[int]uint8)
+				}
+				// split the value into its channels, the fine channel last
+				for i := pa.size - 1; i >= 0; i-- {
+					changed[pa.universe][pa.channels[i]] = uint8(v)
+					v >>= 8
+				}
+			}
+		}
+		p.write(changed)
+
+		if p.elapsed >= p.duration() {
+			p.fading = false
+		}
+	}
+
+	if p.index+1 < len(p.list.Cues) {
+		if (c.Follow > 0 && p.elapsed >= c.Follow) || (c.AutoFollow && !p.fading) {
+			p.transition(p.index + 1)
+		}
+	}
+}
+
+// write writes changed channel values into the universes
+func (p *Player) write(changed map[artnet.Address]map[int]uint8) {
+	for address, values := range changed {
+		p.universes[address].Update(func(f *artnet.Frame) error {
+			for ch, v := range values {
+				f[ch-1] = v
+			}
+			return nil
+		})
+	}
+}
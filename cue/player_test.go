@@ -0,0 +1,162 @@
+package cue
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+	"github.com/jsimonetti/go-artnet/fixture"
+)
+
+func TestPlayer(t *testing.T) {
+	universe := artnet.Address{SubUni: 1}
+	ch := func(n int) Channel { return Channel{Universe: universe, Channel: n} }
+
+	newList := func(tracking bool) *List {
+		return &List{
+			Name:     "main",
+			Tracking: tracking,
+			Cues: []*Cue{
+				{Number: 1, Channels: map[Channel]uint8{ch(1): 200, ch(2): 100}, FadeIn: time.Second},
+				{Number: 2, Channels: map[Channel]uint8{ch(1): 0}, FadeOut: 2 * time.Second, Delay: time.Second, AutoFollow: true},
+				{Number: 3, Channels: map[Channel]uint8{ch(3): 50}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		tracking bool
+		// output of channel 1-3 after cue 3
+		want [3]uint8
+	}{
+		{name: "Tracking", tracking: true, want: [3]uint8{0, 100, 50}},
+		{name: "NonTracking", want: [3]uint8{0, 0, 50}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := artnet.NewController("test", net.IP{2, 0, 0, 1}, artnet.NewDefaultLogger())
+			u, _ := c.Universe(universe)
+			p, err := NewPlayer(c, nil, newList(tt.tracking))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			now := time.Unix(0, 0)
+			output := func() [3]uint8 {
+				f := u.Frame()
+				return [3]uint8{f[0], f[1], f[2]}
+			}
+
+			if err := p.Go(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			p.step(now)
+			p.step(now.Add(500 * time.Millisecond))
+			if want, got := [3]uint8{100, 50, 0}, output(); want != got {
+				t.Fatalf("unexpected output halfway cue 1:\n- want: %v\n-  got: %v", want, got)
+			}
+			if want, got := 0.5, p.State().Progress; want != got {
+				t.Fatalf("unexpected progress:\n- want: %v\n-  got: %v", want, got)
+			}
+
+			// pausing holds the fade
+			p.Pause()
+			p.step(now.Add(5 * time.Second))
+			p.Resume()
+			p.step(now.Add(5500 * time.Millisecond))
+			if want, got := [3]uint8{200, 100, 0}, output(); want != got {
+				t.Fatalf("unexpected output after cue 1:\n- want: %v\n-  got: %v", want, got)
+			}
+
+			// cue 2 waits for its delay, fades out and auto follows into cue 3
+			if err := p.Go(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			now = now.Add(10 * time.Second)
+			p.step(now)
+			p.step(now.Add(time.Second))
+			if want, got := uint8(200), output()[0]; want != got {
+				t.Fatalf("unexpected output after delay:\n- want: %v\n-  got: %v", want, got)
+			}
+			p.step(now.Add(3 * time.Second))
+			if want, got := 2, p.State().Index; want != got {
+				t.Fatalf("unexpected cue after auto follow:\n- want: %v\n-  got: %v", want, got)
+			}
+			p.step(now.Add(3 * time.Second))
+			if want, got := tt.want, output(); want != got {
+				t.Fatalf("unexpected output after cue 3:\n- want: %v\n-  got: %v", want, got)
+			}
+
+			if err := p.Go(); err == nil {
+				t.Fatal("expected error going past the last cue")
+			}
+			if err := p.Goto(1); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			p.step(now.Add(4 * time.Second))
+			p.step(now.Add(5 * time.Second))
+			if want, got := [3]uint8{200, 100, 0}, output(); want != got {
+				t.Fatalf("unexpected output after goto cue 1:\n- want: %v\n-  got: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestPlayerFixtures(t *testing.T) {
+	c := artnet.NewController("test", net.IP{2, 0, 0, 1}, artnet.NewDefaultLogger())
+	patch := fixture.NewPatch(c)
+	f, err := patch.Add("spot", &fixture.Type{
+		Name:      "spot",
+		Footprint: 3,
+		Channels: []fixture.Channel{
+			{Attribute: fixture.Pan, Offsets: []int{0, 1}},
+			{Attribute: fixture.Intensity, Offsets: []int{2}},
+		},
+	}, artnet.Address{}, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	u, _ := c.Universe(artnet.Address{})
+
+	// the first cue fades from what the universe holds when it starts
+	if err := f.Set(fixture.Pan, 0x00ff); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.Set(fixture.Intensity, 100); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p, err := NewPlayer(c, patch, &List{
+		Name: "main",
+		Cues: []*Cue{
+			{
+				Number: 1,
+				Fixtures: []FixtureValue{
+					{Fixture: "spot", Attribute: fixture.Pan, Value: 0x0101},
+					{Fixture: "spot", Attribute: fixture.Intensity, Value: 200},
+				},
+				FadeIn: time.Second,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p.Go(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := time.Unix(0, 0)
+	p.step(now)
+	p.step(now.Add(500 * time.Millisecond))
+
+	// the 16 bit pan is faded as a single value, fading the bytes separately
+	// would pass through 0x0180
+	f1 := u.Frame()
+	if want, got := [3]uint8{0x01, 0x00, 150}, [3]uint8{f1[0], f1[1], f1[2]}; want != got {
+		t.Fatalf("unexpected output halfway cue 1:\n- want: %v\n-  got: %v", want, got)
+	}
+}
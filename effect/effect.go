@@ -0,0 +1,224 @@
+// Package effect runs chases and waveform generators on the universes of an artnet.Controller.
+//
+// Effects are a function of the time since they started, which makes them
+// deterministic for a given clock. The Engine runs them on the frame clock of
+// the controller and combines their values with the output below them on mixer
+// layers, leaving the data written to the universes by cues and fades untouched.
+package effect
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+	"github.com/jsimonetti/go-artnet/fixture"
+)
+
+// Channel identifies a DMX channel, from 1 to 512, of a universe
+type Channel struct {
+	Universe artnet.Address
+	Channel  int
+}
+
+// Member is a member of an effect group, like a fixture or a single channel. All
+// channels of a member get the same value, except for the rainbow waveform which
+// uses the first three channels as red, green and blue.
+type Member struct {
+	Channels []Channel
+}
+
+// ChannelMember returns a member for a single channel
+func ChannelMember(universe artnet.Address, channel int) Member {
+	return Member{Channels: []Channel{{Universe: universe, Channel: channel}}}
+}
+
+// FixtureMember returns a member for the coarse channels of the attributes of a patched fixture
+func FixtureMember(f *fixture.Fixture, attributes ...string) (Member, error) {
+	var m Member
+	for _, attribute := range attributes {
+		c, ok := f.Type.Channel(attribute)
+		if !ok {
+			return m, fmt.Errorf("fixture %q has no attribute %q", f.Name, attribute)
+		}
+		m.Channels = append(m.Channels, Channel{Universe: f.Universe, Channel: f.Address + c.Offsets[0]})
+	}
+	return m, nil
+}
+
+// Effect computes channel values from the time since the effect started
+type Effect interface {
+	Values(t time.Duration) map[Channel]uint8
+}
+
+// Waveform is the shape of a generator
+type Waveform uint8
+
+const (
+	// Sine is a sine wave between the offset and offset plus size
+	Sine Waveform = iota
+
+	// Square is at offset plus size for the first half of a cycle, and at offset for the second half
+	Square
+
+	// Sawtooth rises from offset to offset plus size during each cycle
+	Sawtooth
+
+	// Random picks a new random value every cycle
+	Random
+
+	// Rainbow cycles through the hues on the red, green and blue channels of a member
+	Rainbow
+)
+
+// String returns a string representation of Waveform
+func (w Waveform) String() string {
+	switch w {
+	case Sine:
+		return "sine"
+	case Square:
+		return "square"
+	case Sawtooth:
+		return "sawtooth"
+	case Random:
+		return "random"
+	case Rainbow:
+		return "rainbow"
+	}
+	return fmt.Sprintf("Waveform(%d)", uint8(w))
+}
+
+// Generator is a parametric waveform generator over a group
+type Generator struct {
+	Waveform Waveform
+	Group    []Member
+
+	// Rate is the number of cycles per second
+	Rate float64
+
+	// Spread is the phase difference between the first and last member of the group,
+	// in cycles. A spread of 1 spreads one complete cycle across the group.
+	Spread float64
+
+	// Size is the amplitude of the waveform, Offset is added to it
+	Size   uint8
+	Offset uint8
+
+	// Seed makes the Random waveform differ between generators
+	Seed uint64
+}
+
+// Values implements Effect
+func (g *Generator) Values(t time.Duration) map[Channel]uint8 {
+	values := make(map[Channel]uint8)
+	n := float64(len(g.Group))
+	for i, m := range g.Group {
+		pos := t.Seconds()*g.Rate - g.Spread*float64(i)/n
+		cycle := math.Floor(pos)
+		phase := pos - cycle
+
+		if g.Waveform == Rainbow {
+			r, gr, b := hue(phase)
+			for j, level := range []float64{r, gr, b} {
+				if j < len(m.Channels) {
+					values[m.Channels[j]] = g.level(level)
+				}
+			}
+			continue
+		}
+
+		var level float64
+		switch g.Waveform {
+		case Sine:
+			level = 0.5 - 0.5*math.Cos(2*math.Pi*phase)
+		case Square:
+			if phase < 0.5 {
+				level = 1
+			}
+		case Sawtooth:
+			level = phase
+		case Random:
+			level = float64(splitmix(g.Seed+splitmix(uint64(int64(cycle)))+uint64(i))>>11) / (1 << 53)
+		}
+		for _, ch := range m.Channels {
+			values[ch] = g.level(level)
+		}
+	}
+	return values
+}
+
+// level scales a level from 0 to 1 by size and offset
+func (g *Generator) level(level float64) uint8 {
+	return uint8(math.Min(255, float64(g.Offset)+math.Round(level*float64(g.Size))))
+}
+
+// hue returns the red, green and blue levels of a hue from 0 to 1 at full saturation
+func hue(h float64) (r, g, b float64) {
+	h6 := h * 6
+	x := 1 - math.Abs(math.Mod(h6, 2)-1)
+	switch int(h6) % 6 {
+	case 0:
+		return 1, x, 0
+	case 1:
+		return x, 1, 0
+	case 2:
+		return 0, 1, x
+	case 3:
+		return 0, x, 1
+	case 4:
+		return x, 0, 1
+	}
+	return 1, 0, x
+}
+
+// splitmix is a fast hash, giving the Random waveform a deterministic value per cycle
+func splitmix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// Chase steps through the members of a group
+type Chase struct {
+	Group []Member
+
+	// StepTime is the time each step lasts
+	StepTime time.Duration
+
+	// Width is the number of consecutive members that are on at each step, at least 1
+	Width int
+
+	// On is the value of members that are on, Off that of the others
+	On  uint8
+	Off uint8
+}
+
+// Values implements Effect
+func (c *Chase) Values(t time.Duration) map[Channel]uint8 {
+	values := make(map[Channel]uint8)
+	n := len(c.Group)
+	if n == 0 {
+		return values
+	}
+
+	step := 0
+	if c.StepTime > 0 {
+		step = int(t/c.StepTime) % n
+	}
+	width := c.Width
+	if width < 1 {
+		width = 1
+	}
+
+	for i, m := range c.Group {
+		v := c.Off
+		if (i-step+n)%n < width {
+			v = c.On
+		}
+		for _, ch := range m.Channels {
+			values[ch] = v
+		}
+	}
+	return values
+}
@@ -0,0 +1,200 @@
+package effect
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+	"github.com/jsimonetti/go-artnet/cue"
+)
+
+var testUniverse = artnet.Address{SubUni: 1}
+
+func group(n int) (g []Member) {
+	for i := 0; i < n; i++ {
+		g = append(g, ChannelMember(testUniverse, i+1))
+	}
+	return
+}
+
+func TestGenerator(t *testing.T) {
+	tests := []struct {
+		name string
+		g    Generator
+		t    time.Duration
+		want []uint8
+	}{
+		{
+			name: "SineStart",
+			g:    Generator{Waveform: Sine, Group: group(1), Rate: 1, Size: 200},
+			want: []uint8{0},
+		},
+		{
+			name: "SineHalf",
+			g:    Generator{Waveform: Sine, Group: group(1), Rate: 1, Size: 200, Offset: 10},
+			t:    500 * time.Millisecond,
+			want: []uint8{210},
+		},
+		{
+			name: "SquareSpread",
+			g:    Generator{Waveform: Square, Group: group(4), Rate: 1, Spread: 1, Size: 255},
+			t:    100 * time.Millisecond,
+			want: []uint8{255, 0, 0, 255},
+		},
+		{
+			name: "Sawtooth",
+			g:    Generator{Waveform: Sawtooth, Group: group(1), Rate: 2, Size: 100},
+			t:    1250 * time.Millisecond,
+			want: []uint8{50},
+		},
+		{
+			name: "Rainbow",
+			g: Generator{Waveform: Rainbow, Rate: 1, Size: 255, Group: []Member{{Channels: []Channel{
+				{Universe: testUniverse, Channel: 1}, {Universe: testUniverse, Channel: 2}, {Universe: testUniverse, Channel: 3},
+			}}}},
+			t:    time.Second / 3,
+			want: []uint8{0, 255, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := tt.g.Values(tt.t)
+			for i, want := range tt.want {
+				if got := values[Channel{Universe: testUniverse, Channel: i + 1}]; want != got {
+					t.Fatalf("unexpected value of channel %d:\n- want: %d\n-  got: %d", i+1, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRandomDeterministic(t *testing.T) {
+	g := Generator{Waveform: Random, Group: group(2), Rate: 1, Size: 255, Seed: 42}
+	a, b := g.Values(1500*time.Millisecond), g.Values(1700*time.Millisecond)
+	for ch, v := range a {
+		if b[ch] != v {
+			t.Fatalf("random value changed within a cycle:\n- want: %d\n-  got: %d", v, b[ch])
+		}
+	}
+}
+
+func TestChase(t *testing.T) {
+	c := Chase{Group: group(4), StepTime: time.Second, Width: 2, On: 255}
+
+	values := c.Values(3 * time.Second)
+	want := []uint8{255, 0, 0, 255}
+	for i, w := range want {
+		if got := values[Channel{Universe: testUniverse, Channel: i + 1}]; w != got {
+			t.Fatalf("unexpected value of channel %d:\n- want: %d\n-  got: %d", i+1, w, got)
+		}
+	}
+}
+
+func TestEngine(t *testing.T) {
+	c := artnet.NewController("test", net.IP{2, 0, 0, 1}, artnet.NewDefaultLogger())
+	u, _ := c.Universe(testUniverse)
+	u.SetRange(1, []byte{100, 100})
+
+	e := NewEngine(c)
+	defer e.Close()
+
+	now := time.Unix(0, 0)
+	square := &Generator{Waveform: Square, Group: group(2), Rate: 1, Size: 50}
+	htp := e.Start(square, HTP)
+	sub := e.Start(&Chase{Group: group(2), StepTime: time.Second, On: 30}, Subtract)
+
+	e.step(now)
+	if want, got := [2]uint8{70, 100}, [2]uint8{u.Output()[0], u.Output()[1]}; want != got {
+		t.Fatalf("unexpected output:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// the output below changes while the effect runs
+	u.SetChannel(1, 10)
+	e.step(now.Add(100 * time.Millisecond))
+	if want, got := uint8(20), u.Output()[0]; want != got {
+		t.Fatalf("unexpected output after change below:\n- want: %d\n-  got: %d", want, got)
+	}
+
+	htp.Stop()
+	sub.Stop()
+	e.step(now.Add(200 * time.Millisecond))
+	if want, got := [2]uint8{10, 100}, [2]uint8{u.Output()[0], u.Output()[1]}; want != got {
+		t.Fatalf("unexpected output after stop:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+// static is an effect with constant values
+type static map[Channel]uint8
+
+// Values implements Effect
+func (s static) Values(t time.Duration) map[Channel]uint8 {
+	return s
+}
+
+func TestEngineFadeAndCue(t *testing.T) {
+	start := time.Unix(0, 0)
+	c := artnet.NewController("test", net.IP{2, 0, 0, 1}, artnet.NewDefaultLogger())
+	r, err := artnet.NewControllerReplay(c, start)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	u, _ := c.Universe(testUniverse)
+
+	e := NewEngine(c)
+	defer e.Close()
+	fx := e.Start(static{
+		{Universe: testUniverse, Channel: 1}: 20,
+		{Universe: testUniverse, Channel: 2}: 20,
+	}, Add)
+
+	// channel 1 is faded, channel 2 is faded by a cue
+	if _, err := u.Fade(1, []byte{100}, time.Second, artnet.CurveLinear); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p, err := cue.NewPlayer(c, nil, &cue.List{
+		Name: "main",
+		Cues: []*cue.Cue{{
+			Number:   1,
+			Channels: map[cue.Channel]uint8{{Universe: testUniverse, Channel: 2}: 200},
+			FadeIn:   time.Second,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p.Start()
+	defer p.Stop()
+	if err := p.Go(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// frame and output return the first two channels
+	frame := func() [2]uint8 {
+		f := u.Frame()
+		return [2]uint8{f[0], f[1]}
+	}
+	output := func() [2]uint8 {
+		f := u.Output()
+		return [2]uint8{f[0], f[1]}
+	}
+
+	// the effect is added to the output of the fades, without changing what they write
+	for i := 0; i < 6; i++ {
+		r.Advance(250 * time.Millisecond)
+		f := frame()
+		if want, got := [2]uint8{f[0] + 20, f[1] + 20}, output(); want != got {
+			t.Fatalf("unexpected output while fading:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+	if want, got := [2]uint8{100, 200}, frame(); want != got {
+		t.Fatalf("unexpected data after fades:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	fx.Stop()
+	r.Advance(250 * time.Millisecond)
+	if want, got := [2]uint8{100, 200}, output(); want != got {
+		t.Fatalf("unexpected output after stop:\n- want: %v\n-  got: %v", want, got)
+	}
+}
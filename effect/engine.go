@@ -0,0 +1,178 @@
+package effect
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+)
+
+// Mode defines how the values of an effect are combined with the output below it
+type Mode uint8
+
+const (
+	// HTP outputs the highest of the effect and the output below it
+	HTP Mode = iota
+
+	// Add adds the effect to the output below it
+	Add
+
+	// Subtract subtracts the effect from the output below it
+	Subtract
+)
+
+// String returns a string representation of Mode
+func (m Mode) String() string {
+	switch m {
+	case Add:
+		return "add"
+	case Subtract:
+		return "subtract"
+	}
+	return "HTP"
+}
+
+// layerMode returns the mixer layer mode of m
+func (m Mode) layerMode() artnet.LayerMode {
+	switch m {
+	case Add:
+		return artnet.LayerAdd
+	case Subtract:
+		return artnet.LayerSubtract
+	}
+	return artnet.LayerHTP
+}
+
+// LayerPriority is the priority of the mixer layers effects are output on. The
+// layers are above the data written to the universes, so effects combine with
+// cues and fades without changing what they wrote.
+const LayerPriority = 100
+
+// layerID numbers the layers of effects of all engines, so their names are unique
+var layerID uint64
+
+// Running is an effect started on an Engine
+type Running struct {
+	e     *Engine
+	id    int
+	fx    Effect
+	mode  Mode
+	start time.Time
+
+	// layers holds the mixer layers of the effect per universe, named after layer,
+	// and set the channels it wrote on the last frame
+	layer  uint64
+	layers map[artnet.Address]*artnet.Layer
+	set    map[Channel]bool
+}
+
+// Stop stops the effect, its channels return to the output below it
+func (r *Running) Stop() {
+	r.e.lock.Lock()
+	defer r.e.lock.Unlock()
+	delete(r.e.running, r.id)
+	for _, l := range r.layers {
+		l.Release(0)
+	}
+}
+
+// Engine runs effects on the universes of a controller
+type Engine struct {
+	c      *artnet.Controller
+	remove func()
+
+	lock    sync.Mutex
+	running map[int]*Running
+	nextID  int
+}
+
+// NewEngine returns an engine running on the frame clock of the controller
+func NewEngine(c *artnet.Controller) *Engine {
+	e := &Engine{
+		c:       c,
+		running: make(map[int]*Running),
+	}
+	e.remove = c.OnFrame(e.step)
+	return e
+}
+
+// Close stops the engine, leaving the output as it is
+func (e *Engine) Close() {
+	e.remove()
+}
+
+// Start starts an effect on the next frame. Effects started later are combined on
+// top of effects started earlier.
+func (e *Engine) Start(fx Effect, mode Mode) *Running {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.nextID++
+	r := &Running{
+		e:      e,
+		id:     e.nextID,
+		fx:     fx,
+		mode:   mode,
+		layer:  atomic.AddUint64(&layerID, 1),
+		layers: make(map[artnet.Address]*artnet.Layer),
+		set:    make(map[Channel]bool),
+	}
+	e.running[r.id] = r
+	return r
+}
+
+// step computes the effects at now and writes them into their mixer layers
+func (e *Engine) step(now time.Time) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	ids := make([]int, 0, len(e.running))
+	for id := range e.running {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		r := e.running[id]
+		if r.start.IsZero() {
+			r.start = now
+		}
+		set := make(map[Channel]bool)
+		for ch, v := range r.fx.Values(now.Sub(r.start)) {
+			if ch.Channel < 1 || ch.Channel > 512 {
+				continue
+			}
+			l, err := r.universeLayer(ch.Universe)
+			if err != nil {
+				continue
+			}
+			l.SetChannel(ch.Channel, v)
+			set[ch] = true
+		}
+
+		// channels the effect no longer writes return to the output below
+		for ch := range r.set {
+			if !set[ch] {
+				r.layers[ch.Universe].ClearChannel(ch.Channel)
+			}
+		}
+		r.set = set
+	}
+}
+
+// universeLayer returns the mixer layer of the effect on a universe, creating it when needed
+func (r *Running) universeLayer(address artnet.Address) (*artnet.Layer, error) {
+	if l, ok := r.layers[address]; ok {
+		return l, nil
+	}
+	u, err := r.e.c.Universe(address)
+	if err != nil {
+		return nil, err
+	}
+	l := u.Layer(fmt.Sprintf("effect %d", r.layer), LayerPriority, r.mode.layerMode())
+	r.layers[address] = l
+	return l, nil
+}
@@ -0,0 +1,247 @@
+package artnet
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrFadeCancelled is returned by Fade.Err when a fade was cancelled before completion
+var ErrFadeCancelled = errors.New("fade cancelled")
+
+// ErrFadeOverridden is returned by Fade.Err when newer fades or direct writes took over all
+// channels of a fade
+var ErrFadeOverridden = errors.New("fade overridden")
+
+// FadeCurve defines how a fade progresses from the start to the target value
+type FadeCurve uint8
+
+const (
+	// CurveLinear changes the value at a constant rate
+	CurveLinear FadeCurve = iota
+
+	// CurveSCurve starts and ends slowly, and is fastest halfway
+	CurveSCurve
+
+	// CurveExponential starts slowly and ends fast, which looks linear on most dimmers
+	CurveExponential
+)
+
+// String returns a string representation of FadeCurve
+func (c FadeCurve) String() string {
+	switch c {
+	case CurveSCurve:
+		return "S-curve"
+	case CurveExponential:
+		return "exponential"
+	}
+	return "linear"
+}
+
+// Apply maps the progress x of a fade, from 0 to 1, onto the curve
+func (c FadeCurve) Apply(x float64) float64 {
+	switch c {
+	case CurveSCurve:
+		return x * x * (3 - 2*x)
+	case CurveExponential:
+		return (math.Pow(2, 10*x) - 1) / 1023
+	}
+	return x
+}
+
+// Fade is a running fade of one or more channels of a universe
+type Fade struct {
+	c        *Controller
+	address  Address
+	channels []int
+	from     []byte
+	to       []byte
+	start    time.Time
+	duration time.Duration
+	curve    FadeCurve
+
+	// owned counts the channels this fade still controls
+	owned int
+	done  chan struct{}
+	err   error
+}
+
+// Done returns a channel that is closed when the fade has completed, was cancelled
+// or was overridden
+func (f *Fade) Done() <-chan struct{} {
+	return f.done
+}
+
+// Err returns nil when the fade reached its target, ErrFadeCancelled or ErrFadeOverridden
+// when it did not. It must only be called after Done is closed.
+func (f *Fade) Err() error {
+	return f.err
+}
+
+// fader runs the fades of a controller on its frame clock
+type fader struct {
+	fades  []*Fade
+	owners map[Address]*[512]*Fade
+}
+
+// newFader returns an initialized fader
+func newFader() *fader {
+	return &fader{
+		owners: make(map[Address]*[512]*Fade),
+	}
+}
+
+// add starts a fade, taking over its channels from any running fade
+func (fd *fader) add(f *Fade) {
+	owners, ok := fd.owners[f.address]
+	if !ok {
+		owners = &[512]*Fade{}
+		fd.owners[f.address] = owners
+	}
+	for _, ch := range f.channels {
+		if prev := owners[ch]; prev != nil && prev != f {
+			prev.owned--
+		}
+		owners[ch] = f
+	}
+	f.owned = len(f.channels)
+	fd.fades = append(fd.fades, f)
+}
+
+// release takes channels of a universe away from the fades running on them, so
+// values written directly are not overwritten on the next frame
+func (fd *fader) release(address Address, channels ...int) {
+	owners, ok := fd.owners[address]
+	if !ok {
+		return
+	}
+	for _, ch := range channels {
+		if f := owners[ch]; f != nil {
+			f.owned--
+			owners[ch] = nil
+		}
+	}
+}
+
+// cancel stops the fade, leaving its channels at their current value
+func (fd *fader) cancel(f *Fade) {
+	fd.finish(f, ErrFadeCancelled)
+}
+
+// finish removes the fade, releases its channels and notifies waiters
+func (fd *fader) finish(f *Fade, err error) {
+	for i, fade := range fd.fades {
+		if fade == f {
+			fd.fades = append(fd.fades[:i], fd.fades[i+1:]...)
+			break
+		}
+	}
+	if owners, ok := fd.owners[f.address]; ok {
+		for _, ch := range f.channels {
+			if owners[ch] == f {
+				owners[ch] = nil
+			}
+		}
+	}
+	select {
+	case <-f.done:
+		// already finished
+	default:
+		f.err = err
+		close(f.done)
+	}
+}
+
+// step advances all fades to now and writes their values into the universe buffers
+func (fd *fader) step(now time.Time, universe func(Address) *dmxBuffer) {
+	for i := 0; i < len(fd.fades); i++ {
+		f := fd.fades[i]
+		if f.owned <= 0 {
+			fd.finish(f, ErrFadeOverridden)
+			i--
+			continue
+		}
+
+		progress := 1.0
+		if f.duration > 0 {
+			progress = float64(now.Sub(f.start)) / float64(f.duration)
+		}
+		if progress < 0 {
+			progress = 0
+		}
+		if progress > 1 {
+			progress = 1
+		}
+		level := f.curve.Apply(progress)
+
+		buf := universe(f.address)
+		owners := fd.owners[f.address]
+		for j, ch := range f.channels {
+			if owners[ch] != f {
+				continue
+			}
+			from, to := float64(f.from[j]), float64(f.to[j])
+			buf.set(ch, uint8(math.Round(from+(to-from)*level)), now)
+		}
+
+		if progress >= 1 {
+			fd.finish(f, nil)
+			i--
+		}
+	}
+}
+
+// Fade fades consecutive channels starting at channel to values over duration.
+// A fade takes over channels from fades already running on them, writing to a
+// channel while it fades takes it away from the fade.
+func (u *Universe) Fade(channel int, values []byte, duration time.Duration, curve FadeCurve) (*Fade, error) {
+	if err := checkChannels(channel, len(values)); err != nil {
+		return nil, err
+	}
+
+	channels := make([]int, len(values))
+	for i := range values {
+		channels[i] = channel - 1 + i
+	}
+	return u.fade(channels, values, duration, curve), nil
+}
+
+// FadeTo crossfades the whole universe to target over duration
+func (u *Universe) FadeTo(target Frame, duration time.Duration, curve FadeCurve) *Fade {
+	channels := make([]int, len(target))
+	for i := range target {
+		channels[i] = i
+	}
+	return u.fade(channels, target[:], duration, curve)
+}
+
+// fade starts a fade from the current output of channels to values
+func (u *Universe) fade(channels []int, values []byte, duration time.Duration, curve FadeCurve) *Fade {
+	u.c.nodeLock.Lock()
+	defer u.c.nodeLock.Unlock()
+
+	buf := u.c.universe(u.address)
+	f := &Fade{
+		c:        u.c,
+		address:  u.address,
+		channels: channels,
+		from:     make([]byte, len(channels)),
+		to:       append([]byte(nil), values...),
+		start:    u.c.cNode.clock.Now(),
+		duration: duration,
+		curve:    curve,
+		done:     make(chan struct{}),
+	}
+	for i, ch := range channels {
+		f.from[i] = buf.Data[ch]
+	}
+	u.c.fader.add(f)
+	return f
+}
+
+// Cancel stops the fade, leaving its channels at their current value
+func (f *Fade) Cancel() {
+	f.c.nodeLock.Lock()
+	defer f.c.nodeLock.Unlock()
+	f.c.fader.cancel(f)
+}
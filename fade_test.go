@@ -0,0 +1,119 @@
+package artnet
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestFadeCurve(t *testing.T) {
+	for _, c := range []FadeCurve{CurveLinear, CurveSCurve, CurveExponential} {
+		t.Run(c.String(), func(t *testing.T) {
+			if want, got := 0.0, c.Apply(0); want != got {
+				t.Fatalf("unexpected start:\n- want: %v\n-  got: %v", want, got)
+			}
+			if want, got := 1.0, c.Apply(1); want != got {
+				t.Fatalf("unexpected end:\n- want: %v\n-  got: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestFader(t *testing.T) {
+	start := time.Unix(0, 0)
+	address := Address{SubUni: 1}
+	buf := &dmxBuffer{}
+	universe := func(Address) *dmxBuffer { return buf }
+
+	newFade := func(channels []int, to []byte, duration time.Duration) *Fade {
+		return &Fade{
+			address:  address,
+			channels: channels,
+			from:     make([]byte, len(channels)),
+			to:       to,
+			start:    start,
+			duration: duration,
+			done:     make(chan struct{}),
+		}
+	}
+
+	fd := newFader()
+	a := newFade([]int{0, 1}, []byte{200, 100}, time.Second)
+	fd.add(a)
+
+	fd.step(start.Add(500*time.Millisecond), universe)
+	if want, got := [2]byte{100, 50}, [2]byte{buf.Data[0], buf.Data[1]}; want != got {
+		t.Fatalf("unexpected values halfway:\n- want: %v\n-  got: %v", want, got)
+	}
+	if !buf.mixDirty {
+		t.Fatal("expected buffer to be mixed")
+	}
+
+	// a new fade takes over channel 1, the old fade keeps channel 0
+	b := newFade([]int{1}, []byte{0}, 0)
+	fd.add(b)
+	fd.step(start.Add(time.Second), universe)
+
+	for _, f := range []*Fade{a, b} {
+		select {
+		case <-f.Done():
+		default:
+			t.Fatal("expected fade to be done")
+		}
+		if err := f.Err(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if want, got := [2]byte{200, 0}, [2]byte{buf.Data[0], buf.Data[1]}; want != got {
+		t.Fatalf("unexpected values after fades:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// a fade losing all its channels is overridden
+	c := newFade([]int{2}, []byte{255}, time.Second)
+	fd.add(c)
+	fd.add(newFade([]int{2}, []byte{0}, time.Second))
+	fd.step(start, universe)
+	<-c.Done()
+	if want, got := ErrFadeOverridden, c.Err(); want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := 1, len(fd.fades); want != got {
+		t.Fatalf("unexpected number of fades:\n- want: %d\n-  got: %d", want, got)
+	}
+}
+
+func TestFadeDirectWrite(t *testing.T) {
+	c := NewController("test", net.IP{2, 0, 0, 1}, NewDefaultLogger())
+	u, _ := c.Universe(Address{SubUni: 1})
+	now := time.Now()
+
+	f, err := u.Fade(1, []byte{200, 200}, time.Second, CurveLinear)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a channel written while it fades keeps the written value
+	if err := u.SetChannel(1, 50); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c.fader.step(now.Add(500*time.Millisecond), c.universe)
+	if want, got := byte(50), u.Frame()[0]; want != got {
+		t.Fatalf("unexpected value of written channel:\n- want: %d\n-  got: %d", want, got)
+	}
+	if got := u.Frame()[1]; got == 0 || got == 200 {
+		t.Fatalf("expected channel 2 to be fading, got %d", got)
+	}
+
+	// the fade is overridden once all its channels are written
+	if err := u.SetChannel(2, 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c.fader.step(now.Add(600*time.Millisecond), c.universe)
+	<-f.Done()
+	if want, got := ErrFadeOverridden, f.Err(); want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := [2]byte{50, 10}, [2]byte{u.Frame()[0], u.Frame()[1]}; want != got {
+		t.Fatalf("unexpected values after fade:\n- want: %v\n-  got: %v", want, got)
+	}
+}
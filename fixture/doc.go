@@ -0,0 +1,4 @@
+// Package fixture contains a fixture model on top of the DMX output of an artnet.Controller.
+// Fixture types describe the attributes of a fixture and the DMX channels they use,
+// a Patch places fixtures on a universe and computes the DMX values of their attributes.
+package fixture
@@ -0,0 +1,290 @@
+// Package gdtf reads fixture types from General Device Type Format (GDTF) files.
+//
+// A GDTF file is a zip archive holding a description.xml that describes the
+// fixture and its DMX modes. Every DMX mode is converted into a fixture.Type.
+// Only channels on the first DMX break are supported.
+package gdtf
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jsimonetti/go-artnet/fixture"
+)
+
+// descriptionFile is the name of the fixture description inside a GDTF archive
+const descriptionFile = "description.xml"
+
+// attributes maps GDTF attribute names onto the common fixture attribute names
+var attributes = map[string]string{
+	"Dimmer":     fixture.Intensity,
+	"ColorAdd_R": fixture.Red,
+	"ColorAdd_G": fixture.Green,
+	"ColorAdd_B": fixture.Blue,
+	"ColorAdd_W": fixture.White,
+	"ColorAdd_A": fixture.Amber,
+	"Pan":        fixture.Pan,
+	"Tilt":       fixture.Tilt,
+	"Gobo1":      fixture.Gobo,
+	"Shutter1":   fixture.Strobe,
+	"Zoom":       fixture.Zoom,
+	"Focus1":     fixture.Focus,
+}
+
+// FixtureType holds the fixture information of a GDTF file
+type FixtureType struct {
+	Name         string
+	ShortName    string
+	LongName     string
+	Manufacturer string
+	Description  string
+
+	// ID is the FixtureTypeID, a GUID identifying the fixture type
+	ID string
+
+	// Modes holds a fixture type for each DMX mode
+	Modes []*fixture.Type
+}
+
+// Mode returns the fixture type of the DMX mode with the given name
+func (ft *FixtureType) Mode(name string) (*fixture.Type, error) {
+	for _, m := range ft.Modes {
+		if m.Mode == name {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("fixture type %q has no DMX mode %q", ft.Name, name)
+}
+
+// Open reads the GDTF file at path
+func Open(path string) (*FixtureType, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GDTF file: %v", err)
+	}
+	defer r.Close()
+
+	return read(&r.Reader)
+}
+
+// Read reads a GDTF archive of the given size from r
+func Read(r io.ReaderAt, size int64) (*FixtureType, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GDTF archive: %v", err)
+	}
+	return read(zr)
+}
+
+// read decodes the description of a GDTF archive
+func read(zr *zip.Reader) (*FixtureType, error) {
+	for _, f := range zr.File {
+		if f.Name != descriptionFile {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %v", descriptionFile, err)
+		}
+		defer rc.Close()
+
+		return Decode(rc)
+	}
+	return nil, fmt.Errorf("GDTF archive has no %s", descriptionFile)
+}
+
+// Decode decodes a GDTF description.xml
+func Decode(r io.Reader) (*FixtureType, error) {
+	var doc xmlGDTF
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", descriptionFile, err)
+	}
+
+	xft := doc.FixtureType
+	ft := &FixtureType{
+		Name:         xft.Name,
+		ShortName:    xft.ShortName,
+		LongName:     xft.LongName,
+		Manufacturer: xft.Manufacturer,
+		Description:  xft.Description,
+		ID:           xft.FixtureTypeID,
+	}
+
+	units := make(map[string]string)
+	for _, a := range xft.Attributes {
+		units[a.Name] = a.PhysicalUnit
+	}
+
+	for _, xm := range xft.Modes {
+		t, err := mode(ft.Name, xm, units)
+		if err != nil {
+			return nil, err
+		}
+		ft.Modes = append(ft.Modes, t)
+	}
+	return ft, nil
+}
+
+// mode converts a DMX mode into a fixture type
+func mode(name string, xm xmlMode, units map[string]string) (*fixture.Type, error) {
+	t := &fixture.Type{
+		Name: name,
+		Mode: xm.Name,
+	}
+
+	used := make(map[string]bool)
+	for _, xc := range xm.Channels {
+		if xc.DMXBreak != "" && xc.DMXBreak != "1" {
+			continue
+		}
+		// virtual channels have no offset
+		if xc.Offset == "" || xc.Offset == "None" {
+			continue
+		}
+
+		c, err := channel(xc, units)
+		if err != nil {
+			return nil, fmt.Errorf("mode %q: %v", xm.Name, err)
+		}
+
+		// attributes of repeated geometries, like the cells of a pixel bar, are
+		// qualified with the name of the geometry
+		if used[c.Attribute] {
+			c.Attribute = xc.Geometry + "/" + c.Attribute
+		}
+		used[c.Attribute] = true
+
+		for _, off := range c.Offsets {
+			if off+1 > t.Footprint {
+				t.Footprint = off + 1
+			}
+		}
+		t.Channels = append(t.Channels, c)
+	}
+
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// channel converts a DMX channel
+func channel(xc xmlChannel, units map[string]string) (fixture.Channel, error) {
+	var c fixture.Channel
+
+	for _, s := range strings.Split(xc.Offset, ",") {
+		off, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || off < 1 {
+			return c, fmt.Errorf("invalid channel offset %q", xc.Offset)
+		}
+		c.Offsets = append(c.Offsets, off-1)
+	}
+
+	if len(xc.Logical) == 0 {
+		return c, fmt.Errorf("channel at offset %q has no logical channel", xc.Offset)
+	}
+	logical := xc.Logical[0]
+
+	attribute := logical.Attribute
+	if attribute == "" && len(logical.Functions) > 0 {
+		attribute = logical.Functions[0].Attribute
+	}
+	c.Attribute = attributeName(attribute)
+
+	var err error
+	for _, xf := range logical.Functions {
+		capability := fixture.Capability{
+			Name: xf.Name,
+			Unit: units[xf.Attribute],
+		}
+		if capability.From, err = dmxValue(xf.DMXFrom, c.Resolution()); err != nil {
+			return c, err
+		}
+		capability.To = c.Max()
+		if n := len(c.Capabilities); n > 0 {
+			if capability.From <= c.Capabilities[n-1].From {
+				// functions that overlap the previous one, like those depending on
+				// the value of a mode master channel, cannot be told apart by value
+				continue
+			}
+			c.Capabilities[n-1].To = capability.From - 1
+		}
+		if capability.PhysicalFrom, err = physical(xf.PhysicalFrom, 0); err != nil {
+			return c, err
+		}
+		if capability.PhysicalTo, err = physical(xf.PhysicalTo, 1); err != nil {
+			return c, err
+		}
+		c.Capabilities = append(c.Capabilities, capability)
+	}
+
+	// GDTF 1.0 holds the default on the channel, later versions on the
+	// initial channel function
+	def := xc.Default
+	if def == "" {
+		initial := xc.InitialFunction[strings.LastIndex(xc.InitialFunction, ".")+1:]
+		for i, xf := range logical.Functions {
+			if i == 0 || xf.Name == initial {
+				def = xf.Default
+			}
+		}
+	}
+	if c.Default, err = dmxValue(def, c.Resolution()); err != nil {
+		return c, err
+	}
+
+	return c, nil
+}
+
+// attributeName returns the fixture attribute name of a GDTF attribute
+func attributeName(attribute string) string {
+	if name, ok := attributes[attribute]; ok {
+		return name
+	}
+	return strings.ToLower(attribute)
+}
+
+// dmxValue parses a GDTF DMX value of the form value/bytes and scales it to resolution bytes
+func dmxValue(s string, resolution int) (uint32, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	parts := strings.SplitN(s, "/", 2)
+	v, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid DMX value %q", s)
+	}
+	if len(parts) == 1 {
+		return uint32(v), nil
+	}
+
+	n, err := strconv.Atoi(parts[1])
+	if err != nil || n < 1 || n > 4 {
+		return 0, fmt.Errorf("invalid DMX value %q", s)
+	}
+	// byte mirroring is not used, values are shifted to the resolution of the channel
+	if n < resolution {
+		v <<= 8 * uint(resolution-n)
+	} else {
+		v >>= 8 * uint(n-resolution)
+	}
+	return uint32(v), nil
+}
+
+// physical parses a physical value, returning def when it is not set
+func physical(s string, def float64) (float64, error) {
+	if s == "" {
+		return def, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid physical value %q", s)
+	}
+	return v, nil
+}
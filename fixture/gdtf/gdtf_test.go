@@ -0,0 +1,119 @@
+package gdtf
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jsimonetti/go-artnet/fixture"
+)
+
+func TestOpen(t *testing.T) {
+	ft, err := Open("testdata/spot200.gdtf")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want, got := "Example", ft.Manufacturer; want != got {
+		t.Fatalf("unexpected manufacturer:\n- want: %q\n-  got: %q", want, got)
+	}
+	if want, got := 2, len(ft.Modes); want != got {
+		t.Fatalf("unexpected number of modes:\n- want: %d\n-  got: %d", want, got)
+	}
+
+	m, err := ft.Mode("Extended")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := 7, m.Footprint; want != got {
+		t.Fatalf("unexpected footprint:\n- want: %d\n-  got: %d", want, got)
+	}
+
+	tests := []struct {
+		attribute string
+		offsets   []int
+		def       uint32
+	}{
+		{attribute: fixture.Pan, offsets: []int{0, 1}, def: 0x8000},
+		{attribute: fixture.Tilt, offsets: []int{2, 3}, def: 0x8000},
+		{attribute: fixture.Strobe, offsets: []int{4}, def: 8},
+		{attribute: fixture.Intensity, offsets: []int{5}, def: 0},
+		{attribute: fixture.Gobo, offsets: []int{6}, def: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.attribute, func(t *testing.T) {
+			c, ok := m.Channel(tt.attribute)
+			if !ok {
+				t.Fatalf("missing attribute %q", tt.attribute)
+			}
+			if want, got := tt.offsets, c.Offsets; !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected offsets:\n- want: %v\n-  got: %v", want, got)
+			}
+			if want, got := tt.def, c.Default; want != got {
+				t.Fatalf("unexpected default:\n- want: %d\n-  got: %d", want, got)
+			}
+		})
+	}
+
+	shutter, _ := m.Channel(fixture.Strobe)
+	want := []fixture.Capability{
+		{Name: "Closed", From: 0, To: 7, Unit: "None"},
+		{Name: "Open", From: 8, To: 15, Unit: "None"},
+		{Name: "Strobe", From: 16, To: 255, PhysicalFrom: 1, PhysicalTo: 25, Unit: "Frequency"},
+	}
+	if got := shutter.Capabilities; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected capabilities:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestDMXValue(t *testing.T) {
+	tests := []struct {
+		s          string
+		resolution int
+		v          uint32
+	}{
+		{s: "128/1", resolution: 1, v: 128},
+		{s: "128/1", resolution: 2, v: 0x8000},
+		{s: "32768/2", resolution: 1, v: 128},
+		{s: "255", resolution: 1, v: 255},
+		{s: "", resolution: 2, v: 0},
+	}
+
+	for _, tt := range tests {
+		v, err := dmxValue(tt.s, tt.resolution)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want, got := tt.v, v; want != got {
+			t.Fatalf("unexpected value for %q:\n- want: %d\n-  got: %d", tt.s, want, got)
+		}
+	}
+}
+
+func TestOverlappingFunctions(t *testing.T) {
+	// the second and third function start at the same value as the one before
+	xc := xmlChannel{
+		Offset: "1",
+		Logical: []xmlLogicalChannel{{
+			Attribute: "Dimmer",
+			Functions: []xmlFunction{
+				{Name: "Dimmer", DMXFrom: "0/1"},
+				{Name: "DimmerMode2", DMXFrom: "0/1"},
+				{Name: "Full", DMXFrom: "200/1"},
+				{Name: "FullMode2", DMXFrom: "200/1"},
+			},
+		}},
+	}
+
+	c, err := channel(xc, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []fixture.Capability{
+		{Name: "Dimmer", From: 0, To: 199, PhysicalTo: 1},
+		{Name: "Full", From: 200, To: 255, PhysicalTo: 1},
+	}
+	if got := c.Capabilities; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected capabilities:\n- want: %v\n-  got: %v", want, got)
+	}
+}
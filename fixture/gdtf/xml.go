@@ -0,0 +1,56 @@
+package gdtf
+
+import (
+	"encoding/xml"
+)
+
+// xmlGDTF is the root element of description.xml
+type xmlGDTF struct {
+	XMLName     xml.Name       `xml:"GDTF"`
+	DataVersion string         `xml:"DataVersion,attr"`
+	FixtureType xmlFixtureType `xml:"FixtureType"`
+}
+
+type xmlFixtureType struct {
+	Name          string         `xml:"Name,attr"`
+	ShortName     string         `xml:"ShortName,attr"`
+	LongName      string         `xml:"LongName,attr"`
+	Manufacturer  string         `xml:"Manufacturer,attr"`
+	Description   string         `xml:"Description,attr"`
+	FixtureTypeID string         `xml:"FixtureTypeID,attr"`
+	Attributes    []xmlAttribute `xml:"AttributeDefinitions>Attributes>Attribute"`
+	Modes         []xmlMode      `xml:"DMXModes>DMXMode"`
+}
+
+type xmlAttribute struct {
+	Name         string `xml:"Name,attr"`
+	PhysicalUnit string `xml:"PhysicalUnit,attr"`
+}
+
+type xmlMode struct {
+	Name     string       `xml:"Name,attr"`
+	Channels []xmlChannel `xml:"DMXChannels>DMXChannel"`
+}
+
+type xmlChannel struct {
+	DMXBreak        string              `xml:"DMXBreak,attr"`
+	Offset          string              `xml:"Offset,attr"`
+	Default         string              `xml:"Default,attr"`
+	InitialFunction string              `xml:"InitialFunction,attr"`
+	Geometry        string              `xml:"Geometry,attr"`
+	Logical         []xmlLogicalChannel `xml:"LogicalChannel"`
+}
+
+type xmlLogicalChannel struct {
+	Attribute string        `xml:"Attribute,attr"`
+	Functions []xmlFunction `xml:"ChannelFunction"`
+}
+
+type xmlFunction struct {
+	Name         string `xml:"Name,attr"`
+	Attribute    string `xml:"Attribute,attr"`
+	DMXFrom      string `xml:"DMXFrom,attr"`
+	Default      string `xml:"Default,attr"`
+	PhysicalFrom string `xml:"PhysicalFrom,attr"`
+	PhysicalTo   string `xml:"PhysicalTo,attr"`
+}
@@ -0,0 +1,65 @@
+package ofl
+
+import (
+	"encoding/json"
+)
+
+// jsonFixture is the root object of an Open Fixture Library fixture definition
+type jsonFixture struct {
+	Name             string                  `json:"name"`
+	ShortName        string                  `json:"shortName"`
+	Categories       []string                `json:"categories"`
+	Matrix           *jsonMatrix             `json:"matrix"`
+	Channels         map[string]*jsonChannel `json:"availableChannels"`
+	TemplateChannels map[string]*jsonChannel `json:"templateChannels"`
+	Modes            []jsonMode              `json:"modes"`
+}
+
+type jsonMatrix struct {
+	PixelCount []int         `json:"pixelCount"`
+	PixelKeys  [][][]*string `json:"pixelKeys"`
+}
+
+type jsonChannel struct {
+	FineChannelAliases []string          `json:"fineChannelAliases"`
+	DMXValueResolution string            `json:"dmxValueResolution"`
+	DefaultValue       json.RawMessage   `json:"defaultValue"`
+	Capability         *jsonCapability   `json:"capability"`
+	Capabilities       []*jsonCapability `json:"capabilities"`
+}
+
+// jsonCapability holds a capability. Besides the fields below it can have many
+// type specific properties, the physical ones are read from Properties.
+type jsonCapability struct {
+	DMXRange      []uint32 `json:"dmxRange"`
+	Type          string   `json:"type"`
+	Color         string   `json:"color"`
+	ShutterEffect string   `json:"shutterEffect"`
+	EffectName    string   `json:"effectName"`
+	Comment       string   `json:"comment"`
+
+	Properties map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes a capability, keeping all properties
+func (c *jsonCapability) UnmarshalJSON(b []byte) error {
+	type capability jsonCapability
+	if err := json.Unmarshal(b, (*capability)(c)); err != nil {
+		return err
+	}
+	return json.Unmarshal(b, &c.Properties)
+}
+
+type jsonMode struct {
+	Name      string            `json:"name"`
+	ShortName string            `json:"shortName"`
+	Channels  []json.RawMessage `json:"channels"`
+}
+
+// jsonMatrixInsert is a mode channel entry that inserts the template channels of matrix pixels
+type jsonMatrixInsert struct {
+	Insert           string          `json:"insert"`
+	RepeatFor        json.RawMessage `json:"repeatFor"`
+	ChannelOrder     string          `json:"channelOrder"`
+	TemplateChannels []string        `json:"templateChannels"`
+}
@@ -0,0 +1,596 @@
+// Package ofl reads fixture types from Open Fixture Library (OFL) fixture definitions.
+//
+// Every mode of a fixture is converted into a fixture.Type. The capabilities of a
+// channel are kept with their DMX ranges and, where the definition has them,
+// physical values, so DMX values can be looked up with fixture.Channel.Value.
+// Switching channels and pixel groups are not supported.
+package ofl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jsimonetti/go-artnet/fixture"
+)
+
+// pixelKey is replaced by the key of a pixel in the names of template channels
+const pixelKey = "$pixelKey"
+
+// entities lists the capability properties holding a physical value, most significant first
+var entities = []string{
+	"speed", "duration", "time", "angle", "horizontalAngle", "verticalAngle",
+	"brightness", "colorTemperature", "distance", "fogOutput", "soundSensitivity",
+	"shakeSpeed", "shakeAngle", "parameter", "slotNumber",
+}
+
+// units lists the units of physical values, longest first
+var units = []string{"m^3/min", "bpm", "rpm", "deg", "Hz", "ms", "lm", "%", "K", "s", "m"}
+
+// Fixture holds an Open Fixture Library fixture definition
+type Fixture struct {
+	Name       string
+	ShortName  string
+	Categories []string
+
+	// Matrix holds the pixels of matrix fixtures, it is nil for other fixtures
+	Matrix *Matrix
+
+	// Modes holds a fixture type for each mode
+	Modes []*fixture.Type
+}
+
+// Mode returns the fixture type of the mode with the given name
+func (f *Fixture) Mode(name string) (*fixture.Type, error) {
+	for _, m := range f.Modes {
+		if m.Mode == name {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("fixture %q has no mode %q", f.Name, name)
+}
+
+// Matrix holds the pixels of a matrix fixture
+type Matrix struct {
+	// Pixels holds the pixel keys indexed by [z][y][x], an empty key is a position without pixel
+	Pixels [][][]string
+}
+
+// Size returns the number of positions in each dimension of the matrix
+func (m *Matrix) Size() (x, y, z int) {
+	z = len(m.Pixels)
+	if z > 0 {
+		y = len(m.Pixels[0])
+		if y > 0 {
+			x = len(m.Pixels[0][0])
+		}
+	}
+	return
+}
+
+// Position returns the zero based position of the pixel with the given key
+func (m *Matrix) Position(key string) (x, y, z int, ok bool) {
+	for z := range m.Pixels {
+		for y := range m.Pixels[z] {
+			for x, k := range m.Pixels[z][y] {
+				if k == key && key != "" {
+					return x, y, z, true
+				}
+			}
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// keys returns the pixel keys ordered by their position, the first axis changing fastest
+func (m *Matrix) keys(order string) []string {
+	type pixel struct {
+		key string
+		pos [3]int
+	}
+	var pixels []pixel
+	for z := range m.Pixels {
+		for y := range m.Pixels[z] {
+			for x, k := range m.Pixels[z][y] {
+				if k != "" {
+					pixels = append(pixels, pixel{key: k, pos: [3]int{x, y, z}})
+				}
+			}
+		}
+	}
+
+	axis := func(c byte) int { return int(c - 'X') }
+	sort.SliceStable(pixels, func(i, j int) bool {
+		for n := 2; n >= 0; n-- {
+			a := axis(order[n])
+			if pixels[i].pos[a] != pixels[j].pos[a] {
+				return pixels[i].pos[a] < pixels[j].pos[a]
+			}
+		}
+		return false
+	})
+
+	keys := make([]string, len(pixels))
+	for i, p := range pixels {
+		keys[i] = p.key
+	}
+	return keys
+}
+
+// Open reads the fixture definition at path
+func Open(path string) (*Fixture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open fixture definition: %v", err)
+	}
+	defer f.Close()
+
+	return Decode(f)
+}
+
+// Decode decodes a fixture definition
+func Decode(r io.Reader) (*Fixture, error) {
+	var jf jsonFixture
+	if err := json.NewDecoder(r).Decode(&jf); err != nil {
+		return nil, fmt.Errorf("failed to decode fixture definition: %v", err)
+	}
+
+	f := &Fixture{
+		Name:       jf.Name,
+		ShortName:  jf.ShortName,
+		Categories: jf.Categories,
+	}
+
+	if jf.Matrix != nil {
+		m, err := matrix(jf.Matrix)
+		if err != nil {
+			return nil, err
+		}
+		f.Matrix = m
+	}
+
+	d := &decoder{
+		jf:       &jf,
+		matrix:   f.Matrix,
+		channels: make(map[string]channelRef),
+	}
+	for key, c := range jf.Channels {
+		d.add(key, c, "")
+	}
+	if f.Matrix != nil {
+		for _, key := range f.Matrix.keys("XYZ") {
+			for name, c := range jf.TemplateChannels {
+				d.add(name, c, key)
+			}
+		}
+	}
+
+	for _, jm := range jf.Modes {
+		t, err := d.mode(jm)
+		if err != nil {
+			return nil, fmt.Errorf("fixture %q: %v", f.Name, err)
+		}
+		f.Modes = append(f.Modes, t)
+	}
+	return f, nil
+}
+
+// matrix converts the matrix of a fixture definition
+func matrix(jm *jsonMatrix) (*Matrix, error) {
+	m := &Matrix{}
+
+	if len(jm.PixelKeys) > 0 {
+		for _, plane := range jm.PixelKeys {
+			var rows [][]string
+			for _, row := range plane {
+				keys := make([]string, len(row))
+				for i, k := range row {
+					if k != nil {
+						keys[i] = *k
+					}
+				}
+				rows = append(rows, keys)
+			}
+			m.Pixels = append(m.Pixels, rows)
+		}
+		return m, nil
+	}
+
+	if len(jm.PixelCount) != 3 {
+		return nil, fmt.Errorf("matrix needs either pixelKeys or a pixelCount of 3 dimensions")
+	}
+	nx, ny, nz := jm.PixelCount[0], jm.PixelCount[1], jm.PixelCount[2]
+	dims := 0
+	for _, n := range jm.PixelCount {
+		if n > 1 {
+			dims++
+		}
+	}
+
+	// generated keys are numbered for a single dimension, positions otherwise
+	for z := 0; z < nz; z++ {
+		var rows [][]string
+		for y := 0; y < ny; y++ {
+			keys := make([]string, nx)
+			for x := range keys {
+				switch dims {
+				case 0, 1:
+					keys[x] = strconv.Itoa(x + y + z + 1)
+				case 2:
+					keys[x] = fmt.Sprintf("(%d, %d)", x+1, y+1)
+					if nx == 1 {
+						keys[x] = fmt.Sprintf("(%d, %d)", y+1, z+1)
+					} else if ny == 1 {
+						keys[x] = fmt.Sprintf("(%d, %d)", x+1, z+1)
+					}
+				default:
+					keys[x] = fmt.Sprintf("(%d, %d, %d)", x+1, y+1, z+1)
+				}
+			}
+			rows = append(rows, keys)
+		}
+		m.Pixels = append(m.Pixels, rows)
+	}
+	return m, nil
+}
+
+// channelRef refers to a byte of a channel, a fine channel alias refers to a lower byte
+type channelRef struct {
+	key      string
+	template string
+	pixel    string
+	byteIdx  int
+	channel  *jsonChannel
+}
+
+// decoder converts the modes of a fixture definition
+type decoder struct {
+	jf       *jsonFixture
+	matrix   *Matrix
+	channels map[string]channelRef
+}
+
+// add registers a channel and its fine channel aliases. Template channels are
+// added for each pixel.
+func (d *decoder) add(template string, c *jsonChannel, pixel string) {
+	key := strings.Replace(template, pixelKey, pixel, -1)
+	d.channels[key] = channelRef{key: key, template: template, pixel: pixel, channel: c}
+	for i, alias := range c.FineChannelAliases {
+		alias = strings.Replace(alias, pixelKey, pixel, -1)
+		d.channels[alias] = channelRef{key: key, template: template, pixel: pixel, byteIdx: i + 1, channel: c}
+	}
+}
+
+// names expands the channel entries of a mode into a channel name per DMX offset,
+// an empty name is an unused offset
+func (d *decoder) names(jm jsonMode) ([]string, error) {
+	var names []string
+	for _, raw := range jm.Channels {
+		if string(raw) == "null" {
+			names = append(names, "")
+			continue
+		}
+
+		var name string
+		if err := json.Unmarshal(raw, &name); err == nil {
+			names = append(names, name)
+			continue
+		}
+
+		var insert jsonMatrixInsert
+		if err := json.Unmarshal(raw, &insert); err != nil || insert.Insert != "matrixChannels" {
+			return nil, fmt.Errorf("mode %q: invalid channel entry %s", jm.Name, raw)
+		}
+		if d.matrix == nil {
+			return nil, fmt.Errorf("mode %q: matrix channels without matrix", jm.Name)
+		}
+
+		pixels, err := d.repeatFor(insert.RepeatFor)
+		if err != nil {
+			return nil, fmt.Errorf("mode %q: %v", jm.Name, err)
+		}
+
+		channel := func(template, pixel string) string {
+			return strings.Replace(template, pixelKey, pixel, -1)
+		}
+		if insert.ChannelOrder == "perChannel" {
+			for _, template := range insert.TemplateChannels {
+				for _, pixel := range pixels {
+					names = append(names, channel(template, pixel))
+				}
+			}
+		} else {
+			for _, pixel := range pixels {
+				for _, template := range insert.TemplateChannels {
+					names = append(names, channel(template, pixel))
+				}
+			}
+		}
+	}
+	return names, nil
+}
+
+// repeatFor returns the pixel keys a matrix insert repeats its template channels for
+func (d *decoder) repeatFor(raw json.RawMessage) ([]string, error) {
+	var keys []string
+	if err := json.Unmarshal(raw, &keys); err == nil {
+		return keys, nil
+	}
+
+	var order string
+	if err := json.Unmarshal(raw, &order); err != nil {
+		return nil, fmt.Errorf("invalid repeatFor %s", raw)
+	}
+
+	switch order {
+	case "eachPixelABC":
+		keys = d.matrix.keys("XYZ")
+		sort.SliceStable(keys, func(i, j int) bool {
+			return naturalLess(keys[i], keys[j])
+		})
+		return keys, nil
+	case "eachPixelXYZ", "eachPixelXZY", "eachPixelYXZ", "eachPixelYZX", "eachPixelZXY", "eachPixelZYX":
+		return d.matrix.keys(strings.TrimPrefix(order, "eachPixel")), nil
+	}
+	return nil, fmt.Errorf("unsupported repeatFor %q", order)
+}
+
+// naturalLess compares keys numerically when both are numbers
+func naturalLess(a, b string) bool {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return na < nb
+	}
+	return a < b
+}
+
+// mode converts a mode into a fixture type
+func (d *decoder) mode(jm jsonMode) (*fixture.Type, error) {
+	names, err := d.names(jm)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &fixture.Type{
+		Name:      d.jf.Name,
+		Mode:      jm.Name,
+		Footprint: len(names),
+	}
+
+	// collect the offsets of every byte of the used channels
+	var order []string
+	offsets := make(map[string][]int)
+	refs := make(map[string]channelRef)
+	for off, name := range names {
+		if name == "" {
+			continue
+		}
+		ref, ok := d.channels[name]
+		if !ok {
+			return nil, fmt.Errorf("mode %q: unknown channel %q", jm.Name, name)
+		}
+		if _, ok := offsets[ref.key]; !ok {
+			order = append(order, ref.key)
+			refs[ref.key] = ref
+		}
+		o := offsets[ref.key]
+		for len(o) <= ref.byteIdx {
+			o = append(o, -1)
+		}
+		o[ref.byteIdx] = off
+		offsets[ref.key] = o
+	}
+
+	used := make(map[string]bool)
+	for _, key := range order {
+		ref := refs[key]
+		o := offsets[key]
+		for i, off := range o {
+			if off < 0 {
+				return nil, fmt.Errorf("mode %q: channel %q misses byte %d", jm.Name, key, i+1)
+			}
+		}
+
+		c, err := channel(ref, o)
+		if err != nil {
+			return nil, fmt.Errorf("mode %q: channel %q: %v", jm.Name, key, err)
+		}
+		if used[c.Attribute] {
+			c.Attribute = strings.ToLower(key)
+		}
+		used[c.Attribute] = true
+		t.Channels = append(t.Channels, c)
+	}
+
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// channel converts a channel used at the given offsets
+func channel(ref channelRef, offsets []int) (fixture.Channel, error) {
+	jc := ref.channel
+	c := fixture.Channel{
+		Attribute: attributeName(ref.key, jc),
+		Offsets:   offsets,
+	}
+	if ref.pixel != "" {
+		c.Attribute = ref.pixel + "/" + attributeName(strings.Replace(ref.template, pixelKey, "", -1), jc)
+	}
+
+	// DMX values in the definition are given at the highest resolution of the
+	// channel, unless specified otherwise
+	valueRes := 1 + len(jc.FineChannelAliases)
+	if jc.DMXValueResolution != "" {
+		bits, err := strconv.Atoi(strings.TrimSuffix(jc.DMXValueResolution, "bit"))
+		if err != nil || bits%8 != 0 || bits < 8 || bits > 32 {
+			return c, fmt.Errorf("invalid dmxValueResolution %q", jc.DMXValueResolution)
+		}
+		valueRes = bits / 8
+	}
+	res := len(offsets)
+	maxValue := uint32(1)<<(8*uint(valueRes)) - 1
+
+	if len(jc.DefaultValue) > 0 {
+		var v uint32
+		var pct string
+		if err := json.Unmarshal(jc.DefaultValue, &v); err == nil {
+			c.Default = scale(v, valueRes, res, false)
+		} else if err := json.Unmarshal(jc.DefaultValue, &pct); err == nil && strings.HasSuffix(pct, "%") {
+			p, err := strconv.ParseFloat(strings.TrimSuffix(pct, "%"), 64)
+			if err != nil {
+				return c, fmt.Errorf("invalid defaultValue %q", pct)
+			}
+			c.Default = uint32(math.Round(p / 100 * float64(c.Max())))
+		} else {
+			return c, fmt.Errorf("invalid defaultValue %s", jc.DefaultValue)
+		}
+	}
+
+	capabilities := jc.Capabilities
+	if jc.Capability != nil {
+		single := *jc.Capability
+		single.DMXRange = []uint32{0, maxValue}
+		capabilities = []*jsonCapability{&single}
+	}
+	for _, jcap := range capabilities {
+		if len(jcap.DMXRange) != 2 || jcap.DMXRange[0] > jcap.DMXRange[1] || jcap.DMXRange[1] > maxValue {
+			return c, fmt.Errorf("invalid dmxRange %v", jcap.DMXRange)
+		}
+		capability := fixture.Capability{
+			Name:       capabilityName(jcap),
+			From:       scale(jcap.DMXRange[0], valueRes, res, false),
+			To:         scale(jcap.DMXRange[1], valueRes, res, true),
+			PhysicalTo: 1,
+		}
+		physical(jcap, &capability)
+		c.Capabilities = append(c.Capabilities, capability)
+	}
+	return c, nil
+}
+
+// attributeName returns the fixture attribute name of a channel, based on what it controls
+func attributeName(key string, jc *jsonChannel) string {
+	var types []*jsonCapability
+	if jc.Capability != nil {
+		types = append(types, jc.Capability)
+	}
+	types = append(types, jc.Capabilities...)
+
+	name := ""
+	for i, capability := range types {
+		var n string
+		switch capability.Type {
+		case "Intensity":
+			n = fixture.Intensity
+		case "ColorIntensity":
+			switch capability.Color {
+			case "Red", "Green", "Blue", "White", "Amber":
+				n = strings.ToLower(capability.Color)
+			}
+		case "Pan", "Tilt", "Zoom", "Focus":
+			n = strings.ToLower(capability.Type)
+		case "ShutterStrobe", "StrobeSpeed", "StrobeDuration":
+			n = fixture.Strobe
+		case "WheelSlot", "WheelShake", "WheelSlotRotation":
+			if strings.Contains(strings.ToLower(key), "gobo") {
+				n = fixture.Gobo
+			}
+		}
+		if i > 0 && n != name {
+			n = ""
+		}
+		name = n
+		if name == "" {
+			break
+		}
+	}
+	if name == "" {
+		name = strings.ToLower(strings.TrimSpace(key))
+	}
+	return name
+}
+
+// capabilityName returns the most specific name of a capability
+func capabilityName(jcap *jsonCapability) string {
+	switch {
+	case jcap.ShutterEffect != "":
+		return jcap.ShutterEffect
+	case jcap.EffectName != "":
+		return jcap.EffectName
+	case jcap.Color != "":
+		return jcap.Color
+	}
+	return jcap.Type
+}
+
+// physical sets the physical range of the capability from its first numeric entity
+func physical(jcap *jsonCapability, capability *fixture.Capability) {
+	for _, entity := range entities {
+		from, okFrom := jcap.Properties[entity]
+		to, okTo := from, okFrom
+		if !okFrom {
+			from, okFrom = jcap.Properties[entity+"Start"]
+			to, okTo = jcap.Properties[entity+"End"]
+		}
+		if !okFrom || !okTo {
+			continue
+		}
+
+		vFrom, unit, errFrom := parseEntity(from)
+		vTo, _, errTo := parseEntity(to)
+		if errFrom != nil || errTo != nil {
+			continue
+		}
+		capability.PhysicalFrom = vFrom
+		capability.PhysicalTo = vTo
+		capability.Unit = unit
+		return
+	}
+}
+
+// parseEntity parses a physical value like "10Hz" or 3, keywords like "fast" are not supported
+func parseEntity(raw json.RawMessage) (float64, string, error) {
+	var n float64
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return n, "", nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return 0, "", err
+	}
+	unit := ""
+	for _, u := range units {
+		if strings.HasSuffix(s, u) {
+			unit = u
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(strings.TrimSuffix(s, unit), 64)
+	if err != nil {
+		return 0, "", err
+	}
+	return v, unit, nil
+}
+
+// scale converts a DMX value from one resolution to another, the end of a range
+// is extended to the last fine value
+func scale(v uint32, from, to int, end bool) uint32 {
+	if from > to {
+		return v >> (8 * uint(from-to))
+	}
+	shift := 8 * uint(to-from)
+	v <<= shift
+	if end {
+		v |= uint32(1)<<shift - 1
+	}
+	return v
+}
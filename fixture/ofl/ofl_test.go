@@ -0,0 +1,114 @@
+package ofl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jsimonetti/go-artnet/fixture"
+)
+
+func TestOpen(t *testing.T) {
+	f, err := Open("testdata/led-bar.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want, got := 2, len(f.Modes); want != got {
+		t.Fatalf("unexpected number of modes:\n- want: %d\n-  got: %d", want, got)
+	}
+	if x, y, z := f.Matrix.Size(); x != 4 || y != 1 || z != 1 {
+		t.Fatalf("unexpected matrix size:\n- want: 4x1x1\n-  got: %dx%dx%d", x, y, z)
+	}
+
+	tests := []struct {
+		mode      string
+		footprint int
+		channels  map[string][]int
+	}{
+		{
+			mode:      "5-channel",
+			footprint: 5,
+			channels: map[string][]int{
+				fixture.Red:       {0},
+				fixture.Green:     {1},
+				fixture.Blue:      {2},
+				fixture.Intensity: {3},
+				fixture.Strobe:    {4},
+			},
+		},
+		{
+			mode:      "15-channel",
+			footprint: 15,
+			channels: map[string][]int{
+				fixture.Intensity: {0, 1},
+				fixture.Strobe:    {2},
+				"1/red":           {3},
+				"1/blue":          {5},
+				"4/green":         {13},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			m, err := f.Mode(tt.mode)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want, got := tt.footprint, m.Footprint; want != got {
+				t.Fatalf("unexpected footprint:\n- want: %d\n-  got: %d", want, got)
+			}
+			for attribute, offsets := range tt.channels {
+				c, ok := m.Channel(attribute)
+				if !ok {
+					t.Fatalf("missing attribute %q", attribute)
+				}
+				if want, got := offsets, c.Offsets; !reflect.DeepEqual(want, got) {
+					t.Fatalf("unexpected offsets of %q:\n- want: %v\n-  got: %v", attribute, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	f, err := Open("testdata/led-bar.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m, _ := f.Mode("5-channel")
+	dimmer, _ := m.Channel(fixture.Intensity)
+	if want, got := uint32(255), dimmer.Default; want != got {
+		t.Fatalf("unexpected default:\n- want: %d\n-  got: %d", want, got)
+	}
+
+	// the 8 bit channel in this mode uses the coarse byte of the 16 bit ranges
+	if want, got := uint32(255), dimmer.Capabilities[0].To; want != got {
+		t.Fatalf("unexpected range end:\n- want: %d\n-  got: %d", want, got)
+	}
+
+	strobe, _ := m.Channel(fixture.Strobe)
+	tests := []struct {
+		name     string
+		physical float64
+		v        uint32
+		ok       bool
+	}{
+		{name: "strobe", physical: 1, v: 10, ok: true},
+		{name: "strobe", physical: 25, v: 249, ok: true},
+		{name: "strobe", physical: 13, v: 130, ok: true},
+		{name: "strobe", physical: 30},
+		{name: "open", physical: 0, v: 0, ok: true},
+	}
+
+	for _, tt := range tests {
+		v, ok := strobe.Value(tt.name, tt.physical)
+		if want, got := tt.ok, ok; want != got {
+			t.Fatalf("unexpected lookup result for %s %v:\n- want: %v\n-  got: %v", tt.name, tt.physical, want, got)
+		}
+		if want, got := tt.v, v; want != got {
+			t.Fatalf("unexpected value for %s %v:\n- want: %d\n-  got: %d", tt.name, tt.physical, want, got)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package fixture
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jsimonetti/go-artnet"
+)
+
+// Fixture is a fixture type patched to a universe
+type Fixture struct {
+	Name     string
+	Type     *Type
+	Universe artnet.Address
+
+	// Address is the DMX start address of the fixture, from 1 to 512
+	Address int
+
+	u *artnet.Universe
+}
+
+// Patch holds the fixtures patched to the universes of a controller
+type Patch struct {
+	c        *artnet.Controller
+	fixtures map[string]*Fixture
+	lock     sync.Mutex
+}
+
+// NewPatch returns an empty patch for the controller
+func NewPatch(c *artnet.Controller) *Patch {
+	return &Patch{
+		c:        c,
+		fixtures: make(map[string]*Fixture),
+	}
+}
+
+// Add patches a fixture of type t to the universe at the DMX start address, and
+// sets its attributes to their default value. Fixtures may not overlap.
+func (p *Patch) Add(name string, t *Type, universe artnet.Address, address int) (*Fixture, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	if address < 1 || address+t.Footprint-1 > 512 {
+		return nil, fmt.Errorf("fixture %q: address %d-%d out of range 1-512", name, address, address+t.Footprint-1)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, ok := p.fixtures[name]; ok {
+		return nil, fmt.Errorf("fixture %q already patched", name)
+	}
+	for _, f := range p.fixtures {
+		if f.Universe == universe && address <= f.Address+f.Type.Footprint-1 && f.Address <= address+t.Footprint-1 {
+			return nil, fmt.Errorf("fixture %q at %s/%d overlaps fixture %q at %s/%d", name, universe, address, f.Name, f.Universe, f.Address)
+		}
+	}
+
+	u, err := p.c.Universe(universe)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Fixture{
+		Name:     name,
+		Type:     t,
+		Universe: universe,
+		Address:  address,
+		u:        u,
+	}
+	if err := f.Reset(); err != nil {
+		return nil, err
+	}
+	p.fixtures[name] = f
+	return f, nil
+}
+
+// Remove unpatches a fixture, leaving its DMX channels untouched
+func (p *Patch) Remove(name string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, ok := p.fixtures[name]; !ok {
+		return fmt.Errorf("fixture %q not patched", name)
+	}
+	delete(p.fixtures, name)
+	return nil
+}
+
+// Fixture returns the fixture with the given name
+func (p *Patch) Fixture(name string) (*Fixture, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	f, ok := p.fixtures[name]
+	return f, ok
+}
+
+// Fixtures returns all patched fixtures ordered by universe and address
+func (p *Patch) Fixtures() []*Fixture {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	fixtures := make([]*Fixture, 0, len(p.fixtures))
+	for _, f := range p.fixtures {
+		fixtures = append(fixtures, f)
+	}
+	sort.Slice(fixtures, func(i, j int) bool {
+		a, b := fixtures[i], fixtures[j]
+		if a.Universe != b.Universe {
+			return a.Universe.Integer() < b.Universe.Integer()
+		}
+		return a.Address < b.Address
+	})
+	return fixtures
+}
+
+// SetIntensityChannels marks the intensity channels of the patched fixtures on their
+// universes, so the grand master and blackout of the controller only scale those.
+// For fixtures without an intensity attribute the color channels are used.
+func (p *Patch) SetIntensityChannels() error {
+	channels := make(map[artnet.Address][]int)
+	for _, f := range p.Fixtures() {
+		if _, ok := channels[f.Universe]; !ok {
+			channels[f.Universe] = []int{}
+		}
+		channels[f.Universe] = append(channels[f.Universe], f.IntensityChannels()...)
+	}
+
+	for address, chs := range channels {
+		u, err := p.c.Universe(address)
+		if err != nil {
+			return err
+		}
+		if err := u.SetIntensityChannels(chs...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IntensityChannels returns the DMX channels that control the intensity of the fixture.
+// These are the intensity channels, or the color channels when it has none.
+func (f *Fixture) IntensityChannels() []int {
+	attributes := []string{Intensity}
+	if _, ok := f.Type.Channel(Intensity); !ok {
+		attributes = []string{Red, Green, Blue, White, Amber}
+	}
+
+	var channels []int
+	for _, attr := range attributes {
+		c, ok := f.Type.Channel(attr)
+		if !ok {
+			continue
+		}
+		for _, off := range c.Offsets {
+			channels = append(channels, f.Address+off)
+		}
+	}
+	return channels
+}
+
+// Set parses and applies an attribute assignment of the form "fixture.attribute = value".
+// The value is a raw DMX value ("255", "0x8000"), a percentage ("50%") or, for the
+// color attribute, a hex color ("#ff8800").
+func (p *Patch) Set(assignment string) error {
+	parts := strings.SplitN(assignment, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid assignment %q, expected fixture.attribute = value", assignment)
+	}
+	target, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+
+	i := strings.LastIndex(target, ".")
+	if i < 1 || i == len(target)-1 {
+		return fmt.Errorf("invalid assignment %q, expected fixture.attribute = value", assignment)
+	}
+	name, attribute := target[:i], target[i+1:]
+
+	f, ok := p.Fixture(name)
+	if !ok {
+		return fmt.Errorf("fixture %q not patched", name)
+	}
+
+	switch {
+	case attribute == Color:
+		c, err := parseColor(value)
+		if err != nil {
+			return err
+		}
+		return f.SetColor(c)
+
+	case strings.HasSuffix(value, "%"):
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil {
+			return fmt.Errorf("invalid percentage %q: %v", value, err)
+		}
+		return f.SetLevel(attribute, pct/100)
+
+	default:
+		v, err := strconv.ParseUint(value, 0, 32)
+		if err != nil {
+			return fmt.Errorf("invalid value %q: %v", value, err)
+		}
+		return f.Set(attribute, uint32(v))
+	}
+}
+
+// parseColor parses a hex color in the form #rrggbb
+func parseColor(s string) (color.RGBA, error) {
+	if len(s) != 7 || s[0] != '#' {
+		return color.RGBA{}, fmt.Errorf("invalid color %q, expected #rrggbb", s)
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q: %v", s, err)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// Reset sets all attributes of the fixture to their default value
+func (f *Fixture) Reset() error {
+	return f.u.Update(func(fr *artnet.Frame) error {
+		for _, c := range f.Type.Channels {
+			f.write(fr, c, c.Default)
+		}
+		return nil
+	})
+}
+
+// Set sets an attribute to a raw value, for a 16 bit attribute the value ranges from 0 to 65535
+func (f *Fixture) Set(attribute string, value uint32) error {
+	c, ok := f.Type.Channel(attribute)
+	if !ok {
+		return fmt.Errorf("fixture %q has no attribute %q", f.Name, attribute)
+	}
+	if value > c.Max() {
+		return fmt.Errorf("fixture %q: value %d of attribute %q out of range 0-%d", f.Name, value, attribute, c.Max())
+	}
+
+	return f.u.Update(func(fr *artnet.Frame) error {
+		f.write(fr, c, value)
+		return nil
+	})
+}
+
+// SetLevel sets an attribute to a level from 0 to 1, scaled to the resolution of the attribute
+func (f *Fixture) SetLevel(attribute string, level float64) error {
+	c, ok := f.Type.Channel(attribute)
+	if !ok {
+		return fmt.Errorf("fixture %q has no attribute %q", f.Name, attribute)
+	}
+	if level < 0 || level > 1 {
+		return fmt.Errorf("fixture %q: level %v of attribute %q out of range 0-1", f.Name, level, attribute)
+	}
+
+	return f.u.Update(func(fr *artnet.Frame) error {
+		f.write(fr, c, uint32(math.Round(level*float64(c.Max()))))
+		return nil
+	})
+}
+
+// SetColor sets the red, green and blue attributes of the fixture. When the fixture
+// has a white attribute, the white part of the color is output on it.
+func (f *Fixture) SetColor(col color.Color) error {
+	r, g, b, _ := col.RGBA()
+	levels := map[string]float64{
+		Red:   float64(r) / 0xffff,
+		Green: float64(g) / 0xffff,
+		Blue:  float64(b) / 0xffff,
+	}
+	if _, ok := f.Type.Channel(White); ok {
+		w := math.Min(levels[Red], math.Min(levels[Green], levels[Blue]))
+		levels[Red] -= w
+		levels[Green] -= w
+		levels[Blue] -= w
+		levels[White] = w
+	}
+
+	found := false
+	for attribute := range levels {
+		if _, ok := f.Type.Channel(attribute); ok {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("fixture %q has no color attributes", f.Name)
+	}
+
+	return f.u.Update(func(fr *artnet.Frame) error {
+		for attribute, level := range levels {
+			if c, ok := f.Type.Channel(attribute); ok {
+				f.write(fr, c, uint32(math.Round(level*float64(c.Max()))))
+			}
+		}
+		return nil
+	})
+}
+
+// Get returns the current raw value of an attribute
+func (f *Fixture) Get(attribute string) (uint32, error) {
+	c, ok := f.Type.Channel(attribute)
+	if !ok {
+		return 0, fmt.Errorf("fixture %q has no attribute %q", f.Name, attribute)
+	}
+
+	fr := f.u.Frame()
+	var v uint32
+	for _, off := range c.Offsets {
+		v = v<<8 | uint32(fr[f.Address-1+off])
+	}
+	return v, nil
+}
+
+// ChannelValues returns the DMX values, by channel number in the universe of the
+// fixture, that set an attribute to a raw value
+func (f *Fixture) ChannelValues(attribute string, value uint32) (map[int]uint8, error) {
+	c, ok := f.Type.Channel(attribute)
+	if !ok {
+		return nil, fmt.Errorf("fixture %q has no attribute %q", f.Name, attribute)
+	}
+	if value > c.Max() {
+		return nil, fmt.Errorf("fixture %q: value %d of attribute %q out of range 0-%d", f.Name, value, attribute, c.Max())
+	}
+
+	var fr artnet.Frame
+	f.write(&fr, c, value)
+	values := make(map[int]uint8, len(c.Offsets))
+	for _, off := range c.Offsets {
+		values[f.Address+off] = fr[f.Address-1+off]
+	}
+	return values, nil
+}
+
+// write writes the value of a channel into the frame, the most significant byte first
+func (f *Fixture) write(fr *artnet.Frame, c Channel, value uint32) {
+	for i, off := range c.Offsets {
+		shift := uint(8 * (len(c.Offsets) - 1 - i))
+		fr[f.Address-1+off] = uint8(value >> shift)
+	}
+}
@@ -0,0 +1,123 @@
+package fixture
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/jsimonetti/go-artnet"
+)
+
+var testWash = &Type{
+	Name:      "Wash",
+	Mode:      "10ch",
+	Footprint: 10,
+	Channels: []Channel{
+		{Attribute: Pan, Offsets: []int{0, 1}, Default: 0x8000},
+		{Attribute: Tilt, Offsets: []int{2, 3}, Default: 0x8000},
+		{Attribute: Intensity, Offsets: []int{4}},
+		{Attribute: Red, Offsets: []int{5}},
+		{Attribute: Green, Offsets: []int{6}},
+		{Attribute: Blue, Offsets: []int{7}},
+		{Attribute: White, Offsets: []int{8}},
+		{Attribute: Strobe, Offsets: []int{9}, Default: 255},
+	},
+}
+
+func TestTypeValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		t    Type
+		ok   bool
+	}{
+		{
+			name: "OK",
+			t:    *testWash,
+			ok:   true,
+		},
+		{
+			name: "OutsideFootprint",
+			t: Type{Footprint: 1, Channels: []Channel{
+				{Attribute: Pan, Offsets: []int{0, 1}},
+			}},
+		},
+		{
+			name: "Overlap",
+			t: Type{Footprint: 2, Channels: []Channel{
+				{Attribute: Pan, Offsets: []int{0, 1}},
+				{Attribute: Tilt, Offsets: []int{1}},
+			}},
+		},
+		{
+			name: "DefaultOutOfRange",
+			t: Type{Footprint: 1, Channels: []Channel{
+				{Attribute: Intensity, Offsets: []int{0}, Default: 256},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.t.Validate()
+			if want, got := tt.ok, err == nil; want != got {
+				t.Fatalf("unexpected validation result:\n- want: %v\n-  got: %v (%v)", want, got, err)
+			}
+		})
+	}
+}
+
+func TestPatch(t *testing.T) {
+	c := artnet.NewController("test", net.IP{2, 0, 0, 1}, artnet.NewDefaultLogger())
+	p := NewPatch(c)
+	universe := artnet.Address{SubUni: 1}
+
+	if _, err := p.Add("wash-1", testWash, universe, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := p.Add("wash-2", testWash, universe, 10); err == nil {
+		t.Fatal("expected error for overlapping fixture")
+	}
+	if _, err := p.Add("wash-3", testWash, universe, 11); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := p.Add("wash-4", testWash, universe, 510); err == nil {
+		t.Fatal("expected error for fixture past channel 512")
+	}
+
+	for _, s := range []string{
+		"wash-3.color = #ff8800",
+		"wash-3.intensity = 50%",
+		"wash-3.pan=0x1234",
+	} {
+		if err := p.Set(s); err != nil {
+			t.Fatalf("unexpected error for %q: %v", s, err)
+		}
+	}
+
+	u, _ := c.Universe(universe)
+	f := u.Frame()
+
+	want := []byte{0x12, 0x34, 0x80, 0x00, 128, 0xff, 0x88, 0x00, 0x00, 255}
+	for i, v := range want {
+		if f[10+i] != v {
+			t.Fatalf("unexpected channel %d:\n- want: %#x\n-  got: %#x", 11+i, v, f[10+i])
+		}
+	}
+
+	for _, s := range []string{
+		"wash-3.gobo = 1",
+		"wash-9.intensity = 1",
+		"wash-3.intensity = 256",
+		"wash-3",
+	} {
+		if err := p.Set(s); err == nil {
+			t.Fatalf("expected error for %q", s)
+		}
+	}
+	if err := p.SetIntensityChannels(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := []int{5, 15}, u.IntensityChannels(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected intensity channels:\n- want: %v\n-  got: %v", want, got)
+	}
+}
@@ -0,0 +1,162 @@
+package fixture
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Common attribute names
+const (
+	Intensity = "intensity"
+	Red       = "red"
+	Green     = "green"
+	Blue      = "blue"
+	White     = "white"
+	Amber     = "amber"
+	Pan       = "pan"
+	Tilt      = "tilt"
+	Gobo      = "gobo"
+	Strobe    = "strobe"
+	Zoom      = "zoom"
+	Focus     = "focus"
+
+	// Color is not a channel itself, it sets the red, green, blue and white channels
+	Color = "color"
+)
+
+// Channel describes the DMX channels of a single attribute of a fixture type
+type Channel struct {
+	// Attribute is the name of the attribute controlled by the channel
+	Attribute string
+
+	// Offsets holds the offset of each byte of the value from the start address of
+	// the fixture, the coarse channel first. A 16 bit attribute has two offsets.
+	Offsets []int
+
+	// Default is the value of the attribute when the fixture is patched
+	Default uint32
+
+	// Capabilities optionally describes what the DMX value ranges of the channel do
+	Capabilities []Capability
+}
+
+// Capability describes a DMX value range of a channel
+type Capability struct {
+	Name string
+
+	// From and To are the DMX values of the range at the resolution of the channel
+	From uint32
+	To   uint32
+
+	// PhysicalFrom and PhysicalTo are the physical values at the start and end of the range
+	PhysicalFrom float64
+	PhysicalTo   float64
+	Unit         string
+}
+
+// Resolution returns the number of bytes of the attribute value
+func (c Channel) Resolution() int {
+	return len(c.Offsets)
+}
+
+// Max returns the highest value of the attribute
+func (c Channel) Max() uint32 {
+	return uint32(1)<<(8*uint(len(c.Offsets))) - 1
+}
+
+// Capability returns the first capability with the given name, compared case-insensitively
+func (c Channel) Capability(name string) (Capability, bool) {
+	for _, capability := range c.Capabilities {
+		if strings.EqualFold(capability.Name, name) {
+			return capability, true
+		}
+	}
+	return Capability{}, false
+}
+
+// Value returns the DMX value that sets the named capability to a physical value,
+// like the value for a strobe of 10 Hz. Physical values are interpolated linearly
+// over the DMX range of the capability.
+func (c Channel) Value(name string, physical float64) (uint32, bool) {
+	for _, capability := range c.Capabilities {
+		if !strings.EqualFold(capability.Name, name) {
+			continue
+		}
+		if v, ok := capability.Value(physical); ok {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// Value returns the DMX value within the range of the capability for a physical value
+func (c Capability) Value(physical float64) (uint32, bool) {
+	lo, hi := math.Min(c.PhysicalFrom, c.PhysicalTo), math.Max(c.PhysicalFrom, c.PhysicalTo)
+	if physical < lo || physical > hi {
+		return 0, false
+	}
+	if c.PhysicalFrom == c.PhysicalTo {
+		return c.From, true
+	}
+
+	x := (physical - c.PhysicalFrom) / (c.PhysicalTo - c.PhysicalFrom)
+	return c.From + uint32(math.Round(x*float64(c.To-c.From))), true
+}
+
+// Type is a fixture type in a single DMX mode
+type Type struct {
+	Name string
+	Mode string
+
+	// Footprint is the number of DMX channels used by the fixture
+	Footprint int
+
+	Channels []Channel
+}
+
+// Channel returns the channel of the attribute
+func (t *Type) Channel(attribute string) (Channel, bool) {
+	for _, c := range t.Channels {
+		if c.Attribute == attribute {
+			return c, true
+		}
+	}
+	return Channel{}, false
+}
+
+// Validate checks that all channels fit in the footprint and do not overlap
+func (t *Type) Validate() error {
+	if t.Footprint < 1 || t.Footprint > 512 {
+		return fmt.Errorf("fixture type %q: footprint %d out of range 1-512", t.Name, t.Footprint)
+	}
+
+	used := make(map[int]string)
+	attributes := make(map[string]bool)
+	for _, c := range t.Channels {
+		if c.Attribute == "" || c.Attribute == Color {
+			return fmt.Errorf("fixture type %q: invalid attribute name %q", t.Name, c.Attribute)
+		}
+		if attributes[c.Attribute] {
+			return fmt.Errorf("fixture type %q: duplicate attribute %q", t.Name, c.Attribute)
+		}
+		attributes[c.Attribute] = true
+
+		if len(c.Offsets) < 1 || len(c.Offsets) > 4 {
+			return fmt.Errorf("fixture type %q: attribute %q has %d channels, must be 1-4", t.Name, c.Attribute, len(c.Offsets))
+		}
+		if c.Default > c.Max() {
+			return fmt.Errorf("fixture type %q: default %d of attribute %q out of range 0-%d", t.Name, c.Default, c.Attribute, c.Max())
+		}
+		for _, off := range c.Offsets {
+			if off < 0 || off >= t.Footprint {
+				return fmt.Errorf("fixture type %q: channel %d of attribute %q outside footprint", t.Name, off+1, c.Attribute)
+			}
+			if a, ok := used[off]; ok {
+				return fmt.Errorf("fixture type %q: channel %d used by %q and %q", t.Name, off+1, a, c.Attribute)
+			}
+			used[off] = c.Attribute
+		}
+	}
+	return nil
+}
@@ -0,0 +1,363 @@
+// Package fseq plays xLights FSEQ sequences over Art-Net.
+//
+// Version 1 and 2 sequences are supported, including the sparse channel ranges of
+// version 2. Frame blocks compressed with zstd and zlib are decompressed out of the
+// box, RegisterDecompressor replaces the decompressor of a compression.
+//
+// Channels of a sequence are numbered from 1, like xLights does. A Layout maps
+// them onto Art-Net universes.
+package fseq
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the compression of the frame blocks of a sequence
+type Compression uint8
+
+// compression types of version 2 sequences
+const (
+	None Compression = 0
+	Zstd Compression = 1
+	Zlib Compression = 2
+)
+
+// String returns the name of the compression
+func (c Compression) String() string {
+	switch c {
+	case None:
+		return "none"
+	case Zstd:
+		return "zstd"
+	case Zlib:
+		return "zlib"
+	}
+	return fmt.Sprintf("Compression(%d)", uint8(c))
+}
+
+// Decompressor returns a reader for the decompressed data of a frame block
+type Decompressor func(r io.Reader) (io.ReadCloser, error)
+
+var (
+	decompressors = map[Compression]Decompressor{
+		Zstd: zstdReader,
+		Zlib: zlib.NewReader,
+	}
+	decompressorLock sync.Mutex
+)
+
+// RegisterDecompressor registers the decompressor for a compression, replacing
+// any decompressor registered before. A nil decompressor removes it.
+func RegisterDecompressor(c Compression, fn Decompressor) {
+	decompressorLock.Lock()
+	defer decompressorLock.Unlock()
+	if fn == nil {
+		delete(decompressors, c)
+		return
+	}
+	decompressors[c] = fn
+}
+
+// zstdReader returns a reader for the decompressed data of a zstd frame block
+func zstdReader(r io.Reader) (io.ReadCloser, error) {
+	d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return d.IOReadCloser(), nil
+}
+
+// decompressor returns the decompressor for a compression
+func decompressor(c Compression) (Decompressor, bool) {
+	decompressorLock.Lock()
+	defer decompressorLock.Unlock()
+	fn, ok := decompressors[c]
+	return fn, ok
+}
+
+// Range is a range of channels stored in a sparse sequence
+type Range struct {
+	Start int
+	Count int
+}
+
+// block is a compressed block of consecutive frames
+type block struct {
+	frame  int
+	offset int64
+	length int64
+}
+
+// Sequence is an FSEQ sequence. Frames are read from the underlying file when they
+// are needed.
+type Sequence struct {
+	Major, Minor int
+
+	// Frames is the number of frames, Step the time between two frames
+	Frames int
+	Step   time.Duration
+
+	// Channels is the number of channels stored for each frame
+	Channels int
+	// Ranges holds the ranges the stored channels belong to, all channels from 1
+	// when it is empty
+	Ranges []Range
+
+	Compression Compression
+
+	// Media is the media file the sequence was created for, if any
+	Media string
+
+	r      io.ReaderAt
+	closer io.Closer
+	data   int64
+	blocks []block
+
+	lock sync.Mutex
+	// cached holds the decompressed frames of the block cachedBlock
+	cached      []byte
+	cachedBlock int
+}
+
+// Open opens the sequence at path, it must be closed when it is no longer used
+func Open(path string) (*Sequence, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewSequence(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	s.closer = f
+	return s, nil
+}
+
+// NewSequence reads the header of the sequence in r
+func NewSequence(r io.ReaderAt) (*Sequence, error) {
+	header := make([]byte, 32)
+	if _, err := r.ReadAt(header[:28], 0); err != nil {
+		return nil, fmt.Errorf("failed to read header: %v", err)
+	}
+	if magic := string(header[0:4]); magic != "PSEQ" && magic != "FSEQ" {
+		return nil, errors.New("not an FSEQ sequence")
+	}
+
+	s := &Sequence{
+		Major:       int(header[7]),
+		Minor:       int(header[6]),
+		Channels:    int(binary.LittleEndian.Uint32(header[10:14])),
+		Frames:      int(binary.LittleEndian.Uint32(header[14:18])),
+		Step:        time.Duration(header[18]) * time.Millisecond,
+		r:           r,
+		data:        int64(binary.LittleEndian.Uint16(header[4:6])),
+		cachedBlock: -1,
+	}
+	headerLength := int64(binary.LittleEndian.Uint16(header[8:10]))
+	if s.Step == 0 {
+		return nil, errors.New("invalid step time of 0ms")
+	}
+	if s.Channels == 0 {
+		return nil, errors.New("invalid channel count of 0")
+	}
+
+	switch s.Major {
+	case 1:
+	case 2:
+		if _, err := r.ReadAt(header[28:32], 28); err != nil {
+			return nil, fmt.Errorf("failed to read header: %v", err)
+		}
+		if err := s.readIndex(header); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported version %d.%d", s.Major, s.Minor)
+	}
+
+	if err := s.readVariableHeaders(headerLength); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// readIndex reads the compression blocks and sparse ranges of a version 2 sequence
+func (s *Sequence) readIndex(header []byte) error {
+	s.Compression = Compression(header[20] & 0x0f)
+	blocks := int(header[20]>>4)<<8 | int(header[21])
+	ranges := int(header[22])
+
+	index := make([]byte, 8*blocks+6*ranges)
+	if _, err := s.r.ReadAt(index, 32); err != nil {
+		return fmt.Errorf("failed to read block index: %v", err)
+	}
+
+	offset := s.data
+	for i := 0; i < blocks; i++ {
+		b := block{
+			frame:  int(binary.LittleEndian.Uint32(index[8*i:])),
+			offset: offset,
+			length: int64(binary.LittleEndian.Uint32(index[8*i+4:])),
+		}
+		offset += b.length
+		if b.length == 0 {
+			// unused entries at the end of the index
+			continue
+		}
+		if n := len(s.blocks); n > 0 && b.frame <= s.blocks[n-1].frame {
+			return fmt.Errorf("block %d starts at frame %d, before the previous block", i, b.frame)
+		}
+		s.blocks = append(s.blocks, b)
+	}
+	if s.Compression != None && len(s.blocks) == 0 {
+		return errors.New("compressed sequence without blocks")
+	}
+
+	stored := 0
+	for i := 0; i < ranges; i++ {
+		b := index[8*blocks+6*i:]
+		r := Range{
+			Start: int(uint24(b[0:3])) + 1,
+			Count: int(uint24(b[3:6])),
+		}
+		stored += r.Count
+		s.Ranges = append(s.Ranges, r)
+	}
+	if ranges > 0 && stored != s.Channels {
+		return fmt.Errorf("sparse ranges hold %d channels, expected %d", stored, s.Channels)
+	}
+	return nil
+}
+
+// readVariableHeaders reads the variable headers between the fixed header and the
+// channel data
+func (s *Sequence) readVariableHeaders(offset int64) error {
+	if offset >= s.data {
+		return nil
+	}
+	b := make([]byte, s.data-offset)
+	if _, err := s.r.ReadAt(b, offset); err != nil {
+		return fmt.Errorf("failed to read variable headers: %v", err)
+	}
+	for len(b) >= 4 {
+		length := int(binary.LittleEndian.Uint16(b[0:2]))
+		if length < 4 || length > len(b) {
+			break
+		}
+		if string(b[2:4]) == "mf" {
+			s.Media = string(bytes.TrimRight(b[4:length], "\x00"))
+		}
+		b = b[length:]
+	}
+	return nil
+}
+
+// Duration returns the duration of the sequence
+func (s *Sequence) Duration() time.Duration {
+	return time.Duration(s.Frames) * s.Step
+}
+
+// Size returns the number of channels in a frame returned by Frame
+func (s *Sequence) Size() int {
+	if len(s.Ranges) == 0 {
+		return s.Channels
+	}
+	size := 0
+	for _, r := range s.Ranges {
+		if end := r.Start + r.Count - 1; end > size {
+			size = end
+		}
+	}
+	return size
+}
+
+// Frame returns the data of frame i, the value of channel c at index c-1. Channels
+// outside the sparse ranges are 0.
+func (s *Sequence) Frame(i int) ([]byte, error) {
+	if i < 0 || i >= s.Frames {
+		return nil, fmt.Errorf("frame %d out of range 0-%d", i, s.Frames-1)
+	}
+
+	stored := make([]byte, s.Channels)
+	if err := s.read(i, stored); err != nil {
+		return nil, fmt.Errorf("failed to read frame %d: %v", i, err)
+	}
+	if len(s.Ranges) == 0 {
+		return stored, nil
+	}
+
+	frame := make([]byte, s.Size())
+	for _, r := range s.Ranges {
+		copy(frame[r.Start-1:], stored[:r.Count])
+		stored = stored[r.Count:]
+	}
+	return frame, nil
+}
+
+// read reads the stored channels of frame i into b
+func (s *Sequence) read(i int, b []byte) error {
+	if s.Compression == None {
+		_, err := s.r.ReadAt(b, s.data+int64(i)*int64(s.Channels))
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	n := len(s.blocks) - 1
+	for n > 0 && s.blocks[n].frame > i {
+		n--
+	}
+	if s.cachedBlock != n {
+		data, err := s.decompress(s.blocks[n])
+		if err != nil {
+			return err
+		}
+		s.cached, s.cachedBlock = data, n
+	}
+
+	offset := (i - s.blocks[n].frame) * s.Channels
+	if offset < 0 || offset+s.Channels > len(s.cached) {
+		return fmt.Errorf("frame not in block %d", n)
+	}
+	copy(b, s.cached[offset:])
+	return nil
+}
+
+// decompress returns the decompressed frames of a block
+func (s *Sequence) decompress(b block) ([]byte, error) {
+	fn, ok := decompressor(s.Compression)
+	if !ok {
+		return nil, fmt.Errorf("no decompressor registered for %s", s.Compression)
+	}
+	r, err := fn(io.NewSectionReader(s.r, b.offset, b.length))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// Close closes the file of a sequence opened with Open
+func (s *Sequence) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// uint24 decodes a little endian 24 bit integer
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
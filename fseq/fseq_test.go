@@ -0,0 +1,300 @@
+package fseq
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+	"github.com/jsimonetti/go-artnet/packet"
+)
+
+// encode returns a sequence with frames of 50ms. Compressed sequences hold blocks
+// of two frames, only zlib is compressed.
+func encode(major int, compression Compression, ranges []Range, frames [][]byte) []byte {
+	le := binary.LittleEndian
+	media := append([]byte{0, 0, 'm', 'f'}, "song.mp3\x00"...)
+	le.PutUint16(media, uint16(len(media)))
+
+	var data bytes.Buffer
+	var blocks [][2]uint32
+	for i := 0; i < len(frames); i += 2 {
+		raw := bytes.Join(frames[i:min(i+2, len(frames))], nil)
+		switch compression {
+		case None:
+			data.Write(raw)
+			continue
+		case Zlib:
+			var b bytes.Buffer
+			w := zlib.NewWriter(&b)
+			w.Write(raw)
+			w.Close()
+			raw = b.Bytes()
+		}
+		blocks = append(blocks, [2]uint32{uint32(i), uint32(len(raw))})
+		data.Write(raw)
+	}
+	if compression != None {
+		// unused entry at the end of the index
+		blocks = append(blocks, [2]uint32{0, 0})
+	}
+
+	header := make([]byte, 28)
+	if major == 2 {
+		header = make([]byte, 32+8*len(blocks)+6*len(ranges))
+		header[20] = byte(compression)
+		header[21] = byte(len(blocks))
+		header[22] = byte(len(ranges))
+		for i, b := range blocks {
+			le.PutUint32(header[32+8*i:], b[0])
+			le.PutUint32(header[36+8*i:], b[1])
+		}
+		for i, r := range ranges {
+			b := header[32+8*len(blocks)+6*i:]
+			b[0], b[1], b[2] = byte(r.Start-1), byte((r.Start-1)>>8), 0
+			b[3], b[4], b[5] = byte(r.Count), byte(r.Count>>8), 0
+		}
+	}
+	copy(header, "PSEQ")
+	le.PutUint16(header[4:6], uint16(len(header)+len(media)))
+	header[7] = byte(major)
+	le.PutUint16(header[8:10], uint16(len(header)))
+	le.PutUint32(header[10:14], uint32(len(frames[0])))
+	le.PutUint32(header[14:18], uint32(len(frames)))
+	header[18] = 50
+
+	return bytes.Join([][]byte{header, media, data.Bytes()}, nil)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func TestSequence(t *testing.T) {
+	frames := [][]byte{{1, 2, 3, 4}, {5, 6, 7, 8}, {9, 10, 11, 12}}
+	ranges := []Range{{Start: 3, Count: 1}, {Start: 10, Count: 3}}
+
+	tests := []struct {
+		name        string
+		major       int
+		compression Compression
+		ranges      []Range
+		frame       []byte
+	}{
+		{
+			name:  "Version1",
+			major: 1,
+			frame: []byte{5, 6, 7, 8},
+		},
+		{
+			name:  "Version2",
+			major: 2,
+			frame: []byte{5, 6, 7, 8},
+		},
+		{
+			name:        "Zlib",
+			major:       2,
+			compression: Zlib,
+			frame:       []byte{5, 6, 7, 8},
+		},
+		{
+			name:        "Sparse",
+			major:       2,
+			compression: Zlib,
+			ranges:      ranges,
+			frame:       []byte{0, 0, 5, 0, 0, 0, 0, 0, 0, 6, 7, 8},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSequence(bytes.NewReader(encode(tt.major, tt.compression, tt.ranges, frames)))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want, got := 150*time.Millisecond, s.Duration(); want != got {
+				t.Fatalf("unexpected duration:\n- want: %v\n-  got: %v", want, got)
+			}
+			if want, got := "song.mp3", s.Media; want != got {
+				t.Fatalf("unexpected media:\n- want: %s\n-  got: %s", want, got)
+			}
+
+			frame, err := s.Frame(1)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want, got := tt.frame, frame; !bytes.Equal(want, got) {
+				t.Fatalf("unexpected frame:\n- want: %v\n-  got: %v", want, got)
+			}
+			if frame, _ := s.Frame(2); frame[len(frame)-1] != 12 {
+				t.Fatalf("unexpected last frame: %v", frame)
+			}
+		})
+	}
+
+	// a registered decompressor replaces the default one
+	RegisterDecompressor(Zstd, func(r io.Reader) (io.ReadCloser, error) {
+		return ioutil.NopCloser(r), nil
+	})
+	defer RegisterDecompressor(Zstd, zstdReader)
+	s, _ := NewSequence(bytes.NewReader(encode(2, Zstd, nil, frames)))
+	if frame, err := s.Frame(2); err != nil || !bytes.Equal(frame, frames[2]) {
+		t.Fatalf("unexpected frame %v: %v", frame, err)
+	}
+
+	RegisterDecompressor(Zstd, nil)
+	if _, err := s.Frame(0); err == nil {
+		t.Fatal("expected error without decompressor")
+	}
+}
+
+func TestZstd(t *testing.T) {
+	// 4 frames of 128 channels in two blocks, compressed with the zstd command
+	s, err := Open("testdata/zstd.fseq")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := Zstd, s.Compression; want != got {
+		t.Fatalf("unexpected compression:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	for i := 0; i < 4; i++ {
+		want := make([]byte, 128)
+		for c := range want {
+			want[c] = byte(c/8*3 + i)
+		}
+		got, err := s.Frame(i)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !bytes.Equal(want, got) {
+			t.Fatalf("unexpected frame %d:\n- want: %v\n-  got: %v", i, want, got)
+		}
+	}
+}
+
+func TestPlayer(t *testing.T) {
+	c := artnet.NewController("test", net.IP{2, 0, 0, 1}, artnet.NewDefaultLogger())
+	frames := make([][]byte, 4)
+	for i := range frames {
+		frames[i] = bytes.Repeat([]byte{byte(i + 1)}, 6)
+	}
+	s, err := NewSequence(bytes.NewReader(encode(2, Zlib, nil, frames)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// channels 1-4 on two universes of 2 channels, channels 5-6 at the end of the first
+	layout := append(Universes(artnet.Address{SubUni: 0xff}, 1, 2, 2), Mapping{
+		Start:    5,
+		Count:    2,
+		Universe: artnet.Address{SubUni: 0xff},
+		Channel:  511,
+	})
+	if want, got := (artnet.Address{Net: 1}), layout[1].Universe; want != got {
+		t.Fatalf("unexpected universe:\n- want: %v\n-  got: %v", want, got)
+	}
+	if _, err := NewPlayer(c, s, Layout{{Start: 1, Count: 2, Channel: 512}}, artnet.NewDefaultLogger()); err == nil {
+		t.Fatal("expected error for mapping out of range")
+	}
+	p, err := NewPlayer(c, s, layout, artnet.NewDefaultLogger())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	u1, _ := c.Universe(artnet.Address{SubUni: 0xff})
+	u2, _ := c.Universe(artnet.Address{Net: 1})
+	output := func() []byte {
+		f1, f2 := u1.Frame(), u2.Frame()
+		return []byte{f1[0], f1[1], f2[0], f2[1], f1[510], f1[511]}
+	}
+
+	r, err := artnet.NewControllerReplay(c, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p.Start()
+	defer p.Stop()
+	tests := []struct {
+		name string
+		step time.Duration
+		sync time.Duration
+		loop bool
+		want byte
+	}{
+		// playback starts on the first frame of the controller
+		{name: "Start", step: time.Millisecond, want: 1},
+		{name: "Frame", step: 60 * time.Millisecond, want: 2},
+		{name: "Sync", step: 10 * time.Millisecond, sync: 160 * time.Millisecond, want: 4},
+		{name: "Loop", step: 50 * time.Millisecond, loop: true, want: 1},
+		{name: "End", step: 200 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		p.SetLoop(tt.loop)
+		if tt.sync != 0 {
+			p.Sync(tt.sync)
+		}
+		r.Advance(tt.step)
+		if tt.want == 0 {
+			continue
+		}
+		if want, got := bytes.Repeat([]byte{tt.want}, 6), output(); !reflect.DeepEqual(want, got) {
+			t.Fatalf("%s: unexpected output:\n- want: %v\n-  got: %v", tt.name, want, got)
+		}
+	}
+	if p.Playing() {
+		t.Fatal("expected player to stop at the end")
+	}
+
+	if err := p.Seek(110 * time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := byte(3), output()[0]; want != got {
+		t.Fatalf("unexpected output after seek:\n- want: %d\n-  got: %d", want, got)
+	}
+}
+
+func TestTimecode(t *testing.T) {
+	tests := []struct {
+		name string
+		tc   packet.ArtTimeCodePacket
+		want time.Duration
+	}{
+		{
+			name: "Film",
+			tc:   packet.ArtTimeCodePacket{Hours: 1, Minutes: 2, Seconds: 3, Frames: 12, Type: 0},
+			want: time.Hour + 2*time.Minute + 3500*time.Millisecond,
+		},
+		{
+			name: "EBU",
+			tc:   packet.ArtTimeCodePacket{Seconds: 1, Frames: 5, Type: 1},
+			want: 1200 * time.Millisecond,
+		},
+		{
+			name: "DropFrame",
+			tc:   packet.ArtTimeCodePacket{Minutes: 10, Type: 2},
+			want: 17982 * 1001 * time.Second / 30000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Timecode(&tt.tc)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want := tt.want; want != got {
+				t.Fatalf("unexpected position:\n- want: %v\n-  got: %v", want, got)
+			}
+		})
+	}
+}
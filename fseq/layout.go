@@ -0,0 +1,51 @@
+package fseq
+
+import (
+	"fmt"
+
+	"github.com/jsimonetti/go-artnet"
+)
+
+// Mapping maps Count channels of a sequence from Start onto a universe from Channel
+type Mapping struct {
+	Start    int
+	Count    int
+	Universe artnet.Address
+	Channel  int
+}
+
+// Layout maps the channels of a sequence onto universes
+type Layout []Mapping
+
+// Universes returns a layout of count consecutive universes from first, each
+// holding size channels of the sequence, starting at channel start. xLights
+// commonly uses 510 channels per universe, 170 RGB pixels.
+func Universes(first artnet.Address, start, size, count int) Layout {
+	layout := make(Layout, 0, count)
+	for i := 0; i < count; i++ {
+		n := first.Integer() + i
+		layout = append(layout, Mapping{
+			Start:    start + i*size,
+			Count:    size,
+			Universe: artnet.Address{Net: uint8(n >> 8), SubUni: uint8(n)},
+			Channel:  1,
+		})
+	}
+	return layout
+}
+
+// validate checks that the mappings fit in their universes
+func (l Layout) validate() error {
+	for i, m := range l {
+		if m.Start < 1 || m.Count < 1 {
+			return fmt.Errorf("mapping %d: invalid channels %d-%d of the sequence", i, m.Start, m.Start+m.Count-1)
+		}
+		if m.Universe.Net > 0x7f {
+			return fmt.Errorf("mapping %d: invalid net %d for universe, must be 0-127", i, m.Universe.Net)
+		}
+		if m.Channel < 1 || m.Channel+m.Count-1 > 512 {
+			return fmt.Errorf("mapping %d: channels %d-%d out of range 1-512", i, m.Channel, m.Channel+m.Count-1)
+		}
+	}
+	return nil
+}
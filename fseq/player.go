@@ -0,0 +1,128 @@
+package fseq
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+	"github.com/jsimonetti/go-artnet/packet"
+)
+
+// Player streams a sequence through a controller at the frame rate of the
+// sequence. Frames are output at the start of the controller frame they fall
+// into, so the controller should run at least at the frame rate of the sequence.
+type Player struct {
+	*artnet.Playback
+	c        *artnet.Controller
+	s        *Sequence
+	log      artnet.Logger
+	mappings map[artnet.Address][]Mapping
+
+	current int
+}
+
+// NewPlayer returns a player for sequence s, mapped onto universes by layout
+func NewPlayer(c *artnet.Controller, s *Sequence, layout Layout, log artnet.Logger) (*Player, error) {
+	if err := layout.validate(); err != nil {
+		return nil, err
+	}
+
+	p := &Player{
+		c:        c,
+		s:        s,
+		log:      log.With(artnet.Fields{"type": "FSEQ"}),
+		mappings: make(map[artnet.Address][]Mapping),
+		current:  -1,
+	}
+	for _, m := range layout {
+		p.mappings[m.Universe] = append(p.mappings[m.Universe], m)
+	}
+	p.Playback = artnet.NewPlayback(c, s.Duration(), p.play)
+	return p, nil
+}
+
+// Seek moves playback to position d and outputs the frame at that position
+func (p *Player) Seek(d time.Duration) error {
+	if d < 0 || d >= p.s.Duration() {
+		return fmt.Errorf("position %v out of range 0-%v", d, p.s.Duration())
+	}
+
+	var err error
+	p.Playback.Seek(d, func(position time.Duration) {
+		p.current = int(position / p.s.Step)
+		err = p.output(p.current)
+	})
+	return err
+}
+
+// Sync aligns playback to an external timecode at position d, like one received
+// in an ArtTimeCode. Drift of less than a frame is ignored.
+func (p *Player) Sync(d time.Duration) {
+	p.Playback.Sync(d, p.s.Step)
+}
+
+// play outputs the frame at position
+func (p *Player) play(position time.Duration, looped bool) {
+	if looped {
+		p.current = -1
+	}
+	frame := int(position / p.s.Step)
+	if frame == p.current || position >= p.s.Duration() {
+		return
+	}
+	p.current = frame
+
+	if err := p.output(frame); err != nil {
+		p.log.With(artnet.Fields{"frame": frame, "err": err}).Error("error playing frame")
+	}
+}
+
+// output outputs a frame on the universes of the layout
+func (p *Player) output(frame int) error {
+	data, err := p.s.Frame(frame)
+	if err != nil {
+		return err
+	}
+
+	for address, mappings := range p.mappings {
+		u, err := p.c.Universe(address)
+		if err != nil {
+			return err
+		}
+		u.Update(func(f *artnet.Frame) error {
+			for _, m := range mappings {
+				if m.Start > len(data) {
+					continue
+				}
+				end := m.Start - 1 + m.Count
+				if end > len(data) {
+					end = len(data)
+				}
+				copy(f[m.Channel-1:], data[m.Start-1:end])
+			}
+			return nil
+		})
+	}
+	return nil
+}
+
+// Timecode returns the position of an ArtTimeCode
+func Timecode(tc *packet.ArtTimeCodePacket) (time.Duration, error) {
+	seconds := time.Duration(tc.Hours)*time.Hour + time.Duration(tc.Minutes)*time.Minute +
+		time.Duration(tc.Seconds)*time.Second
+
+	switch tc.Type {
+	case 0:
+		return seconds + time.Duration(tc.Frames)*time.Second/24, nil
+	case 1:
+		return seconds + time.Duration(tc.Frames)*time.Second/25, nil
+	case 2:
+		// drop frame, two frame numbers are skipped every minute but every tenth
+		minutes := 60*int64(tc.Hours) + int64(tc.Minutes)
+		frames := int64(seconds/time.Second)*30 + int64(tc.Frames) - 2*(minutes-minutes/10)
+		return time.Duration(frames) * 1001 * time.Second / 30000, nil
+	case 3:
+		return seconds + time.Duration(tc.Frames)*time.Second/30, nil
+	}
+	return 0, fmt.Errorf("unknown timecode type %d", tc.Type)
+}
@@ -2,4 +2,7 @@ module github.com/jsimonetti/go-artnet
 
 go 1.13
 
-require github.com/sirupsen/logrus v1.9.4
+require (
+	github.com/klauspost/compress v1.11.13
+	github.com/sirupsen/logrus v1.9.4
+)
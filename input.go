@@ -0,0 +1,224 @@
+package artnet
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/jsimonetti/go-artnet/packet"
+)
+
+// inputs re-transmit unchanged data every 900ms, the specification recommends 800ms to 1000ms
+var inputKeepAlive = 900 * time.Millisecond
+
+// input sources are polled at the DMX512 frame rate
+var inputPollInterval = 25 * time.Millisecond
+
+// subscribers are forgotten after they miss two ArtPolls
+var subscriberTimeout = 7 * time.Second
+
+// InputSourceFn is polled for the current DMX data of an input port. An error
+// indicates the input has failed and stops the transmission of ArtDmx.
+type InputSourceFn func() (dmx [512]byte, err error)
+
+// input holds the state of a single input port
+type input struct {
+	dmx      [512]byte
+	active   bool
+	lastSent time.Time
+	sequence uint8
+	source   InputSourceFn
+}
+
+// subscriber is a device with an output port patched to one of our inputs
+type subscriber struct {
+	ip       net.IP
+	lastSeen time.Time
+}
+
+// WriteInput sets the DMX data received on the input port with the given index.
+// Changed data is transmitted immediately.
+func (n *Node) WriteInput(port int, dmx [512]byte) error {
+	n.inputLock.Lock()
+	if port < 0 || port >= len(n.inputs) {
+		n.inputLock.Unlock()
+		return fmt.Errorf("unknown input port %d", port)
+	}
+	in := n.inputs[port]
+	changed := !in.active || in.dmx != dmx
+	in.dmx = dmx
+	in.active = true
+	n.inputLock.Unlock()
+
+	if changed {
+		n.transmitInput(port, n.clock.Now())
+	}
+	return nil
+}
+
+// ClearInput marks the input port with the given index as failed, it will stop
+// transmitting until new data is written to it.
+func (n *Node) ClearInput(port int) error {
+	n.inputLock.Lock()
+	defer n.inputLock.Unlock()
+	if port < 0 || port >= len(n.inputs) {
+		return fmt.Errorf("unknown input port %d", port)
+	}
+	n.inputs[port].active = false
+	return nil
+}
+
+// SetInputSource sets a source which is polled for the DMX data of the input port
+// with the given index. A nil source stops polling.
+func (n *Node) SetInputSource(port int, source InputSourceFn) error {
+	n.inputLock.Lock()
+	defer n.inputLock.Unlock()
+	if port < 0 || port >= len(n.inputs) {
+		return fmt.Errorf("unknown input port %d", port)
+	}
+	n.inputs[port].source = source
+	return nil
+}
+
+// inputLoop polls the input sources and re-transmits unchanged inputs
+func (n *Node) inputLoop() {
+	ticker := time.NewTicker(inputPollInterval)
+	defer ticker.Stop()
+
+	// loop until shutdown
+	for {
+		select {
+		case <-ticker.C:
+			n.pollInputs(time.Now())
+
+		case <-n.shutdownCh:
+			return
+		}
+	}
+}
+
+// pollInputs polls the input sources and re-transmits the inputs unchanged at now
+func (n *Node) pollInputs(now time.Time) {
+	n.inputLock.Lock()
+	sources := make(map[int]InputSourceFn)
+	for i, in := range n.inputs {
+		if in.source != nil {
+			sources[i] = in.source
+		}
+	}
+	n.inputLock.Unlock()
+
+	for i, source := range sources {
+		dmx, err := source()
+		if err != nil {
+			n.log.With(Fields{"err": err, "port": i}).Debug("input source failed")
+			n.ClearInput(i)
+			continue
+		}
+		n.WriteInput(i, dmx)
+	}
+
+	n.inputLock.Lock()
+	var keepAlive []int
+	for i, in := range n.inputs {
+		if in.active && now.Sub(in.lastSent) >= inputKeepAlive {
+			keepAlive = append(keepAlive, i)
+		}
+	}
+	n.inputLock.Unlock()
+
+	for _, i := range keepAlive {
+		n.transmitInput(i, now)
+	}
+}
+
+// transmitInput sends the data of an input port to the subscribers of its address
+// or broadcasts it when nobody has subscribed
+func (n *Node) transmitInput(port int, now time.Time) {
+	n.configLock.Lock()
+	if port >= len(n.Config.InputPorts) || n.Config.InputPorts[port].Status.Disabled() {
+		n.configLock.Unlock()
+		return
+	}
+	address := n.Config.InputPorts[port].Address
+	status := n.Config.InputPorts[port].Status
+	n.Config.InputPorts[port].Status = status.WithData(true)
+	n.configLock.Unlock()
+
+	if !status.Data() {
+		n.transport.change()
+	}
+
+	n.inputLock.Lock()
+	in := n.inputs[port]
+	if !in.active {
+		n.inputLock.Unlock()
+		return
+	}
+	// the sequence runs from 1 to 255, 0 disables sequencing
+	in.sequence++
+	if in.sequence == 0 {
+		in.sequence = 1
+	}
+	in.lastSent = now
+	p := &packet.ArtDMXPacket{
+		Sequence: in.sequence,
+		Physical: uint8(port),
+		SubUni:   address.SubUni,
+		Net:      address.Net,
+		Data:     in.dmx,
+	}
+	var dst []net.UDPAddr
+	for _, sub := range n.subscribers[address] {
+		if now.Sub(sub.lastSeen) < subscriberTimeout {
+			dst = append(dst, net.UDPAddr{IP: sub.ip, Port: packet.ArtNetPort})
+		}
+	}
+	n.inputLock.Unlock()
+
+	b, err := p.MarshalBinary()
+	if err != nil {
+		n.log.With(Fields{"err": err}).Error("error creating ArtDmx packet for input")
+		return
+	}
+
+	if len(dst) == 0 {
+		dst = append(dst, n.broadcastAddr)
+	}
+	for _, addr := range dst {
+		n.transport.send(addr, b)
+	}
+}
+
+// updateSubscribers records the device as subscriber of the inputs it has an output for
+func (n *Node) updateSubscribers(cfg NodeConfig, now time.Time) {
+	n.inputLock.Lock()
+	defer n.inputLock.Unlock()
+
+	if len(n.inputs) == 0 {
+		return
+	}
+
+	for _, port := range cfg.OutputPorts {
+		subs := n.subscribers[port.Address]
+		found := false
+		for i := range subs {
+			if subs[i].ip.Equal(cfg.IP) {
+				subs[i].lastSeen = now
+				found = true
+			}
+		}
+		if !found {
+			subs = append(subs, subscriber{ip: cfg.IP, lastSeen: now})
+		}
+
+		// forget stale subscribers
+		for i := 0; i < len(subs); i++ {
+			if now.Sub(subs[i].lastSeen) >= subscriberTimeout {
+				subs = append(subs[:i], subs[i+1:]...)
+				i--
+			}
+		}
+		n.subscribers[port.Address] = subs
+	}
+}
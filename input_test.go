@@ -0,0 +1,114 @@
+package artnet
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jsimonetti/go-artnet/packet"
+	"github.com/jsimonetti/go-artnet/packet/code"
+)
+
+// inputNode returns a node with a single input port for universe 0:0.1, set up
+// like Start does but without network or loops, so the test drives it
+func inputNode() *Node {
+	n := NewNode("input", code.StNode, net.IP{2, 0, 0, 10}, NewDefaultLogger())
+	n.Config.InputPorts = []InputPort{{Address: Address{SubUni: 1}, Type: new(code.PortType).WithType("DMX512").WithInput(true)}}
+	n.sendCh = make(chan netPayload, 10)
+	n.pollCh = make(chan packet.ArtPollPacket, 10)
+	n.pollReplyCh = make(chan packet.ArtPollReplyPacket, 10)
+	n.inputs = []*input{{}}
+	n.subscribers = make(map[Address][]subscriber)
+	return n
+}
+
+// sentDMX returns the ArtDmx packets queued for sending by n
+func sentDMX(t *testing.T, n *Node) []netPayload {
+	var sent []netPayload
+	for {
+		select {
+		case s := <-n.sendCh:
+			p, err := packet.Unmarshal(s.data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, ok := p.(*packet.ArtDMXPacket); ok {
+				sent = append(sent, s)
+			}
+		default:
+			return sent
+		}
+	}
+}
+
+func TestNodeInput(t *testing.T) {
+	n := inputNode()
+	value := byte(1)
+	n.SetInputSource(0, func() (data [512]byte, err error) {
+		data[0] = value
+		return
+	})
+
+	// expect polls the input at now and checks the ArtDmx packets sent
+	expect := func(name string, now time.Time, dst ...net.IP) {
+		t.Helper()
+		n.pollInputs(now)
+		sent := sentDMX(t, n)
+		if want, got := len(dst), len(sent); want != got {
+			t.Fatalf("%s: unexpected ArtDmx packets:\n- want: %d\n-  got: %d", name, want, got)
+		}
+		for i := range dst {
+			if want, got := dst[i], sent[i].address.IP; !want.Equal(got) {
+				t.Fatalf("%s: unexpected destination:\n- want: %v\n-  got: %v", name, want, got)
+			}
+			p, _ := packet.Unmarshal(sent[i].data)
+			if want, got := value, p.(*packet.ArtDMXPacket).Data[0]; want != got {
+				t.Fatalf("%s: unexpected data:\n- want: %d\n-  got: %d", name, want, got)
+			}
+		}
+	}
+	handle := func(src net.UDPAddr, p packet.ArtNetPacket) {
+		p, err := packet.Unmarshal(marshal(t, p))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		n.handlePacket(p, src)
+	}
+	broadcast := defaultBroadcastAddr.IP
+
+	// changed data is broadcast while nobody subscribed
+	expect("Poll", time.Now(), broadcast)
+	expect("Unchanged", time.Now())
+	value = 2
+	expect("Changed", time.Now(), broadcast)
+
+	// unchanged data is sent again after the keep-alive interval
+	expect("BeforeKeepAlive", time.Now().Add(inputKeepAlive-inputPollInterval))
+	expect("KeepAlive", time.Now().Add(inputKeepAlive), broadcast)
+
+	// a device with an output for the universe subscribes to the data
+	subscriber := net.UDPAddr{IP: net.IP{2, 0, 0, 20}, Port: packet.ArtNetPort}
+	handle(subscriber, ArtPollReplyFromConfig(NodeConfig{
+		Name:        "output",
+		Type:        code.StNode,
+		IP:          subscriber.IP,
+		BaseAddress: Address{SubUni: 1},
+		OutputPorts: []OutputPort{{Address: Address{SubUni: 1}, Type: new(code.PortType).WithType("DMX512").WithOutput(true)}},
+	}))
+	value = 3
+	expect("Subscribed", time.Now(), subscriber.IP)
+
+	// subscribers are forgotten after 7 seconds
+	expect("Expired", time.Now().Add(subscriberTimeout), broadcast)
+
+	// an ArtInput disables the port
+	handle(subscriber, &packet.ArtInputPacket{NumPorts: 1, Input: [4]uint8{0x01}})
+	if !n.Config.InputPorts[0].Status.Disabled() {
+		t.Fatal("expected input port to be disabled")
+	}
+	value = 4
+	expect("Disabled", time.Now().Add(subscriberTimeout+inputKeepAlive))
+
+	handle(subscriber, &packet.ArtInputPacket{NumPorts: 1})
+	expect("Enabled", time.Now().Add(subscriberTimeout+inputKeepAlive), broadcast)
+}
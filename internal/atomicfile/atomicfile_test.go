@@ -0,0 +1,75 @@
+package atomicfile
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "atomicfile")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "file")
+
+	write := func(data string) error {
+		return Write(path, func(w io.Writer) error {
+			_, err := io.WriteString(w, data)
+			return err
+		})
+	}
+	check := func(name, data string, mode os.FileMode) {
+		t.Helper()
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if want, got := data, string(b); want != got {
+			t.Fatalf("%s: unexpected data:\n- want: %q\n-  got: %q", name, want, got)
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if want, got := mode, fi.Mode().Perm(); want != got {
+			t.Fatalf("%s: unexpected mode:\n- want: %v\n-  got: %v", name, want, got)
+		}
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if len(files) != 1 {
+			t.Fatalf("%s: expected temporary files to be removed, got %d files", name, len(files))
+		}
+	}
+
+	if err := write("first"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	check("New", "first", 0644)
+
+	// the mode of the replaced file is kept
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := write("second"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	check("Replaced", "second", 0600)
+
+	// a failed write leaves the previous file intact
+	failed := errors.New("failed")
+	err = Write(path, func(w io.Writer) error {
+		io.WriteString(w, "partial")
+		return failed
+	})
+	if err != failed {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", failed, err)
+	}
+	check("Failed", "second", 0600)
+}
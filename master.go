@@ -0,0 +1,332 @@
+package artnet
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// masters holds the grand master, blackout and submasters of a controller. They
+// scale the output after the mixer layers have been applied.
+type masters struct {
+	grand float64
+
+	// blackout is the requested state, blackoutLevel the level at the last frame
+	// which fades from blackoutFrom to the requested state
+	blackout      bool
+	blackoutLevel float64
+	blackoutFrom  float64
+	blackoutStart time.Time
+	blackoutFade  time.Duration
+
+	submasters map[string]*Submaster
+}
+
+// newMasters returns masters that leave the output untouched
+func newMasters() *masters {
+	return &masters{
+		grand:         1,
+		blackoutLevel: 1,
+		submasters:    make(map[string]*Submaster),
+	}
+}
+
+// blackoutAt returns the blackout level at now, and whether it is still fading
+func (m *masters) blackoutAt(now time.Time) (float64, bool) {
+	to := 1.0
+	if m.blackout {
+		to = 0
+	}
+	if m.blackoutLevel == to {
+		return to, false
+	}
+	if m.blackoutStart.IsZero() {
+		m.blackoutStart = now
+	}
+
+	x := 1.0
+	if m.blackoutFade > 0 {
+		x = float64(now.Sub(m.blackoutStart)) / float64(m.blackoutFade)
+	}
+	if x >= 1 {
+		m.blackoutLevel = to
+		return to, false
+	}
+	m.blackoutLevel = m.blackoutFrom + (to-m.blackoutFrom)*x
+	return m.blackoutLevel, true
+}
+
+// apply scales the output of a universe, it returns true while the blackout is fading
+func (m *masters) apply(buf *dmxBuffer, out *Frame, now time.Time) bool {
+	blackout, fading := m.blackoutAt(now)
+	intensity := m.grand * blackout
+
+	var subs []*Submaster
+	for _, s := range m.submasters {
+		if _, ok := s.channels[buf.address]; ok && s.level < 1 {
+			subs = append(subs, s)
+		}
+	}
+	if (intensity == 1 || (buf.intensity == nil && blackout == 1)) && len(subs) == 0 {
+		return fading
+	}
+
+	for ch := range out {
+		level := 1.0
+		switch {
+		case buf.intensity == nil:
+			// without intensity channels the blackout turns off every channel
+			level = blackout
+		case buf.intensity[ch]:
+			level = intensity
+		}
+		for _, s := range subs {
+			if s.channels[buf.address][ch] {
+				level *= s.level
+			}
+		}
+		if level < 1 {
+			out[ch] = uint8(math.Round(float64(out[ch]) * level))
+		}
+	}
+	return fading
+}
+
+// dirty marks the output of all universes to be mixed again. The caller must hold nodeLock.
+func (c *Controller) dirty() {
+	for _, buf := range c.universes {
+		buf.mixDirty = true
+	}
+}
+
+// SetGrandMaster sets the grand master level from 0 to 1. It scales the intensity
+// channels of all universes, universes without intensity channels are not scaled
+// and a warning is logged for them when the grand master is pulled down.
+func (c *Controller) SetGrandMaster(level float64) error {
+	if level < 0 || level > 1 {
+		return fmt.Errorf("grand master level %v out of range 0-1", level)
+	}
+
+	c.nodeLock.Lock()
+	defer c.nodeLock.Unlock()
+	if c.masters.grand == 1 && level < 1 {
+		for address, buf := range c.universes {
+			if buf.intensity == nil {
+				c.log.With(Fields{"address": address.String()}).Warn("grand master does not scale universe without intensity channels")
+			}
+		}
+	}
+	c.masters.grand = level
+	c.dirty()
+	return nil
+}
+
+// GrandMaster returns the grand master level
+func (c *Controller) GrandMaster() float64 {
+	c.nodeLock.Lock()
+	defer c.nodeLock.Unlock()
+	return c.masters.grand
+}
+
+// Blackout turns the intensity channels of all universes off, or back on, fading over
+// fade. Universes without intensity channels are turned off entirely. The data
+// written to the universes is kept, so turning the blackout off restores the
+// previous output.
+func (c *Controller) Blackout(enable bool, fade time.Duration) {
+	c.nodeLock.Lock()
+	defer c.nodeLock.Unlock()
+
+	m := c.masters
+	if m.blackout == enable {
+		return
+	}
+	m.blackout = enable
+	m.blackoutFrom = m.blackoutLevel
+	m.blackoutStart = time.Time{}
+	m.blackoutFade = fade
+	c.dirty()
+}
+
+// IsBlackout returns whether the blackout is on
+func (c *Controller) IsBlackout() bool {
+	c.nodeLock.Lock()
+	defer c.nodeLock.Unlock()
+	return c.masters.blackout
+}
+
+// SetIntensityChannels sets the channels of the universe that are scaled by the grand
+// master and blackout, other channels such as pan and tilt are left untouched. By
+// default a universe has no intensity channels, the grand master then leaves it
+// untouched and the blackout turns off all of its channels. SetIntensityChannels
+// of a fixture.Patch derives them from the patched fixtures.
+func (u *Universe) SetIntensityChannels(channels ...int) error {
+	var intensity [512]bool
+	for _, ch := range channels {
+		if err := checkChannels(ch, 1); err != nil {
+			return err
+		}
+		intensity[ch-1] = true
+	}
+
+	u.c.nodeLock.Lock()
+	defer u.c.nodeLock.Unlock()
+	buf := u.c.universe(u.address)
+	buf.intensity = &intensity
+	buf.mixDirty = true
+	return nil
+}
+
+// ResetIntensityChannels removes the intensity channels of the universe, the grand
+// master no longer scales it and the blackout turns off all of its channels
+func (u *Universe) ResetIntensityChannels() {
+	u.c.nodeLock.Lock()
+	defer u.c.nodeLock.Unlock()
+	buf := u.c.universe(u.address)
+	buf.intensity = nil
+	buf.mixDirty = true
+}
+
+// IntensityChannels returns the intensity channels of the universe, or nil when they
+// have not been set
+func (u *Universe) IntensityChannels() []int {
+	u.c.nodeLock.Lock()
+	defer u.c.nodeLock.Unlock()
+	buf := u.c.universe(u.address)
+	if buf.intensity == nil {
+		return nil
+	}
+	channels := []int{}
+	for i, ok := range buf.intensity {
+		if ok {
+			channels = append(channels, i+1)
+		}
+	}
+	return channels
+}
+
+// Submaster is a named master scaling a group of channels, on any universe
+type Submaster struct {
+	c        *Controller
+	name     string
+	level    float64
+	channels map[Address]*[512]bool
+}
+
+// Submaster returns the submaster with the given name, creating it at full level
+// when it does not exist
+func (c *Controller) Submaster(name string) *Submaster {
+	c.nodeLock.Lock()
+	defer c.nodeLock.Unlock()
+
+	s, ok := c.masters.submasters[name]
+	if !ok {
+		s = &Submaster{
+			c:        c,
+			name:     name,
+			level:    1,
+			channels: make(map[Address]*[512]bool),
+		}
+		c.masters.submasters[name] = s
+	}
+	return s
+}
+
+// Submasters returns the names of all submasters in alphabetical order
+func (c *Controller) Submasters() []string {
+	c.nodeLock.Lock()
+	defer c.nodeLock.Unlock()
+
+	names := make([]string, 0, len(c.masters.submasters))
+	for name := range c.masters.submasters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RemoveSubmaster removes a submaster, its channels are no longer scaled by it
+func (c *Controller) RemoveSubmaster(name string) {
+	c.nodeLock.Lock()
+	defer c.nodeLock.Unlock()
+
+	if _, ok := c.masters.submasters[name]; ok {
+		delete(c.masters.submasters, name)
+		c.dirty()
+	}
+}
+
+// Name returns the name of the submaster
+func (s *Submaster) Name() string {
+	return s.name
+}
+
+// Add adds channels of a universe to the submaster
+func (s *Submaster) Add(address Address, channels ...int) error {
+	for _, ch := range channels {
+		if err := checkChannels(ch, 1); err != nil {
+			return err
+		}
+	}
+
+	s.c.nodeLock.Lock()
+	defer s.c.nodeLock.Unlock()
+
+	set, ok := s.channels[address]
+	if !ok {
+		set = &[512]bool{}
+		s.channels[address] = set
+	}
+	for _, ch := range channels {
+		set[ch-1] = true
+	}
+	s.c.universe(address).mixDirty = true
+	return nil
+}
+
+// Remove removes channels of a universe from the submaster
+func (s *Submaster) Remove(address Address, channels ...int) error {
+	for _, ch := range channels {
+		if err := checkChannels(ch, 1); err != nil {
+			return err
+		}
+	}
+
+	s.c.nodeLock.Lock()
+	defer s.c.nodeLock.Unlock()
+
+	set, ok := s.channels[address]
+	if !ok {
+		return nil
+	}
+	for _, ch := range channels {
+		set[ch-1] = false
+	}
+	if *set == ([512]bool{}) {
+		delete(s.channels, address)
+	}
+	s.c.universe(address).mixDirty = true
+	return nil
+}
+
+// SetLevel sets the level of the submaster from 0 to 1
+func (s *Submaster) SetLevel(level float64) error {
+	if level < 0 || level > 1 {
+		return fmt.Errorf("submaster %q: level %v out of range 0-1", s.name, level)
+	}
+
+	s.c.nodeLock.Lock()
+	defer s.c.nodeLock.Unlock()
+	s.level = level
+	for address := range s.channels {
+		s.c.universe(address).mixDirty = true
+	}
+	return nil
+}
+
+// Level returns the level of the submaster
+func (s *Submaster) Level() float64 {
+	s.c.nodeLock.Lock()
+	defer s.c.nodeLock.Unlock()
+	return s.level
+}
@@ -0,0 +1,96 @@
+package artnet
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestMasters(t *testing.T) {
+	var log bytes.Buffer
+	l := logrus.New()
+	l.Out = &log
+	c := NewController("test", net.IP{2, 0, 0, 1}, NewLogger(logrus.NewEntry(l)))
+	u, _ := c.Universe(Address{SubUni: 1})
+	buf := c.universes[u.Address()]
+	now := time.Now()
+
+	output := func() [4]uint8 {
+		buf.remix(now)
+		return [4]uint8{buf.Output[0], buf.Output[1], buf.Output[2], buf.Output[3]}
+	}
+
+	// channel 1 is pan, channels 2-4 are intensity
+	u.SetRange(1, []byte{200, 200, 200, 200})
+
+	// without intensity channels the grand master leaves the output untouched and
+	// warns about it, the blackout turns off every channel
+	c.SetGrandMaster(0)
+	if want, got := [4]uint8{200, 200, 200, 200}, output(); want != got {
+		t.Fatalf("unexpected output without intensity channels:\n- want: %v\n-  got: %v", want, got)
+	}
+	if !strings.Contains(log.String(), "grand master does not scale universe without intensity channels") {
+		t.Fatalf("expected warning for universe without intensity channels, got %q", log.String())
+	}
+	c.Blackout(true, 0)
+	if want, got := [4]uint8{0, 0, 0, 0}, output(); want != got {
+		t.Fatalf("unexpected output in blackout without intensity channels:\n- want: %v\n-  got: %v", want, got)
+	}
+	c.Blackout(false, 0)
+	c.SetGrandMaster(1)
+	output()
+
+	if err := u.SetIntensityChannels(2, 3, 4); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := []int{2, 3, 4}, u.IntensityChannels(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected intensity channels:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	if err := c.SetGrandMaster(0.5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.SetGrandMaster(1.5); err == nil {
+		t.Fatal("expected error for grand master out of range")
+	}
+
+	sub := c.Submaster("front")
+	if err := sub.Add(u.Address(), 1, 4); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sub.SetLevel(0.5)
+	if want, got := [4]uint8{100, 100, 100, 50}, output(); want != got {
+		t.Fatalf("unexpected output:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	c.RemoveSubmaster("front")
+	c.SetGrandMaster(1)
+	if want, got := [4]uint8{200, 200, 200, 200}, output(); want != got {
+		t.Fatalf("unexpected output without masters:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// the blackout fades the intensity channels out and back in, keeping the data
+	c.Blackout(true, time.Second)
+	output()
+	now = now.Add(500 * time.Millisecond)
+	if want, got := [4]uint8{200, 100, 100, 100}, output(); want != got {
+		t.Fatalf("unexpected output while fading out:\n- want: %v\n-  got: %v", want, got)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if want, got := [4]uint8{200, 0, 0, 0}, output(); want != got {
+		t.Fatalf("unexpected output in blackout:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := (Frame{200, 200, 200, 200}), u.Frame(); want != got {
+		t.Fatalf("unexpected data in blackout:\n- want: %v\n-  got: %v", want[:4], got[:4])
+	}
+
+	c.Blackout(false, 0)
+	if want, got := [4]uint8{200, 200, 200, 200}, output(); want != got {
+		t.Fatalf("unexpected output after blackout:\n- want: %v\n-  got: %v", want, got)
+	}
+}
@@ -0,0 +1,187 @@
+package artnet
+
+import (
+	"net"
+	"time"
+)
+
+// sources are dropped from a merge when no ArtDmx has been received from them for 10 seconds
+var mergeTimeout = 10 * time.Second
+
+// MergeMode defines how an output port combines ArtDmx received from two sources
+type MergeMode uint8
+
+const (
+	// MergeHTP outputs the highest value of both sources per channel (Highest Takes Precedence).
+	// This is the default merge mode.
+	MergeHTP MergeMode = iota
+
+	// MergeLTP outputs the value of the source that changed a channel last (Latest Takes Precedence).
+	MergeLTP
+)
+
+// String returns a string representation of MergeMode
+func (m MergeMode) String() string {
+	if m == MergeLTP {
+		return "LTP"
+	}
+	return "HTP"
+}
+
+// MergeSource contains the last data received from a single source on an output port
+type MergeSource struct {
+	IP       net.IP
+	Data     [512]byte
+	LastSeen time.Time
+}
+
+// MergeState contains the merge information for a single output port
+type MergeState struct {
+	Address Address
+	Mode    MergeMode
+	Merging bool
+	Sources []MergeSource
+}
+
+// merger merges the ArtDmx streams for a single output port. The Art-Net specification
+// allows a node to merge at most two sources, a third source is ignored until one of the
+// others has timed out.
+type merger struct {
+	mode    MergeMode
+	sources []*MergeSource
+
+	// cancel is set by AcCancelMerge, the next source to send data will become the
+	// exclusive source of the port until it times out
+	cancel    bool
+	exclusive net.IP
+
+	output [512]byte
+}
+
+// newMerger returns a merger in the given mode
+func newMerger(mode MergeMode) *merger {
+	return &merger{mode: mode}
+}
+
+// merging indicates if data from more than one source is being merged
+func (m *merger) merging() bool {
+	return len(m.sources) > 1
+}
+
+// expire drops the sources that have not been seen since mergeTimeout
+// and reports if the set of sources has changed
+func (m *merger) expire(now time.Time) bool {
+	changed := false
+	for i := 0; i < len(m.sources); i++ {
+		if m.sources[i].LastSeen.Add(mergeTimeout).Before(now) {
+			if m.exclusive.Equal(m.sources[i].IP) {
+				m.exclusive = nil
+			}
+			m.sources = append(m.sources[:i], m.sources[i+1:]...)
+			changed = true
+			i--
+		}
+	}
+	if changed && len(m.sources) == 1 {
+		// a single source is output as is
+		m.output = m.sources[0].Data
+	}
+	return changed
+}
+
+// update merges the data received from ip into the output and returns the new output.
+// ok is false when the data has been ignored.
+func (m *merger) update(ip net.IP, dmx [512]byte, now time.Time) (output [512]byte, ok bool) {
+	m.expire(now)
+
+	if m.cancel {
+		// the first source after AcCancelMerge takes over the port
+		m.cancel = false
+		m.exclusive = ip
+		m.sources = nil
+	}
+	if m.exclusive != nil && !m.exclusive.Equal(ip) {
+		return m.output, false
+	}
+
+	var src *MergeSource
+	for _, s := range m.sources {
+		if s.IP.Equal(ip) {
+			src = s
+			break
+		}
+	}
+
+	if src == nil {
+		if len(m.sources) >= 2 {
+			// we only merge two sources
+			return m.output, false
+		}
+		src = &MergeSource{IP: ip}
+		m.sources = append(m.sources, src)
+		if len(m.sources) == 1 {
+			// this is the only source, copy everything below
+			src.Data = dmx
+			src.LastSeen = now
+			m.output = dmx
+			return m.output, true
+		}
+	}
+
+	switch m.mode {
+	case MergeLTP:
+		// only channels changed by this source take precedence
+		for i := range dmx {
+			if dmx[i] != src.Data[i] || len(m.sources) == 1 {
+				m.output[i] = dmx[i]
+			}
+		}
+		src.Data = dmx
+	default:
+		src.Data = dmx
+		m.output = m.htp()
+	}
+	src.LastSeen = now
+
+	return m.output, true
+}
+
+// htp returns the highest value per channel of all sources
+func (m *merger) htp() (output [512]byte) {
+	for _, s := range m.sources {
+		for i, v := range s.Data {
+			if v > output[i] {
+				output[i] = v
+			}
+		}
+	}
+	return
+}
+
+// setMode changes the merge mode of the port
+func (m *merger) setMode(mode MergeMode) {
+	m.mode = mode
+	if mode == MergeHTP && len(m.sources) > 0 {
+		m.output = m.htp()
+	}
+}
+
+// clear drops all sources and blacks out the output
+func (m *merger) clear() {
+	m.sources = nil
+	m.exclusive = nil
+	m.output = [512]byte{}
+}
+
+// state returns a copy of the merge state
+func (m *merger) state(address Address) MergeState {
+	s := MergeState{
+		Address: address,
+		Mode:    m.mode,
+		Merging: m.merging(),
+	}
+	for _, src := range m.sources {
+		s.Sources = append(s.Sources, *src)
+	}
+	return s
+}
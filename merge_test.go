@@ -0,0 +1,115 @@
+package artnet
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMergerUpdate(t *testing.T) {
+	a := net.IP{2, 0, 0, 1}
+	b := net.IP{2, 0, 0, 2}
+	c := net.IP{2, 0, 0, 3}
+	now := time.Now()
+
+	type update struct {
+		ip   net.IP
+		dmx  [512]byte
+		ok   bool
+		want [512]byte
+	}
+	tests := []struct {
+		name    string
+		mode    MergeMode
+		updates []update
+		merging bool
+	}{
+		{
+			name: "SingleSource",
+			mode: MergeHTP,
+			updates: []update{
+				{ip: a, dmx: [512]byte{10, 20}, ok: true, want: [512]byte{10, 20}},
+				{ip: a, dmx: [512]byte{5, 0}, ok: true, want: [512]byte{5, 0}},
+			},
+		},
+		{
+			name: "HTP",
+			mode: MergeHTP,
+			updates: []update{
+				{ip: a, dmx: [512]byte{10, 20, 0}, ok: true, want: [512]byte{10, 20, 0}},
+				{ip: b, dmx: [512]byte{0, 30, 40}, ok: true, want: [512]byte{10, 30, 40}},
+				{ip: a, dmx: [512]byte{50, 0, 0}, ok: true, want: [512]byte{50, 30, 40}},
+			},
+			merging: true,
+		},
+		{
+			name: "LTP",
+			mode: MergeLTP,
+			updates: []update{
+				{ip: a, dmx: [512]byte{10, 20, 0}, ok: true, want: [512]byte{10, 20, 0}},
+				{ip: b, dmx: [512]byte{0, 5, 40}, ok: true, want: [512]byte{10, 5, 40}},
+				{ip: a, dmx: [512]byte{10, 20, 1}, ok: true, want: [512]byte{10, 5, 1}},
+			},
+			merging: true,
+		},
+		{
+			name: "ThirdSourceIgnored",
+			mode: MergeHTP,
+			updates: []update{
+				{ip: a, dmx: [512]byte{10}, ok: true, want: [512]byte{10}},
+				{ip: b, dmx: [512]byte{20}, ok: true, want: [512]byte{20}},
+				{ip: c, dmx: [512]byte{30}, ok: false, want: [512]byte{20}},
+			},
+			merging: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMerger(tt.mode)
+			for i, u := range tt.updates {
+				out, ok := m.update(u.ip, u.dmx, now)
+				if ok != u.ok {
+					t.Fatalf("update %d: unexpected ok:\n- want: %v\n-  got: %v", i, u.ok, ok)
+				}
+				if out != u.want {
+					t.Fatalf("update %d: unexpected output:\n- want: %v\n-  got: %v", i, u.want[:4], out[:4])
+				}
+			}
+			if m.merging() != tt.merging {
+				t.Fatalf("unexpected merging:\n- want: %v\n-  got: %v", tt.merging, m.merging())
+			}
+		})
+	}
+}
+
+func TestMergerTimeoutAndCancel(t *testing.T) {
+	a := net.IP{2, 0, 0, 1}
+	b := net.IP{2, 0, 0, 2}
+	now := time.Now()
+
+	m := newMerger(MergeHTP)
+	m.update(a, [512]byte{10}, now)
+	m.update(b, [512]byte{20}, now.Add(5*time.Second))
+
+	// a times out, only b remains
+	if !m.expire(now.Add(11 * time.Second)) {
+		t.Fatal("expected source to expire")
+	}
+	if m.merging() {
+		t.Fatal("expected merge to end after timeout")
+	}
+	if m.output[0] != 20 {
+		t.Fatalf("unexpected output after timeout: %d", m.output[0])
+	}
+
+	// cancel merge, the next source becomes exclusive
+	m.update(a, [512]byte{30}, now.Add(12*time.Second))
+	m.cancel = true
+	if out, _ := m.update(a, [512]byte{5}, now.Add(13*time.Second)); out[0] != 5 {
+		t.Fatalf("unexpected output after cancel: %d", out[0])
+	}
+	if _, ok := m.update(b, [512]byte{50}, now.Add(13*time.Second)); ok {
+		t.Fatal("expected other source to be ignored after cancel")
+	}
+}
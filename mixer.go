@@ -0,0 +1,286 @@
+package artnet
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// LayerMode defines how a mixer layer combines with the output below it
+type LayerMode uint8
+
+const (
+	// LayerHTP outputs the highest of the layer and the output below it (Highest Takes Precedence)
+	LayerHTP LayerMode = iota
+
+	// LayerLTP outputs the value that changed last, the layer or the output below it
+	// (Latest Takes Precedence)
+	LayerLTP
+
+	// LayerOverride always replaces the output below it
+	LayerOverride
+
+	// LayerAdd adds the layer to the output below it
+	LayerAdd
+
+	// LayerSubtract subtracts the layer from the output below it
+	LayerSubtract
+)
+
+// String returns a string representation of LayerMode
+func (m LayerMode) String() string {
+	switch m {
+	case LayerLTP:
+		return "LTP"
+	case LayerOverride:
+		return "override"
+	case LayerAdd:
+		return "add"
+	case LayerSubtract:
+		return "subtract"
+	}
+	return "HTP"
+}
+
+// Layer is a named mixer layer on top of the data written to a universe. Layers are
+// mixed in order of priority, the data written to the universe is below all layers.
+// A layer only affects the channels that have been set on it.
+type Layer struct {
+	u        *Universe
+	name     string
+	priority int
+	mode     LayerMode
+	opacity  float64
+
+	data    Frame
+	set     [512]bool
+	changed [512]time.Time
+
+	releasing    bool
+	releaseStart time.Time
+	releaseFade  time.Duration
+}
+
+// Layer returns the mixer layer with the given name, creating it with full opacity
+// when it does not exist. The priority and mode of an existing layer are updated.
+func (u *Universe) Layer(name string, priority int, mode LayerMode) *Layer {
+	u.c.nodeLock.Lock()
+	defer u.c.nodeLock.Unlock()
+
+	buf := u.c.universe(u.address)
+	for _, l := range buf.layers {
+		if l.name == name && !l.releasing {
+			l.priority = priority
+			l.mode = mode
+			buf.sortLayers()
+			return l
+		}
+	}
+
+	l := &Layer{
+		u:        u,
+		name:     name,
+		priority: priority,
+		mode:     mode,
+		opacity:  1,
+	}
+	buf.layers = append(buf.layers, l)
+	buf.sortLayers()
+	return l
+}
+
+// Layers returns the names of the layers of the universe, from lowest to highest priority
+func (u *Universe) Layers() []string {
+	u.c.nodeLock.Lock()
+	defer u.c.nodeLock.Unlock()
+
+	buf := u.c.universe(u.address)
+	names := make([]string, len(buf.layers))
+	for i, l := range buf.layers {
+		names[i] = l.name
+	}
+	return names
+}
+
+// sortLayers orders the layers by priority, keeping the order of equal priorities
+func (buf *dmxBuffer) sortLayers() {
+	sort.SliceStable(buf.layers, func(i, j int) bool {
+		return buf.layers[i].priority < buf.layers[j].priority
+	})
+	buf.mixDirty = true
+}
+
+// Name returns the name of the layer
+func (l *Layer) Name() string {
+	return l.name
+}
+
+// update runs fn on the layer while holding the lock of the controller
+func (l *Layer) update(fn func(buf *dmxBuffer) error) error {
+	l.u.c.nodeLock.Lock()
+	defer l.u.c.nodeLock.Unlock()
+
+	buf := l.u.c.universe(l.u.address)
+	if err := fn(buf); err != nil {
+		return err
+	}
+	buf.mixDirty = true
+	return nil
+}
+
+// SetChannel sets a channel of the layer
+func (l *Layer) SetChannel(channel int, value uint8) error {
+	return l.SetRange(channel, []byte{value})
+}
+
+// SetRange sets consecutive channels of the layer starting at channel
+func (l *Layer) SetRange(channel int, values []byte) error {
+	if err := checkChannels(channel, len(values)); err != nil {
+		return err
+	}
+
+	return l.update(func(buf *dmxBuffer) error {
+		now := l.u.c.cNode.clock.Now()
+		for i, v := range values {
+			ch := channel - 1 + i
+			if !l.set[ch] || l.data[ch] != v {
+				l.changed[ch] = now
+			}
+			l.data[ch] = v
+			l.set[ch] = true
+		}
+		return nil
+	})
+}
+
+// ClearChannel removes a channel from the layer, it no longer affects the output below it
+func (l *Layer) ClearChannel(channel int) error {
+	if err := checkChannels(channel, 1); err != nil {
+		return err
+	}
+
+	return l.update(func(buf *dmxBuffer) error {
+		l.set[channel-1] = false
+		return nil
+	})
+}
+
+// SetOpacity sets the opacity of the layer from 0 to 1
+func (l *Layer) SetOpacity(opacity float64) error {
+	if opacity < 0 || opacity > 1 {
+		return fmt.Errorf("opacity %v out of range 0-1", opacity)
+	}
+
+	return l.update(func(buf *dmxBuffer) error {
+		l.opacity = opacity
+		return nil
+	})
+}
+
+// Release fades the layer out over fade and removes it. A new layer with the
+// same name can be created while the layer is fading out.
+func (l *Layer) Release(fade time.Duration) {
+	l.update(func(buf *dmxBuffer) error {
+		if !l.releasing {
+			l.releasing = true
+			l.releaseFade = fade
+		}
+		return nil
+	})
+}
+
+// level returns the opacity of the layer at now, and whether it has been released completely
+func (l *Layer) level(now time.Time) (float64, bool) {
+	if !l.releasing {
+		return l.opacity, false
+	}
+	if l.releaseStart.IsZero() {
+		l.releaseStart = now
+	}
+	if l.releaseFade <= 0 {
+		return 0, true
+	}
+	x := float64(now.Sub(l.releaseStart)) / float64(l.releaseFade)
+	if x >= 1 {
+		return 0, true
+	}
+	return l.opacity * (1 - x), false
+}
+
+// remix updates the output when the data, layers or masters have changed
+func (buf *dmxBuffer) remix(now time.Time) {
+	if !buf.mixDirty {
+		return
+	}
+	out := buf.mix(now)
+	if buf.masters != nil && buf.masters.apply(buf, &out, now) {
+		buf.mixDirty = true
+	}
+	if out != buf.Output {
+		buf.Output = out
+		buf.Stale = true
+	}
+}
+
+// mix mixes the layers on top of the data written to the universe. Released layers
+// are removed, while layers are fading out the buffer stays dirty.
+func (buf *dmxBuffer) mix(now time.Time) Frame {
+	out := buf.Data
+	buf.mixDirty = false
+	if len(buf.layers) == 0 {
+		return out
+	}
+
+	outTime := buf.changed
+	layers := buf.layers[:0]
+	for _, l := range buf.layers {
+		opacity, done := l.level(now)
+		if done {
+			continue
+		}
+		layers = append(layers, l)
+		if l.releasing {
+			buf.mixDirty = true
+		}
+
+		for ch := range out {
+			if !l.set[ch] {
+				continue
+			}
+			v := l.data[ch]
+			switch l.mode {
+			case LayerHTP:
+				if s := uint8(math.Round(float64(v) * opacity)); s > out[ch] {
+					out[ch] = s
+				}
+			case LayerLTP:
+				if !l.changed[ch].Before(outTime[ch]) {
+					out[ch] = crossfade(out[ch], v, opacity)
+					outTime[ch] = l.changed[ch]
+				}
+			case LayerOverride:
+				out[ch] = crossfade(out[ch], v, opacity)
+				if l.changed[ch].After(outTime[ch]) {
+					outTime[ch] = l.changed[ch]
+				}
+			case LayerAdd:
+				s := int(out[ch]) + int(math.Round(float64(v)*opacity))
+				out[ch] = uint8(math.Min(255, float64(s)))
+			case LayerSubtract:
+				s := int(out[ch]) - int(math.Round(float64(v)*opacity))
+				out[ch] = uint8(math.Max(0, float64(s)))
+			}
+		}
+	}
+	for i := len(layers); i < len(buf.layers); i++ {
+		buf.layers[i] = nil
+	}
+	buf.layers = layers
+	return out
+}
+
+// crossfade returns the value between from and to at x from 0 to 1
+func crossfade(from, to uint8, x float64) uint8 {
+	return uint8(math.Round(float64(from) + (float64(to)-float64(from))*x))
+}
@@ -0,0 +1,65 @@
+package artnet
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMixer(t *testing.T) {
+	c := NewController("test", net.IP{2, 0, 0, 1}, NewDefaultLogger())
+	u, _ := c.Universe(Address{SubUni: 1})
+	buf := c.universes[u.Address()]
+	now := time.Now()
+
+	output := func() [4]uint8 {
+		buf.remix(now)
+		return [4]uint8{buf.Output[0], buf.Output[1], buf.Output[2], buf.Output[3]}
+	}
+
+	u.SetRange(1, []byte{100, 100, 100, 100})
+
+	house := u.Layer("house", 20, LayerOverride)
+	cues := u.Layer("cues", 10, LayerHTP)
+	live := u.Layer("live", 10, LayerLTP)
+	if want, got := []string{"cues", "live", "house"}, u.Layers(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected layers:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	cues.SetRange(1, []byte{50, 200})
+	live.SetChannel(3, 10)
+	house.SetChannel(4, 255)
+	house.SetOpacity(0.5)
+	if want, got := [4]uint8{100, 200, 10, 178}, output(); want != got {
+		t.Fatalf("unexpected output:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// a later change below takes precedence over the LTP layer
+	u.SetChannel(3, 20)
+	if want, got := uint8(20), output()[2]; want != got {
+		t.Fatalf("unexpected LTP output:\n- want: %d\n-  got: %d", want, got)
+	}
+
+	// releasing fades the layer out and removes it
+	house.Release(time.Second)
+	output()
+	now = now.Add(500 * time.Millisecond)
+	if want, got := uint8(139), output()[3]; want != got {
+		t.Fatalf("unexpected output while releasing:\n- want: %d\n-  got: %d", want, got)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if want, got := uint8(100), output()[3]; want != got {
+		t.Fatalf("unexpected output after release:\n- want: %d\n-  got: %d", want, got)
+	}
+	if want, got := []string{"cues", "live"}, u.Layers(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected layers after release:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// add and subtract layers clip to the channel range
+	u.Layer("add", 30, LayerAdd).SetChannel(1, 200)
+	u.Layer("subtract", 30, LayerSubtract).SetRange(2, []byte{50, 20})
+	if want, got := [4]uint8{255, 150, 0, 100}, output(); want != got {
+		t.Fatalf("unexpected output of add and subtract layers:\n- want: %v\n-  got: %v", want, got)
+	}
+}
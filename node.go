@@ -1,8 +1,10 @@
 package artnet
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"math/rand"
 	"net"
 	"sync"
 	"time"
@@ -14,10 +16,18 @@ import (
 // NodeCallbackFn gets called when a new packet has been received and needs to be processed
 type NodeCallbackFn func(p packet.ArtNetPacket)
 
+// NodeOutputFn gets called with the (merged) DMX data to output on an output port
+type NodeOutputFn func(port int, address Address, dmx [512]byte)
+
+// handlerFn is a packet handler internal to the node. Unlike callbacks, handlers
+// are told where the packet came from.
+type handlerFn func(p packet.ArtNetPacket, src net.UDPAddr)
+
 // Node is the information known about a node
 type Node struct {
 	// Config holds the configuration of this node
-	Config NodeConfig
+	Config     NodeConfig
+	configLock sync.Mutex
 
 	broadcastAddr net.UDPAddr
 
@@ -39,11 +49,56 @@ type Node struct {
 	// pollCh will send ArtPollReply packets
 	pollReplyCh chan packet.ArtPollReplyPacket
 
+	// changeCh is signalled when the conditions of the node change
+	changeCh chan struct{}
+	// replyOnChange holds the controllers that asked for replies on change
+	replyOnChange map[string]time.Time
+	pollLock      sync.Mutex
+
 	log Logger
 
 	callbacks map[code.OpCode]NodeCallbackFn
+	handlers  map[code.OpCode]handlerFn
+
+	// taps are called with every packet sent or received
+	taps    map[int]TapFn
+	tapID   int
+	tapLock sync.Mutex
+
+	// clock and transport are replaced by a Replay while replaying traffic
+	clock     clock
+	transport transport
+
+	// outputs holds the merge state of every output port
+	outputs    []*merger
+	outputFn   NodeOutputFn
+	outputLock sync.Mutex
+
+	// syncLast is the time the last ArtSync has been accepted, the node outputs
+	// synchronously while ArtSync keeps arriving. Until then ArtDmx is held in pending.
+	syncLast time.Time
+	// dmxSource is the source of the most recent ArtDmx packet
+	dmxSource net.IP
+	pending   map[int]nodeFrame
+
+	// inputs holds the state of every input port
+	inputs      []*input
+	subscribers map[Address][]subscriber
+	inputLock   sync.Mutex
 }
 
+// nodeFrame is a DMX frame waiting to be output on a port
+type nodeFrame struct {
+	address Address
+	dmx     [512]byte
+}
+
+// nodes fall back to immediate output when no ArtSync has been received for 4 seconds
+var syncTimeout = 4 * time.Second
+
+// replies to ArtPoll are delayed randomly up to 1 second
+var pollReplyDelay = time.Second
+
 // netPayload contains bytes read from the network and/or an error
 type netPayload struct {
 	address net.UDPAddr
@@ -63,16 +118,23 @@ func NewNode(name string, style code.StyleCode, ip net.IP, log Logger, opts ...N
 		conn:          nil,
 		shutdown:      true,
 		log:           log.With(Fields{"type": "Node"}),
+		clock:         systemClock{},
 	}
+	n.transport = netTransport{n: n}
 
 	for _, opt := range opts {
 		n.SetOption(opt)
 	}
 
-	// initialize required node callbacks
-	n.callbacks = map[code.OpCode]NodeCallbackFn{
+	// initialize required node handlers
+	n.callbacks = make(map[code.OpCode]NodeCallbackFn)
+	n.handlers = map[code.OpCode]handlerFn{
 		code.OpPoll:      n.handlePacketPoll,
 		code.OpPollReply: n.handlePacketPollReply,
+		code.OpDMX:       n.handlePacketDMX,
+		code.OpAddress:   n.handlePacketAddress,
+		code.OpSync:      n.handlePacketSync,
+		code.OpInput:     n.handlePacketInput,
 	}
 
 	if len(ip) < 1 {
@@ -114,18 +176,11 @@ func (n *Node) isShutdown() bool {
 
 // Start will start the controller
 func (n *Node) Start() error {
-	if err := n.Config.validate(); err != nil {
+	if err := n.init(); err != nil {
 		return err
 	}
 	n.log.With(Fields{"ip": n.Config.IP.String(), "type": n.Config.Type.String()}).Debug("node started")
 
-	n.sendCh = make(chan netPayload, 10)
-	n.recvCh = make(chan netPayload, 10)
-	n.pollCh = make(chan packet.ArtPollPacket, 10)
-	n.pollReplyCh = make(chan packet.ArtPollReplyPacket, 10)
-	n.shutdownCh = make(chan struct{})
-	n.shutdown = false
-
 	c, err := net.ListenPacket("udp4", n.listenAddr.String())
 	if err != nil {
 		n.shutdownErr = fmt.Errorf("error net.ListenPacket: %s", err)
@@ -137,40 +192,122 @@ func (n *Node) Start() error {
 	go n.pollReplyLoop()
 	go n.recvLoop()
 	go n.sendLoop()
+	go n.expireLoop()
+	go n.inputLoop()
+
+	return nil
+}
+
+// init validates the config and sets up the state of the node before it is started
+func (n *Node) init() error {
+	if err := n.Config.validate(); err != nil {
+		return err
+	}
+	if !n.isShutdown() {
+		return fmt.Errorf("node has already been started")
+	}
+
+	n.sendCh = make(chan netPayload, 10)
+	n.recvCh = make(chan netPayload, 10)
+	n.pollCh = make(chan packet.ArtPollPacket, 10)
+	n.pollReplyCh = make(chan packet.ArtPollReplyPacket, 10)
+	n.shutdownCh = make(chan struct{})
+	n.shutdownLock.Lock()
+	n.shutdown = false
+	n.shutdownLock.Unlock()
 
+	n.changeCh = make(chan struct{}, 1)
+	n.replyOnChange = make(map[string]time.Time)
+	n.setupPorts()
 	return nil
 }
 
+// clock provides the time of a node, the system clock or the virtual clock of a Replay
+type clock interface {
+	Now() time.Time
+}
+
+// systemClock is the clock of a running node
+type systemClock struct{}
+
+// Now implements clock
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// transport carries the packets a node sends and the ArtPollReplies it hands to its
+// controller, over the network or into a Replay
+type transport interface {
+	// send sends a packet to address
+	send(address net.UDPAddr, data []byte)
+	// pollReply sends an ArtPollReply in reply to an ArtPoll
+	pollReply()
+	// change sends an ArtPollReply when the conditions of the node changed and a
+	// controller asked for replies on change
+	change()
+	// forwardPollReply hands an ArtPollReply received from another node to the controller
+	forwardPollReply(p packet.ArtPollReplyPacket)
+}
+
+// netTransport is the transport of a running node, it hands packets to the
+// network loops of the node
+type netTransport struct {
+	n *Node
+}
+
+// send queues a packet to be sent to the network
+func (t netTransport) send(address net.UDPAddr, data []byte) {
+	t.n.sendCh <- netPayload{
+		address: address,
+		data:    data,
+	}
+}
+
+// pollReply asks the poll reply loop to send an ArtPollReply
+func (t netTransport) pollReply() {
+	t.n.pollCh <- packet.ArtPollPacket{}
+}
+
+// change tells the poll reply loop the conditions of the node have changed
+func (t netTransport) change() {
+	select {
+	case t.n.changeCh <- struct{}{}:
+	default:
+	}
+}
+
+// forwardPollReply hands an ArtPollReply to the controller loop
+func (t netTransport) forwardPollReply(p packet.ArtPollReplyPacket) {
+	t.n.pollReplyCh <- p
+}
+
 // pollReplyLoop loops to reply to ArtPoll packets
-// when a controller asks for continuous updates, we do that using a ticker
+// when a controller asks for replies on change, we send one whenever our conditions change
 func (n *Node) pollReplyLoop() {
-	var timer time.Ticker
+	var delay <-chan time.Time
 
-	// create an ArtPollReply packet to send out in response to an ArtPoll packet
-	p := ArtPollReplyFromConfig(n.Config)
-	me, err := p.MarshalBinary()
-	if err != nil {
-		n.log.With(Fields{"err": err}).Error("error creating ArtPollReply packet for self")
-		return
-	}
+	// announce ourselves on power up
+	last := n.sendPollReply()
 
 	// loop until shutdown
 	for {
 		select {
-		case <-timer.C:
-			// if we should regularly send replies (can be requested by the controller)
-			// we send it here
-
 		case <-n.pollCh:
-			// reply with pollReply
-			n.log.With(nil).Debug("sending ArtPollReply")
-
-			n.sendCh <- netPayload{
-				address: n.broadcastAddr,
-				data:    me,
+			// replies are delayed randomly by up to a second to avoid reply storms,
+			// polls arriving in the meantime are answered by the same reply
+			if delay == nil {
+				delay = time.After(time.Duration(rand.Int63n(int64(pollReplyDelay))))
 			}
 
-			// TODO: if we are asked to send changes regularly, set the Ticker here
+		case <-delay:
+			delay = nil
+			last = n.sendPollReply()
+
+		case <-n.changeCh:
+			if delay != nil {
+				continue
+			}
+			last = n.sendChangedPollReply(last)
 
 		case <-n.shutdownCh:
 			return
@@ -178,6 +315,128 @@ func (n *Node) pollReplyLoop() {
 	}
 }
 
+// sendChangedPollReply sends an ArtPollReply when a controller asked for replies
+// on change and the reply differs from the last one sent. It returns the bytes of
+// the last reply.
+func (n *Node) sendChangedPollReply(last []byte) []byte {
+	if !n.wantsReplyOnChange(n.clock.Now()) {
+		return last
+	}
+	if pages, err := n.pollReply(); err != nil || bytes.Equal(bytes.Join(pages, nil), last) {
+		// nothing changed that is visible in the ArtPollReply
+		return last
+	}
+	return n.sendPollReply()
+}
+
+// pollReply creates the ArtPollReply packets for all pages from the current config
+func (n *Node) pollReply() ([][]byte, error) {
+	n.configLock.Lock()
+	replies := ArtPollRepliesFromConfig(n.Config)
+	n.configLock.Unlock()
+
+	var pages [][]byte
+	for _, p := range replies {
+		b, err := p.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, b)
+	}
+	return pages, nil
+}
+
+// sendPollReply broadcasts an ArtPollReply for every page and returns the bytes sent
+func (n *Node) sendPollReply() []byte {
+	pages, err := n.pollReply()
+	if err != nil {
+		n.log.With(Fields{"err": err}).Error("error creating ArtPollReply packet for self")
+		return nil
+	}
+
+	n.log.With(Fields{"pages": len(pages)}).Debug("sending ArtPollReply")
+	for _, me := range pages {
+		n.transport.send(n.broadcastAddr, me)
+	}
+	return bytes.Join(pages, nil)
+}
+
+// wantsReplyOnChange indicates if a controller asked for replies on change recently
+func (n *Node) wantsReplyOnChange(now time.Time) bool {
+	n.pollLock.Lock()
+	defer n.pollLock.Unlock()
+
+	for ip, seen := range n.replyOnChange {
+		if now.Sub(seen) >= subscriberTimeout {
+			delete(n.replyOnChange, ip)
+		}
+	}
+	return len(n.replyOnChange) > 0
+}
+
+// SetConfig replaces the configuration of the node. When the node is running, the
+// change is announced to the controllers that asked for replies on change.
+func (n *Node) SetConfig(cfg NodeConfig) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	n.configLock.Lock()
+	n.Config = cfg
+	n.configLock.Unlock()
+
+	n.setupPorts()
+	n.transport.change()
+	return nil
+}
+
+// setupPorts sizes the output and input state to the configured ports,
+// the state of existing ports is kept
+func (n *Node) setupPorts() {
+	n.configLock.Lock()
+	defer n.configLock.Unlock()
+
+	n.outputLock.Lock()
+	outputs := make([]*merger, len(n.Config.OutputPorts))
+	for i, port := range n.Config.OutputPorts {
+		mode := MergeHTP
+		if port.Status.LTP() {
+			mode = MergeLTP
+		}
+		if i < len(n.outputs) {
+			outputs[i] = n.outputs[i]
+			outputs[i].setMode(mode)
+			continue
+		}
+		outputs[i] = newMerger(mode)
+	}
+	n.outputs = outputs
+	if n.pending == nil {
+		n.pending = make(map[int]nodeFrame)
+	}
+	for port := range n.pending {
+		if port >= len(n.outputs) {
+			delete(n.pending, port)
+		}
+	}
+	n.outputLock.Unlock()
+
+	n.inputLock.Lock()
+	inputs := make([]*input, len(n.Config.InputPorts))
+	for i := range n.Config.InputPorts {
+		if i < len(n.inputs) {
+			inputs[i] = n.inputs[i]
+			continue
+		}
+		inputs[i] = &input{}
+	}
+	n.inputs = inputs
+	if n.subscribers == nil {
+		n.subscribers = make(map[Address][]subscriber)
+	}
+	n.inputLock.Unlock()
+}
+
 // sendLoop is used to send packets to the network
 func (n *Node) sendLoop() {
 	// loop until shutdown
@@ -196,6 +455,7 @@ func (n *Node) sendLoop() {
 				n.log.With(Fields{"error": err}).Debugf("error writing packet")
 				continue
 			}
+			n.tap(time.Now(), n.localAddr, payload.address, payload.data)
 			n.log.With(Fields{"dst": payload.address.String(), "bytes": num}).Debugf("packet sent")
 
 		}
@@ -204,7 +464,8 @@ func (n *Node) sendLoop() {
 
 // recvLoop is used to receive packets from the network
 // it starts a goroutine for dumping the msgs onto a channel,
-// the payload from that channel is then fed into a handler
+// the payload from that channel is then fed into a handler,
+// one packet at a time
 // due to the nature of broadcasting, we see our own sent
 // packets to, but we ignore them
 func (n *Node) recvLoop() {
@@ -240,6 +501,7 @@ func (n *Node) recvLoop() {
 				data:    make([]byte, num),
 			}
 			copy(payload.data, b)
+			n.tap(time.Now(), *from, n.localAddr, payload.data)
 			n.recvCh <- payload
 		}
 	}()
@@ -261,8 +523,10 @@ func (n *Node) recvLoop() {
 			// unmarshalled packet that must have a valid
 			// opcode which we can now extract and handle
 			// the packet by calling the corresponding
-			// callback
-			go n.handlePacket(p)
+			// callback. Packets are handled in the order
+			// they arrive, so an ArtSync never overtakes
+			// the ArtDmx sent before it.
+			n.handlePacket(p, payload.address)
 
 		case <-n.shutdownCh:
 			return
@@ -271,43 +535,347 @@ func (n *Node) recvLoop() {
 }
 
 // handlePacket contains the logic for dealing with incoming packets
-func (n *Node) handlePacket(p packet.ArtNetPacket) {
-	callback, ok := n.callbacks[p.GetOpCode()]
+// a registered callback takes precedence over the handler of the node
+func (n *Node) handlePacket(p packet.ArtNetPacket, src net.UDPAddr) {
+	if callback, ok := n.callbacks[p.GetOpCode()]; ok {
+		callback(p)
+		return
+	}
+
+	handler, ok := n.handlers[p.GetOpCode()]
 	if !ok {
 		n.log.With(Fields{"packet": p}).Debugf("ignoring unhandled packet")
 		return
 	}
 
-	callback(p)
+	handler(p, src)
 }
 
-func (n *Node) handlePacketPoll(p packet.ArtNetPacket) {
+func (n *Node) handlePacketPoll(p packet.ArtNetPacket, src net.UDPAddr) {
 	poll, ok := p.(*packet.ArtPollPacket)
 	if !ok {
 		n.log.With(Fields{"packet": p}).Debugf("unknown packet type")
 		return
 	}
 
-	n.pollCh <- *poll
+	n.pollLock.Lock()
+	if poll.TalkToMe.ReplyOnChange() {
+		n.replyOnChange[src.IP.String()] = n.clock.Now()
+	} else {
+		delete(n.replyOnChange, src.IP.String())
+	}
+	n.pollLock.Unlock()
+
+	n.transport.pollReply()
+}
+
+func (n *Node) handlePacketPollReply(p packet.ArtNetPacket, src net.UDPAddr) {
+	pollReply, ok := p.(*packet.ArtPollReplyPacket)
+	if !ok {
+		n.log.With(Fields{"packet": p}).Debugf("unknown packet type")
+		return
+	}
+
+	// devices with outputs patched to our inputs subscribe to their data
+	n.updateSubscribers(ConfigFromArtPollReply(*pollReply), n.clock.Now())
+
+	// only forward these packets if we are a controller
+	n.configLock.Lock()
+	controller := n.Config.Type == code.StController
+	n.configLock.Unlock()
+	if !controller {
+		return
+	}
+	n.transport.forwardPollReply(*pollReply)
 }
 
-func (n *Node) handlePacketPollReply(p packet.ArtNetPacket) {
-	// only handle these packets if we are a controller
-	if n.Config.Type == code.StController {
-		pollReply, ok := p.(*packet.ArtPollReplyPacket)
+func (n *Node) handlePacketInput(p packet.ArtNetPacket, src net.UDPAddr) {
+	in, ok := p.(*packet.ArtInputPacket)
+	if !ok {
+		n.log.With(Fields{"packet": p}).Debugf("unknown packet type")
+		return
+	}
+
+	// the inputs are those of the page with the BindIndex
+	n.configLock.Lock()
+	pg, ok := n.Config.page(in.BindIndex)
+	if !ok {
+		n.configLock.Unlock()
+		n.log.With(Fields{"bindIndex": in.BindIndex}).Debug("ignoring ArtInput for unknown BindIndex")
+		return
+	}
+	for i := 0; i < int(in.NumPorts) && i < len(in.Input) && i < len(pg.slots); i++ {
+		port := pg.slots[i].input
+		if port < 0 || port >= len(n.Config.InputPorts) {
+			continue
+		}
+		disabled := in.Input[i]&0x01 > 0
+		n.Config.InputPorts[port].Status = n.Config.InputPorts[port].Status.WithDisabled(disabled)
+	}
+	n.configLock.Unlock()
+	n.transport.change()
+
+	// an ArtInput is answered with an ArtPollReply
+	n.transport.pollReply()
+}
+
+func (n *Node) handlePacketDMX(p packet.ArtNetPacket, src net.UDPAddr) {
+	dmx, ok := p.(*packet.ArtDMXPacket)
+	if !ok {
+		n.log.With(Fields{"packet": p}).Debugf("unknown packet type")
+		return
+	}
+
+	address := Address{Net: dmx.Net, SubUni: dmx.SubUni}
+	now := n.clock.Now()
+
+	var ports []int
+	var frames [][512]byte
+	changed := false
+
+	n.configLock.Lock()
+	n.outputLock.Lock()
+	n.dmxSource = src.IP
+	synchronous := n.synchronous(now)
+	for i, port := range n.Config.OutputPorts {
+		if port.Address != address || i >= len(n.outputs) {
+			continue
+		}
+		m := n.outputs[i]
+		out, ok := m.update(src.IP, dmx.Data, now)
+		status := port.Status.WithMerging(m.merging()).WithData(true)
+		if status != port.Status {
+			n.Config.OutputPorts[i].Status = status
+			changed = true
+		}
 		if !ok {
-			n.log.With(Fields{"packet": p}).Debugf("unknown packet type")
+			n.log.With(Fields{"src": src.IP.String(), "address": address.String()}).Debug("ignoring ArtDmx, already merging two sources")
+			continue
+		}
+		if synchronous && !m.merging() {
+			// hold the data until the next ArtSync, merging ports ignore ArtSync
+			n.pending[i] = nodeFrame{address: address, dmx: out}
+			continue
+		}
+		ports = append(ports, i)
+		frames = append(frames, out)
+	}
+	n.outputLock.Unlock()
+	n.configLock.Unlock()
+
+	if changed {
+		n.transport.change()
+	}
+	for i := range ports {
+		n.output(ports[i], address, frames[i])
+	}
+}
+
+func (n *Node) handlePacketSync(p packet.ArtNetPacket, src net.UDPAddr) {
+	if _, ok := p.(*packet.ArtSyncPacket); !ok {
+		n.log.With(Fields{"packet": p}).Debugf("unknown packet type")
+		return
+	}
+
+	n.outputLock.Lock()
+	if !src.IP.Equal(n.dmxSource) {
+		// only the source of the most recent ArtDmx may synchronise the output
+		n.outputLock.Unlock()
+		n.log.With(Fields{"src": src.IP.String()}).Debug("ignoring ArtSync from other source than ArtDmx")
+		return
+	}
+	n.syncLast = n.clock.Now()
+	pending := n.takePending()
+	n.outputLock.Unlock()
+
+	for port, frame := range pending {
+		n.output(port, frame.address, frame.dmx)
+	}
+}
+
+// synchronous indicates if ArtDmx is held until ArtSync arrives. This
+// assumes the outputLock is held.
+func (n *Node) synchronous(now time.Time) bool {
+	return !n.syncLast.IsZero() && now.Sub(n.syncLast) < syncTimeout
+}
+
+// takePending returns the frames held for ArtSync and clears them. This
+// assumes the outputLock is held.
+func (n *Node) takePending() map[int]nodeFrame {
+	pending := n.pending
+	n.pending = make(map[int]nodeFrame)
+	return pending
+}
+
+// handlePacketAddress handles the merge commands of an ArtAddress: cancel merge,
+// LTP and HTP merging and clearing outputs. The other commands, and the names,
+// addresses and port switches in the packet, are ignored.
+func (n *Node) handlePacketAddress(p packet.ArtNetPacket, src net.UDPAddr) {
+	addr, ok := p.(*packet.ArtAddressPacket)
+	if !ok {
+		n.log.With(Fields{"packet": p}).Debugf("unknown packet type")
+		return
+	}
+
+	cmd := addr.Command
+	n.log.With(Fields{"src": src.IP.String(), "command": cmd.String(), "bindIndex": addr.BindIndex}).Debug("received ArtAddress")
+
+	// per-port commands address the ports of the page with the BindIndex
+	n.configLock.Lock()
+	pg, ok := n.Config.page(addr.BindIndex)
+	n.configLock.Unlock()
+	if !ok {
+		n.log.With(Fields{"bindIndex": addr.BindIndex}).Debug("ignoring ArtAddress for unknown BindIndex")
+		return
+	}
+	output := func() (int, bool) {
+		if cmd.Port() >= len(pg.slots) || pg.slots[cmd.Port()].output < 0 {
+			return 0, false
+		}
+		return pg.slots[cmd.Port()].output, true
+	}
+
+	switch {
+	case cmd == code.AcCancelMerge:
+		n.outputLock.Lock()
+		for _, slot := range pg.slots {
+			if slot.output >= 0 && slot.output < len(n.outputs) && n.outputs[slot.output].merging() {
+				n.outputs[slot.output].cancel = true
+			}
+		}
+		n.outputLock.Unlock()
+
+	case cmd >= code.AcMergeLtp0 && cmd <= code.AcMergeLtp3:
+		if port, ok := output(); ok {
+			if err := n.SetMergeMode(port, MergeLTP); err != nil {
+				n.log.With(Fields{"err": err}).Debug("error handling ArtAddress")
+			}
+		}
+
+	case cmd >= code.AcMergeHtp0 && cmd <= code.AcMergeHtp3:
+		if port, ok := output(); ok {
+			if err := n.SetMergeMode(port, MergeHTP); err != nil {
+				n.log.With(Fields{"err": err}).Debug("error handling ArtAddress")
+			}
+		}
+
+	case cmd >= code.AcClearOp0 && cmd <= code.AcClearOp3:
+		port, ok := output()
+		if !ok {
+			break
+		}
+		n.configLock.Lock()
+		n.outputLock.Lock()
+		if port >= len(n.outputs) {
+			n.outputLock.Unlock()
+			n.configLock.Unlock()
+			break
+		}
+		n.outputs[port].clear()
+		address := n.Config.OutputPorts[port].Address
+		n.outputLock.Unlock()
+		n.configLock.Unlock()
+		n.output(port, address, [512]byte{})
+	}
+
+	// an ArtAddress is answered with an ArtPollReply
+	n.transport.pollReply()
+}
+
+// output hands the DMX data for an output port to the output function
+func (n *Node) output(port int, address Address, dmx [512]byte) {
+	if n.outputFn == nil {
+		return
+	}
+	n.outputFn(port, address, dmx)
+}
+
+// expireLoop drops merge sources that have stopped sending
+// and returns to immediate output when ArtSync has stopped
+func (n *Node) expireLoop() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	// loop until shutdown
+	for {
+		select {
+		case <-ticker.C:
+			n.expire(time.Now())
+
+		case <-n.shutdownCh:
 			return
 		}
+	}
+}
+
+// expire drops the merge sources that have stopped sending at now
+// and outputs the frames held for an ArtSync that has timed out
+func (n *Node) expire(now time.Time) {
+	changed := false
+	n.configLock.Lock()
+	n.outputLock.Lock()
+	for i, m := range n.outputs {
+		if m.expire(now) && i < len(n.Config.OutputPorts) {
+			n.Config.OutputPorts[i].Status = n.Config.OutputPorts[i].Status.WithMerging(m.merging())
+			changed = true
+		}
+	}
+	var pending map[int]nodeFrame
+	if !n.synchronous(now) && len(n.pending) > 0 {
+		n.log.With(nil).Debug("ArtSync timed out, returning to immediate output")
+		n.syncLast = time.Time{}
+		pending = n.takePending()
+	}
+	n.outputLock.Unlock()
+	n.configLock.Unlock()
 
-		n.pollReplyCh <- *pollReply
+	if changed {
+		n.transport.change()
+	}
+	for port, frame := range pending {
+		n.output(port, frame.address, frame.dmx)
 	}
 }
 
+// MergeState returns the merge state of the output port with the given index
+func (n *Node) MergeState(port int) (MergeState, error) {
+	n.configLock.Lock()
+	defer n.configLock.Unlock()
+	n.outputLock.Lock()
+	defer n.outputLock.Unlock()
+
+	if port < 0 || port >= len(n.outputs) {
+		return MergeState{}, fmt.Errorf("unknown output port %d", port)
+	}
+	return n.outputs[port].state(n.Config.OutputPorts[port].Address), nil
+}
+
+// SetMergeMode sets the merge mode of the output port with the given index
+func (n *Node) SetMergeMode(port int, mode MergeMode) error {
+	n.configLock.Lock()
+	n.outputLock.Lock()
+
+	if port < 0 || port >= len(n.outputs) {
+		n.outputLock.Unlock()
+		n.configLock.Unlock()
+		return fmt.Errorf("unknown output port %d", port)
+	}
+	n.outputs[port].setMode(mode)
+	n.Config.OutputPorts[port].Status = n.Config.OutputPorts[port].Status.WithLTP(mode == MergeLTP)
+
+	n.outputLock.Unlock()
+	n.configLock.Unlock()
+
+	n.transport.change()
+	return nil
+}
+
 // RegisterCallback stores the given callback which will be called when a
 // packet with the given opcode arrives. This registration function can
 // only register callbacks before the node has been started. Calling this
-// function multiple times replaces every previous callback.
+// function multiple times replaces every previous callback. A callback
+// replaces the handling built into the node for the given opcode. Callbacks
+// are called in the order packets arrive, and must return quickly.
 func (n *Node) RegisterCallback(opcode code.OpCode, callback NodeCallbackFn) {
 	if !n.isShutdown() {
 		n.log.With(Fields{"opcode": opcode}).Debugf("ignoring callback registration: node has already been started")
@@ -0,0 +1,280 @@
+package artnet
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jsimonetti/go-artnet/packet"
+	"github.com/jsimonetti/go-artnet/packet/code"
+)
+
+// marshal returns the wire format of p
+func marshal(t *testing.T, p packet.ArtNetPacket) []byte {
+	b, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return b
+}
+
+// dmxPacket returns an ArtDmx for universe 0:0.1 with the first channel set to value
+func dmxPacket(t *testing.T, value byte) []byte {
+	p := &packet.ArtDMXPacket{SubUni: 1}
+	p.Data[0] = value
+	return marshal(t, p)
+}
+
+// outputNode returns a node with a single output port for universe 0:0.1
+func outputNode(opts ...NodeOption) *Node {
+	opts = append([]NodeOption{NodeListenAddress(net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})}, opts...)
+	n := NewNode("node", code.StNode, net.IP{2, 0, 0, 10}, NewDefaultLogger(), opts...)
+	n.Config.OutputPorts = []OutputPort{
+		{Address: Address{SubUni: 1}, Type: new(code.PortType).WithType("DMX512").WithOutput(true)},
+	}
+	return n
+}
+
+func TestNodeReceiveOrder(t *testing.T) {
+	outputs := make(chan byte, 256)
+	n := outputNode(NodeOutput(func(port int, address Address, dmx [512]byte) {
+		outputs <- dmx[0]
+	}))
+	if err := n.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer n.Stop()
+
+	controller := net.UDPAddr{IP: net.IP{2, 0, 0, 1}, Port: packet.ArtNetPort}
+	receive := func(data []byte) {
+		n.recvCh <- netPayload{address: controller, data: data}
+	}
+
+	// the first ArtSync switches the node to synchronous output, after that every
+	// frame must be output by the ArtSync following it
+	receive(dmxPacket(t, 0))
+	receive(marshal(t, &packet.ArtSyncPacket{}))
+	for i := 1; i <= 100; i++ {
+		receive(dmxPacket(t, byte(i)))
+		receive(marshal(t, &packet.ArtSyncPacket{}))
+	}
+
+	for i := 0; i <= 100; i++ {
+		select {
+		case got := <-outputs:
+			if want := byte(i); want != got {
+				t.Fatalf("unexpected output:\n- want: %d\n-  got: %d", want, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for output %d", i)
+		}
+	}
+}
+
+func TestNodeSync(t *testing.T) {
+	controller := net.UDPAddr{IP: net.IP{2, 0, 0, 1}, Port: packet.ArtNetPort}
+	other := net.UDPAddr{IP: net.IP{2, 0, 0, 2}, Port: packet.ArtNetPort}
+	sync := marshal(t, &packet.ArtSyncPacket{})
+	dmx := func(value byte) []byte { return dmxPacket(t, value) }
+
+	type receive struct {
+		src  net.UDPAddr
+		data []byte
+	}
+
+	tests := []struct {
+		name string
+		// expired moves the last ArtSync back beyond the timeout before receiving
+		expired bool
+		// received after the node has been switched to synchronous output by controller
+		receive []receive
+		want    byte
+	}{
+		{
+			name:    "HeldUntilSync",
+			receive: []receive{{controller, dmx(20)}},
+			want:    10,
+		},
+		{
+			name:    "OutputOnSync",
+			receive: []receive{{controller, dmx(20)}, {controller, sync}},
+			want:    20,
+		},
+		{
+			name:    "SyncFromOtherSource",
+			receive: []receive{{controller, dmx(20)}, {other, sync}},
+			want:    10,
+		},
+		{
+			name:    "Timeout",
+			expired: true,
+			receive: []receive{{controller, dmx(20)}},
+			want:    20,
+		},
+		{
+			name:    "MergingBypassesSync",
+			receive: []receive{{other, dmx(5)}, {controller, dmx(30)}},
+			want:    30,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got byte
+			n := outputNode(NodeOutput(func(port int, address Address, dmx [512]byte) {
+				got = dmx[0]
+			}))
+			if err := n.Start(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer n.Stop()
+
+			handle := func(src net.UDPAddr, data []byte) {
+				p, err := packet.Unmarshal(data)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				n.handlePacket(p, src)
+			}
+
+			handle(controller, dmx(10))
+			handle(controller, sync)
+			if tt.expired {
+				n.outputLock.Lock()
+				n.syncLast = n.syncLast.Add(-syncTimeout)
+				n.outputLock.Unlock()
+			}
+			for _, r := range tt.receive {
+				handle(r.src, r.data)
+			}
+
+			if tt.want != got {
+				t.Fatalf("unexpected output:\n- want: %d\n-  got: %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNodePollReply(t *testing.T) {
+	controller := net.UDPAddr{IP: net.IP{2, 0, 0, 1}, Port: packet.ArtNetPort}
+	n := outputNode()
+	if err := n.init(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// sent returns the packets queued for sending
+	sent := func() []netPayload {
+		var sent []netPayload
+		for {
+			select {
+			case s := <-n.sendCh:
+				sent = append(sent, s)
+			default:
+				return sent
+			}
+		}
+	}
+	poll := func(p *packet.ArtPollPacket) {
+		p2, err := packet.Unmarshal(marshal(t, p))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		n.handlePacket(p2, controller)
+		<-n.pollCh
+	}
+
+	// the node announces itself on power up
+	last := n.sendPollReply()
+	replies := sent()
+	if len(replies) != 1 {
+		t.Fatalf("expected a single ArtPollReply on power up, got %v", replies)
+	}
+	if p, err := packet.Unmarshal(replies[0].data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, ok := p.(*packet.ArtPollReplyPacket); !ok {
+		t.Fatalf("expected ArtPollReply on power up, got %v", p)
+	}
+
+	// expect checks the number of ArtPollReplies broadcast after a change of the merge mode
+	expect := func(name string, mode MergeMode, want int) {
+		t.Helper()
+		if err := n.SetMergeMode(0, mode); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		last = n.sendChangedPollReply(last)
+		replies := sent()
+		if got := len(replies); want != got {
+			t.Fatalf("%s: unexpected replies:\n- want: %d\n-  got: %d", name, want, got)
+		}
+		for _, s := range replies {
+			if !s.address.IP.Equal(defaultBroadcastAddr.IP) {
+				t.Fatalf("%s: unexpected destination %v", name, s.address.IP)
+			}
+		}
+	}
+
+	// changes are only announced to controllers that asked for them
+	expect("NoSubscriber", MergeLTP, 0)
+	poll(&packet.ArtPollPacket{TalkToMe: new(code.TalkToMe).WithReplyOnChange(true)})
+	// back to the state of the last reply
+	expect("Unchanged", MergeHTP, 0)
+	expect("Changed", MergeLTP, 1)
+
+	// a controller that stops polling is forgotten after 7 seconds
+	n.pollLock.Lock()
+	for ip, seen := range n.replyOnChange {
+		n.replyOnChange[ip] = seen.Add(-subscriberTimeout)
+	}
+	n.pollLock.Unlock()
+	expect("Expired", MergeHTP, 0)
+
+	// an ArtPoll without reply on change unsubscribes the controller
+	poll(&packet.ArtPollPacket{TalkToMe: new(code.TalkToMe).WithReplyOnChange(true)})
+	poll(&packet.ArtPollPacket{})
+	expect("Unsubscribed", MergeHTP, 0)
+}
+
+func TestNodePollReplyDelay(t *testing.T) {
+	defer func(delay time.Duration) { pollReplyDelay = delay }(pollReplyDelay)
+	pollReplyDelay = 100 * time.Millisecond
+	// margin allows for the scheduling of the poll reply loop
+	margin := 100 * time.Millisecond
+
+	n := outputNode()
+	if err := n.init(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	go n.pollReplyLoop()
+	defer n.Stop()
+
+	// receive waits for a packet to be sent and returns the time it took
+	receive := func() time.Duration {
+		start := time.Now()
+		select {
+		case <-n.sendCh:
+			return time.Since(start)
+		case <-time.After(pollReplyDelay + margin):
+			t.Fatal("timeout waiting for ArtPollReply")
+		}
+		return 0
+	}
+
+	// announce ourselves on power up
+	if d := receive(); d > margin {
+		t.Fatalf("power up reply delayed by %v", d)
+	}
+
+	for i := 0; i < 10; i++ {
+		// polls arriving during the delay are answered by the same reply
+		n.pollCh <- packet.ArtPollPacket{}
+		n.pollCh <- packet.ArtPollPacket{}
+		if d := receive(); d > pollReplyDelay+margin {
+			t.Fatalf("reply delayed by %v, more than %v", d, pollReplyDelay)
+		}
+		select {
+		case <-n.sendCh:
+			t.Fatal("expected a single reply to both polls")
+		case <-time.After(pollReplyDelay):
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package artnet
 
 import (
+	"fmt"
 	"net"
+	"time"
 
 	"github.com/jsimonetti/go-artnet/packet"
 )
@@ -30,6 +32,54 @@ func BroadcastAddr(addr net.UDPAddr) Option {
 	}
 }
 
+// SyncOutput enables synchronous output. All updated universes of a frame are sent first,
+// followed by a single broadcast ArtSync to have the nodes output them at the same time.
+func SyncOutput(enable bool) Option {
+	return func(c *Controller) error {
+		c.syncOutput = enable
+		return nil
+	}
+}
+
+// MatchMAC also compares the MAC address of nodes announcing the same IP. A node
+// with a different MAC address replaces the node previously known at that IP.
+func MatchMAC(enable bool) Option {
+	return func(c *Controller) error {
+		c.matchMAC = enable
+		return nil
+	}
+}
+
+// BroadcastThreshold sets the number of subscribed nodes above which a universe is
+// broadcast instead of sent unicast to each node; defaults to 40. Zero or less
+// always sends unicast.
+func BroadcastThreshold(nodes int) Option {
+	return func(c *Controller) error {
+		c.broadcastThreshold = nodes
+		return nil
+	}
+}
+
+// BroadcastUnknown broadcasts universes that no known node has subscribed to, instead
+// of not sending them at all
+func BroadcastUnknown(enable bool) Option {
+	return func(c *Controller) error {
+		c.broadcastUnknown = enable
+		return nil
+	}
+}
+
+// KeepAlive sets the interval at which unchanged data is sent again; defaults to 900ms.
+// The specification requires an interval between 800ms and 1000ms.
+func KeepAlive(interval time.Duration) Option {
+	return func(c *Controller) error {
+		if interval < 800*time.Millisecond || interval > time.Second {
+			return fmt.Errorf("keep-alive interval %v out of range 800ms-1s", interval)
+		}
+		c.keepAlive = interval
+		return nil
+	}
+}
 
 // ListenAddr sets the listen address and port to use; defaults to :6454 if unset
 func ListenAddress(addr net.UDPAddr) Option {
@@ -76,3 +126,11 @@ func NodeListenIP(ip net.IP) NodeOption {
 		return nil
 	}
 }
+
+// NodeOutput sets the function that is called with the DMX data to output on an output port
+func NodeOutput(fn NodeOutputFn) NodeOption {
+	return func(n *Node) error {
+		n.outputFn = fn
+		return nil
+	}
+}
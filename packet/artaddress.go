@@ -68,7 +68,7 @@ type ArtAddressPacket struct {
 
 	// Command contains Node configuration commands. Note that Ltp / Htp settings should be
 	// retained by the node during power cycling
-	Command uint8
+	Command code.AddressCommand
 }
 
 // NewArtAddressPacket returns an ArtNetPacket with the correct OpCode
@@ -1,3 +1,79 @@
 package packet
 
+import (
+	"github.com/jsimonetti/go-artnet/packet/code"
+)
+
+var _ ArtNetPacket = &ArtInputPacket{}
+
 // ArtInputPacket contains an ArtInput Packet.
+//
+// A Controller or monitoring device on the network can enable or disable individual DMX512
+// inputs on any of the network nodes. This allows the Controller to directly control network
+// traffic and ensures that unused inputs are disabled and therefore not wasting bandwidth.
+// All nodes power on with all inputs enabled.
+// Caution should be exercised when implementing this function in the controller. Keep in
+// mind that some network traffic may be operating on a node to node basis.
+//
+// Packet Strategy:
+//  Controller -  Receive:            Application Specific
+//                Unicast Transmit:   Controller transmits to a specific node IP address
+//                Broadcast Transmit: Not Allowed
+//  Node -        Receive:            Reply by broadcasting ArtPollReply
+//                Unicast Transmit:   Not Allowed
+//                Broadcast Transmit: Not Allowed
+//  MediaServer - Receive:            Reply by broadcasting ArtPollReply
+//                Unicast Transmit:   Not Allowed
+//                Broadcast Transmit: Not Allowed
+type ArtInputPacket struct {
+	// Inherit the Header header
+	Header
+
+	// Filler byte. Transmit as zero
+	_ uint8
+
+	// BindIndex defines the bound node which originated this packet and is used to uniquely identify
+	// the bound node when identical IP addresses are in use. This number represents the order of bound
+	// devices. A lower number means closer to root device. A value of 1 means root device.
+	BindIndex uint8
+
+	// NumPorts describes the number of input or output ports. If number of inputs is not
+	// equal to number of outputs, the largest value is taken. The maximum value is 4.
+	NumPorts uint16
+
+	// Input contains a byte for each of the 4 possible input ports. Bit 0 is set to
+	// disable the input, all other bits are unused.
+	Input [4]uint8
+}
+
+// NewArtInputPacket returns an ArtNetPacket with the correct OpCode
+func NewArtInputPacket() *ArtInputPacket {
+	return &ArtInputPacket{}
+}
+
+// MarshalBinary marshals an ArtInputPacket into a byte slice.
+func (p *ArtInputPacket) MarshalBinary() ([]byte, error) {
+	return marshalPacket(p)
+}
+
+// UnmarshalBinary unmarshals the contents of a byte slice into an ArtInputPacket.
+func (p *ArtInputPacket) UnmarshalBinary(b []byte) error {
+	return unmarshalPacket(p, b)
+}
+
+// validate is used to validate the Packet.
+func (p *ArtInputPacket) validate() error {
+	if err := p.Header.validate(); err != nil {
+		return err
+	}
+	if p.OpCode != code.OpInput {
+		return errInvalidOpCode
+	}
+	return nil
+}
+
+// finish is used to finish the Packet for sending.
+func (p *ArtInputPacket) finish() {
+	p.OpCode = code.OpInput
+	p.Header.finish()
+}
@@ -0,0 +1,105 @@
+package packet
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/jsimonetti/go-artnet/packet/code"
+	"github.com/jsimonetti/go-artnet/version"
+)
+
+func TestArtInputPacketMarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		p    ArtInputPacket
+		b    []byte
+		err  error
+	}{
+		{
+			name: "Empty",
+			p:    ArtInputPacket{},
+			b: []byte{
+				0x41, 0x72, 0x74, 0x2d, 0x4e, 0x65, 0x74, 0x00,
+				0x00, 0x70, 0x00, 0x0e, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+			},
+		},
+		{
+			name: "DisablePort2",
+			p: ArtInputPacket{
+				BindIndex: 1,
+				NumPorts:  4,
+				Input:     [4]uint8{0x00, 0x01, 0x00, 0x00},
+			},
+			b: []byte{
+				0x41, 0x72, 0x74, 0x2d, 0x4e, 0x65, 0x74, 0x00,
+				0x00, 0x70, 0x00, 0x0e, 0x00, 0x01, 0x00, 0x04,
+				0x00, 0x01, 0x00, 0x00,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := tt.p.MarshalBinary()
+
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			if want, got := tt.b, b; !bytes.Equal(want, got) {
+				t.Fatalf("unexpected Message bytes:\n- want: [%# x]\n-  got: [%# x]", want, got)
+			}
+		})
+	}
+}
+
+func TestArtInputPacketUnmarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		p    ArtInputPacket
+		b    []byte
+		err  error
+	}{
+		{
+			name: "DisablePort2",
+			p: ArtInputPacket{
+				Header: Header{
+					ID:      ArtNet,
+					OpCode:  code.OpInput,
+					Version: version.Bytes(),
+				},
+				BindIndex: 1,
+				NumPorts:  4,
+				Input:     [4]uint8{0x00, 0x01, 0x00, 0x00},
+			},
+			b: []byte{
+				0x41, 0x72, 0x74, 0x2d, 0x4e, 0x65, 0x74, 0x00,
+				0x00, 0x70, 0x00, 0x0e, 0x00, 0x01, 0x00, 0x04,
+				0x00, 0x01, 0x00, 0x00,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a ArtInputPacket
+			err := a.UnmarshalBinary(tt.b)
+
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			if want, got := tt.p, a; !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected Message bytes:\n- want: [%#v]\n-  got: [%#v]", want, got)
+			}
+		})
+	}
+}
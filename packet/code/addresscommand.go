@@ -0,0 +1,134 @@
+package code
+
+import "fmt"
+
+// AddressCommand defines the Node configuration commands sent in an ArtAddress packet.
+// Commands that address a single port carry the port number (0-3) in the low nibble.
+type AddressCommand uint8
+
+const (
+	// AcNone No action.
+	AcNone AddressCommand = 0x00
+
+	// AcCancelMerge If Node is currently in merge mode, cancel merge mode upon receipt of
+	// next ArtDmx packet.
+	AcCancelMerge AddressCommand = 0x01
+
+	// AcLedNormal The front panel indicators of the Node operate normally.
+	AcLedNormal AddressCommand = 0x02
+
+	// AcLedMute The front panel indicators of the Node are disabled and switched off.
+	AcLedMute AddressCommand = 0x03
+
+	// AcLedLocate Rapid flashing of the Node’s front panel indicators. It is intended as
+	// an outlet locator for large installations.
+	AcLedLocate AddressCommand = 0x04
+
+	// AcResetRxFlags Resets the Node’s Sip, Text, Test and data error flags.
+	AcResetRxFlags AddressCommand = 0x05
+
+	// AcMergeLtp0 Set DMX Port 0 to Merge in LTP mode.
+	AcMergeLtp0 AddressCommand = 0x10
+
+	// AcMergeLtp1 Set DMX Port 1 to Merge in LTP mode.
+	AcMergeLtp1 AddressCommand = 0x11
+
+	// AcMergeLtp2 Set DMX Port 2 to Merge in LTP mode.
+	AcMergeLtp2 AddressCommand = 0x12
+
+	// AcMergeLtp3 Set DMX Port 3 to Merge in LTP mode.
+	AcMergeLtp3 AddressCommand = 0x13
+
+	// AcMergeHtp0 Set DMX Port 0 to Merge in HTP (default) mode.
+	AcMergeHtp0 AddressCommand = 0x50
+
+	// AcMergeHtp1 Set DMX Port 1 to Merge in HTP (default) mode.
+	AcMergeHtp1 AddressCommand = 0x51
+
+	// AcMergeHtp2 Set DMX Port 2 to Merge in HTP (default) mode.
+	AcMergeHtp2 AddressCommand = 0x52
+
+	// AcMergeHtp3 Set DMX Port 3 to Merge in HTP (default) mode.
+	AcMergeHtp3 AddressCommand = 0x53
+
+	// AcArtNetSel0 Set DMX Port 0 to output both DMX512 and RDM packets from the Art-Net protocol.
+	AcArtNetSel0 AddressCommand = 0x60
+
+	// AcArtNetSel1 Set DMX Port 1 to output both DMX512 and RDM packets from the Art-Net protocol.
+	AcArtNetSel1 AddressCommand = 0x61
+
+	// AcArtNetSel2 Set DMX Port 2 to output both DMX512 and RDM packets from the Art-Net protocol.
+	AcArtNetSel2 AddressCommand = 0x62
+
+	// AcArtNetSel3 Set DMX Port 3 to output both DMX512 and RDM packets from the Art-Net protocol.
+	AcArtNetSel3 AddressCommand = 0x63
+
+	// AcAcnSel0 Set DMX Port 0 to output DMX512 data from the sACN protocol and RDM data from
+	// the Art-Net protocol.
+	AcAcnSel0 AddressCommand = 0x70
+
+	// AcAcnSel1 Set DMX Port 1 to output DMX512 data from the sACN protocol and RDM data from
+	// the Art-Net protocol.
+	AcAcnSel1 AddressCommand = 0x71
+
+	// AcAcnSel2 Set DMX Port 2 to output DMX512 data from the sACN protocol and RDM data from
+	// the Art-Net protocol.
+	AcAcnSel2 AddressCommand = 0x72
+
+	// AcAcnSel3 Set DMX Port 3 to output DMX512 data from the sACN protocol and RDM data from
+	// the Art-Net protocol.
+	AcAcnSel3 AddressCommand = 0x73
+
+	// AcClearOp0 Clear DMX Output buffer for Port 0.
+	AcClearOp0 AddressCommand = 0x90
+
+	// AcClearOp1 Clear DMX Output buffer for Port 1.
+	AcClearOp1 AddressCommand = 0x91
+
+	// AcClearOp2 Clear DMX Output buffer for Port 2.
+	AcClearOp2 AddressCommand = 0x92
+
+	// AcClearOp3 Clear DMX Output buffer for Port 3.
+	AcClearOp3 AddressCommand = 0x93
+)
+
+var addressCommandName = map[AddressCommand]string{
+	AcNone:         "AcNone",
+	AcCancelMerge:  "AcCancelMerge",
+	AcLedNormal:    "AcLedNormal",
+	AcLedMute:      "AcLedMute",
+	AcLedLocate:    "AcLedLocate",
+	AcResetRxFlags: "AcResetRxFlags",
+	AcMergeLtp0:    "AcMergeLtp0",
+	AcMergeLtp1:    "AcMergeLtp1",
+	AcMergeLtp2:    "AcMergeLtp2",
+	AcMergeLtp3:    "AcMergeLtp3",
+	AcMergeHtp0:    "AcMergeHtp0",
+	AcMergeHtp1:    "AcMergeHtp1",
+	AcMergeHtp2:    "AcMergeHtp2",
+	AcMergeHtp3:    "AcMergeHtp3",
+	AcArtNetSel0:   "AcArtNetSel0",
+	AcArtNetSel1:   "AcArtNetSel1",
+	AcArtNetSel2:   "AcArtNetSel2",
+	AcArtNetSel3:   "AcArtNetSel3",
+	AcAcnSel0:      "AcAcnSel0",
+	AcAcnSel1:      "AcAcnSel1",
+	AcAcnSel2:      "AcAcnSel2",
+	AcAcnSel3:      "AcAcnSel3",
+	AcClearOp0:     "AcClearOp0",
+	AcClearOp1:     "AcClearOp1",
+	AcClearOp2:     "AcClearOp2",
+	AcClearOp3:     "AcClearOp3",
+}
+
+// Port returns the port number a per-port command applies to
+func (i AddressCommand) Port() int {
+	return int(i & 0x0f)
+}
+
+func (i AddressCommand) String() string {
+	if name, ok := addressCommandName[i]; ok {
+		return name
+	}
+	return fmt.Sprintf("AddressCommand(%d)", i)
+}
@@ -14,8 +14,6 @@ func Unmarshal(b []byte) (p ArtNetPacket, err error) {
 		return
 	}
 
-	notImplErr := fmt.Errorf("unimplemented opcode %#v found", h.OpCode)
-
 	switch h.OpCode {
 	case code.OpPoll:
 		p = &ArtPollPacket{}
@@ -35,7 +33,7 @@ func Unmarshal(b []byte) (p ArtNetPacket, err error) {
 	case code.OpAddress:
 		p = &ArtAddressPacket{}
 	case code.OpInput:
-		return nil, notImplErr
+		p = &ArtInputPacket{}
 	case code.OpTimeCode:
 		p = &ArtTimeCodePacket{}
 	case code.OpTrigger:
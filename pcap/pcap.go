@@ -0,0 +1,162 @@
+// Package pcap reads Art-Net traffic from pcap and pcapng capture files and
+// writes the traffic of a node to pcap files that open in Wireshark.
+//
+// Only UDP over IPv4 to or from port 6454 is read. Ethernet (with VLAN tags),
+// Linux cooked, BSD loopback and raw IP link types are supported. Fragmented IP
+// packets are skipped.
+package pcap
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/jsimonetti/go-artnet/packet"
+)
+
+// Port is the UDP port of Art-Net
+const Port = 6454
+
+// link types of the capture formats
+const (
+	linkNull     = 0
+	linkEthernet = 1
+	linkRaw      = 101
+	linkLinuxSLL = 113
+	linkIPv4     = 228
+)
+
+// Packet is an Art-Net packet read from a capture
+type Packet struct {
+	Time time.Time
+	Src  net.UDPAddr
+	Dst  net.UDPAddr
+
+	// Data holds the UDP payload
+	Data []byte
+
+	// Packet holds the unmarshalled payload, it is nil when Err is set
+	Packet packet.ArtNetPacket
+
+	// Err is set when the payload is not a valid Art-Net packet
+	Err error
+}
+
+var errSkip = errors.New("not an Art-Net packet")
+
+// decode decodes a captured frame of the given link type, it returns errSkip for
+// frames that do not hold Art-Net
+func decode(link uint32, ts time.Time, frame []byte, truncated bool) (*Packet, error) {
+	ip, err := network(link, frame)
+	if err != nil {
+		return nil, err
+	}
+
+	// IPv4 header
+	if len(ip) < 20 || ip[0]>>4 != 4 {
+		return nil, errSkip
+	}
+	ihl := int(ip[0]&0x0f) * 4
+	total := int(binary.BigEndian.Uint16(ip[2:4]))
+	if ihl < 20 || total < ihl || len(ip) < ihl {
+		return nil, errSkip
+	}
+	if ip[9] != 17 {
+		return nil, errSkip
+	}
+	if flags := binary.BigEndian.Uint16(ip[6:8]); flags&0x3fff != 0 {
+		// more fragments or a fragment offset
+		return nil, errSkip
+	}
+	if total < len(ip) {
+		// strip Ethernet padding
+		ip = ip[:total]
+	}
+	src, dst := net.IP(append([]byte(nil), ip[12:16]...)), net.IP(append([]byte(nil), ip[16:20]...))
+
+	// UDP header
+	udp := ip[ihl:]
+	if len(udp) < 8 {
+		return nil, errSkip
+	}
+	srcPort := int(binary.BigEndian.Uint16(udp[0:2]))
+	dstPort := int(binary.BigEndian.Uint16(udp[2:4]))
+	if srcPort != Port && dstPort != Port {
+		return nil, errSkip
+	}
+	length := int(binary.BigEndian.Uint16(udp[4:6]))
+	if length < 8 {
+		return nil, errSkip
+	}
+
+	p := &Packet{
+		Time: ts,
+		Src:  net.UDPAddr{IP: src, Port: srcPort},
+		Dst:  net.UDPAddr{IP: dst, Port: dstPort},
+	}
+	payload := udp[8:]
+	if len(payload) < length-8 || truncated {
+		p.Data = append([]byte(nil), payload...)
+		p.Err = fmt.Errorf("packet truncated to %d of %d bytes", len(payload), length-8)
+		return p, nil
+	}
+	p.Data = append([]byte(nil), payload[:length-8]...)
+	if p.Packet, p.Err = packet.Unmarshal(p.Data); p.Err != nil {
+		p.Packet = nil
+	}
+	return p, nil
+}
+
+// network returns the network layer of a frame of the given link type
+func network(link uint32, frame []byte) ([]byte, error) {
+	switch link {
+	case linkEthernet:
+		if len(frame) < 14 {
+			return nil, errSkip
+		}
+		etherType := binary.BigEndian.Uint16(frame[12:14])
+		frame = frame[14:]
+		for etherType == 0x8100 || etherType == 0x88a8 {
+			if len(frame) < 4 {
+				return nil, errSkip
+			}
+			etherType = binary.BigEndian.Uint16(frame[2:4])
+			frame = frame[4:]
+		}
+		if etherType != 0x0800 {
+			return nil, errSkip
+		}
+		return frame, nil
+
+	case linkLinuxSLL:
+		if len(frame) < 16 || binary.BigEndian.Uint16(frame[14:16]) != 0x0800 {
+			return nil, errSkip
+		}
+		return frame[16:], nil
+
+	case linkNull:
+		// the address family is in the byte order of the capturing host
+		if len(frame) < 4 {
+			return nil, errSkip
+		}
+		if family := binary.LittleEndian.Uint32(frame); family != 2 && family != 2<<24 {
+			return nil, errSkip
+		}
+		return frame[4:], nil
+
+	case linkRaw, linkIPv4:
+		return frame, nil
+	}
+	return nil, errSkip
+}
+
+// supported returns whether frames of the link type can be decoded
+func supported(link uint32) bool {
+	switch link {
+	case linkNull, linkEthernet, linkRaw, linkLinuxSLL, linkIPv4:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+	"github.com/jsimonetti/go-artnet/packet"
+	"github.com/jsimonetti/go-artnet/packet/code"
+)
+
+func TestWriteRead(t *testing.T) {
+	ts := time.Unix(1760000000, 123456000)
+	node := net.UDPAddr{IP: net.IP{2, 0, 0, 1}, Port: Port}
+	broadcast := net.UDPAddr{IP: net.IP{2, 255, 255, 255}, Port: Port}
+
+	dmx := &packet.ArtDMXPacket{Sequence: 7, SubUni: 3}
+	dmx.Data[0] = 0xff
+	dmxData, err := dmx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pollData, err := packet.NewArtPollPacket().MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	writes := []struct {
+		src, dst net.UDPAddr
+		data     []byte
+	}{
+		{src: node, dst: broadcast, data: pollData},
+		{src: net.UDPAddr{IP: node.IP, Port: 1234}, dst: net.UDPAddr{IP: node.IP, Port: 1234}, data: []byte("other")},
+		{src: node, dst: net.UDPAddr{IP: net.IP{2, 0, 0, 2}}, data: dmxData},
+		{src: node, dst: broadcast, data: []byte("Art-Net\x00")},
+	}
+	for i, p := range writes {
+		if err := w.WritePacket(ts.Add(time.Duration(i)*time.Second), p.src, p.dst, p.data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// the IPv4 header checksum of the first frame must verify
+	if got := checksum(buf.Bytes()[24+16+14 : 24+16+14+20]); got != 0 {
+		t.Fatalf("unexpected IPv4 header checksum %#x", got)
+	}
+
+	packets, err := ReadAll(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := 3, len(packets); want != got {
+		t.Fatalf("unexpected packets:\n- want: %d\n-  got: %d", want, got)
+	}
+
+	if _, ok := packets[0].Packet.(*packet.ArtPollPacket); !ok {
+		t.Fatalf("unexpected packet %T", packets[0].Packet)
+	}
+	if want, got := broadcast.String(), packets[0].Dst.String(); want != got {
+		t.Fatalf("unexpected destination:\n- want: %s\n-  got: %s", want, got)
+	}
+	if want, got := ts, packets[0].Time; !want.Equal(got) {
+		t.Fatalf("unexpected time:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	p, ok := packets[1].Packet.(*packet.ArtDMXPacket)
+	if !ok || p.Sequence != 7 || p.SubUni != 3 || p.Data[0] != 0xff {
+		t.Fatalf("unexpected packet %#v", packets[1].Packet)
+	}
+	if want, got := "2.0.0.2:6454", packets[1].Dst.String(); want != got {
+		t.Fatalf("unexpected destination:\n- want: %s\n-  got: %s", want, got)
+	}
+
+	if packets[2].Err == nil || packets[2].Packet != nil {
+		t.Fatal("expected error for invalid Art-Net packet")
+	}
+
+	if _, err := ReadAll(bytes.NewReader(buf.Bytes()[:buf.Len()-1])); err == nil {
+		t.Fatal("expected error for truncated capture")
+	}
+}
+
+func TestPcapng(t *testing.T) {
+	var pcap bytes.Buffer
+	w, _ := NewWriter(&pcap)
+	data, _ := packet.NewArtPollPacket().MarshalBinary()
+	w.WritePacket(time.Time{}, net.UDPAddr{IP: net.IP{10, 0, 0, 1}}, net.UDPAddr{IP: net.IP{10, 0, 0, 2}}, data)
+	frame := pcap.Bytes()[24+16:]
+
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	block := func(typ uint32, body []byte) {
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+		binary.Write(&buf, le, typ)
+		binary.Write(&buf, le, uint32(12+len(body)))
+		buf.Write(body)
+		binary.Write(&buf, le, uint32(12+len(body)))
+	}
+	u32 := func(v ...uint32) []byte {
+		b := make([]byte, 4*len(v))
+		for i := range v {
+			le.PutUint32(b[4*i:], v[i])
+		}
+		return b
+	}
+
+	// section header, unknown interface and Ethernet interface with nanoseconds
+	block(blockSection, append(u32(byteOrderMagic, 1), 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff))
+	block(blockInterface, u32(147, 0))
+	block(blockInterface, append(u32(linkEthernet, 0), 9, 0, 1, 0, 9, 0, 0, 0, 0, 0, 0, 0))
+	block(0xbad, u32(1, 2, 3))
+
+	// 1760000000.5 s in nanoseconds
+	ts := uint64(1760000000500000000)
+	block(blockEnhanced, append(u32(1, uint32(ts>>32), uint32(ts), uint32(len(frame)), uint32(len(frame))), frame...))
+	block(blockEnhanced, append(u32(0, 0, 0, 4, 4), 1, 2, 3, 4))
+
+	packets, err := ReadAll(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := 1, len(packets); want != got {
+		t.Fatalf("unexpected packets:\n- want: %d\n-  got: %d", want, got)
+	}
+	if want, got := time.Unix(1760000000, 500000000), packets[0].Time; !want.Equal(got) {
+		t.Fatalf("unexpected time:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := "10.0.0.1:6454", packets[0].Src.String(); want != got {
+		t.Fatalf("unexpected source:\n- want: %s\n-  got: %s", want, got)
+	}
+	if _, ok := packets[0].Packet.(*packet.ArtPollPacket); !ok {
+		t.Fatalf("unexpected packet %T", packets[0].Packet)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	controller := net.UDPAddr{IP: net.IP{2, 0, 0, 1}, Port: Port}
+	node := net.UDPAddr{IP: net.IP{2, 0, 0, 10}, Port: Port}
+	start := time.Unix(1760000000, 0)
+
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf)
+	for i, value := range []byte{10, 20} {
+		p := &packet.ArtDMXPacket{SubUni: 1}
+		p.Data[0] = value
+		data, _ := p.MarshalBinary()
+		w.WritePacket(start.Add(time.Duration(i)*time.Second), controller, node, data)
+	}
+	packets, err := ReadAll(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	n := artnet.NewNode("node", code.StNode, node.IP, artnet.NewDefaultLogger())
+	n.Config.OutputPorts = []artnet.OutputPort{
+		{Address: artnet.Address{SubUni: 1}, Type: new(code.PortType).WithType("DMX512").WithOutput(true)},
+	}
+	r, err := artnet.NewReplay(n, start)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := Replay(r, packets); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, data, _ := r.Output(0); data[0] != 20 {
+		t.Fatalf("unexpected output:\n- want: %d\n-  got: %d", 20, data[0])
+	}
+	if want, got := start.Add(time.Second), r.Now(); !want.Equal(got) {
+		t.Fatalf("unexpected time:\n- want: %v\n-  got: %v", want, got)
+	}
+}
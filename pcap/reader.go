@@ -0,0 +1,308 @@
+package pcap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+)
+
+const (
+	magicMicros = 0xa1b2c3d4
+	magicNanos  = 0xa1b23c4d
+
+	blockSection     = 0x0a0d0d0a
+	blockInterface   = 1
+	blockSimple      = 3
+	blockEnhanced    = 6
+	byteOrderMagic   = 0x1a2b3c4d
+	optionEnd        = 0
+	optionTSResol    = 9
+	maxCaptureLength = 256 * 1024
+)
+
+// iface is an interface described in a pcapng section
+type iface struct {
+	link uint32
+
+	// units is the number of timestamp units per second
+	units uint64
+}
+
+// Reader reads Art-Net packets from a pcap or pcapng capture
+type Reader struct {
+	r     *bufio.Reader
+	order binary.ByteOrder
+
+	// pcap
+	link  uint32
+	nanos bool
+
+	// pcapng
+	ng         bool
+	interfaces []iface
+}
+
+// NewReader returns a reader for a capture, the format is detected from the
+// header
+func NewReader(r io.Reader) (*Reader, error) {
+	rd := &Reader{r: bufio.NewReader(r)}
+	b, err := rd.r.Peek(4)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read capture header: %v", err)
+	}
+
+	if binary.BigEndian.Uint32(b) == blockSection {
+		rd.ng = true
+		return rd, nil
+	}
+
+	header := make([]byte, 24)
+	if _, err := io.ReadFull(rd.r, header); err != nil {
+		return nil, fmt.Errorf("failed to read capture header: %v", err)
+	}
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		switch order.Uint32(header) {
+		case magicMicros:
+			rd.order = order
+		case magicNanos:
+			rd.order, rd.nanos = order, true
+		}
+	}
+	if rd.order == nil {
+		return nil, errors.New("not a pcap or pcapng capture")
+	}
+	rd.link = rd.order.Uint32(header[20:24])
+	if !supported(rd.link) {
+		return nil, fmt.Errorf("unsupported link type %d", rd.link)
+	}
+	return rd, nil
+}
+
+// Next returns the next Art-Net packet, or io.EOF at the end of the capture.
+// Other traffic in the capture is skipped.
+func (r *Reader) Next() (*Packet, error) {
+	for {
+		var p *Packet
+		var err error
+		if r.ng {
+			p, err = r.nextBlock()
+		} else {
+			p, err = r.nextRecord()
+		}
+		if err == errSkip {
+			continue
+		}
+		return p, err
+	}
+}
+
+// ReadAll reads all Art-Net packets of a capture
+func ReadAll(r io.Reader) ([]*Packet, error) {
+	rd, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var packets []*Packet
+	for {
+		p, err := rd.Next()
+		if err == io.EOF {
+			return packets, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		packets = append(packets, p)
+	}
+}
+
+// nextRecord reads the next record of a pcap capture
+func (r *Reader) nextRecord() (*Packet, error) {
+	header := make([]byte, 16)
+	if err := r.read(header, true); err != nil {
+		return nil, err
+	}
+	length, orig := r.order.Uint32(header[8:12]), r.order.Uint32(header[12:16])
+	if length > maxCaptureLength {
+		return nil, fmt.Errorf("corrupt capture: record of %d bytes", length)
+	}
+	frame := make([]byte, length)
+	if err := r.read(frame, false); err != nil {
+		return nil, err
+	}
+
+	frac := time.Duration(r.order.Uint32(header[4:8]))
+	if !r.nanos {
+		frac *= time.Microsecond
+	}
+	ts := time.Unix(int64(r.order.Uint32(header[0:4])), int64(frac))
+	return decode(r.link, ts, frame, length < orig)
+}
+
+// nextBlock reads the next block of a pcapng capture
+func (r *Reader) nextBlock() (*Packet, error) {
+	header := make([]byte, 8)
+	if err := r.read(header, true); err != nil {
+		return nil, err
+	}
+
+	if binary.BigEndian.Uint32(header) == blockSection {
+		bom := make([]byte, 4)
+		if err := r.read(bom, false); err != nil {
+			return nil, err
+		}
+		switch {
+		case binary.LittleEndian.Uint32(bom) == byteOrderMagic:
+			r.order = binary.LittleEndian
+		case binary.BigEndian.Uint32(bom) == byteOrderMagic:
+			r.order = binary.BigEndian
+		default:
+			return nil, errors.New("corrupt capture: invalid byte order magic")
+		}
+		r.interfaces = nil
+		if _, err := r.block(r.order.Uint32(header[4:8]), 12); err != nil {
+			return nil, err
+		}
+		return nil, errSkip
+	}
+	if r.order == nil {
+		return nil, errors.New("corrupt capture: block before section header")
+	}
+
+	body, err := r.block(r.order.Uint32(header[4:8]), 8)
+	if err != nil {
+		return nil, err
+	}
+	switch r.order.Uint32(header[0:4]) {
+	case blockInterface:
+		if err := r.addInterface(body); err != nil {
+			return nil, err
+		}
+		return nil, errSkip
+
+	case blockEnhanced:
+		if len(body) < 20 {
+			return nil, errors.New("corrupt capture: short enhanced packet block")
+		}
+		id := r.order.Uint32(body[0:4])
+		if int(id) >= len(r.interfaces) {
+			return nil, fmt.Errorf("corrupt capture: packet on unknown interface %d", id)
+		}
+		i := r.interfaces[id]
+		length, orig := r.order.Uint32(body[12:16]), r.order.Uint32(body[16:20])
+		if int(length) > len(body)-20 {
+			return nil, errors.New("corrupt capture: packet exceeds block")
+		}
+		ts := uint64(r.order.Uint32(body[4:8]))<<32 | uint64(r.order.Uint32(body[8:12]))
+		return decode(i.link, timestamp(ts, i.units), body[20:20+length], length < orig)
+
+	case blockSimple:
+		if len(body) < 4 || len(r.interfaces) == 0 {
+			return nil, errors.New("corrupt capture: invalid simple packet block")
+		}
+		orig := r.order.Uint32(body[0:4])
+		frame := body[4:]
+		if uint32(len(frame)) > orig {
+			frame = frame[:orig]
+		}
+		// simple packet blocks have no timestamp
+		return decode(r.interfaces[0].link, time.Time{}, frame, uint32(len(frame)) < orig)
+	}
+	return nil, errSkip
+}
+
+// block reads the rest of a block of length bytes of which read have been read,
+// it returns the body without the trailing length
+func (r *Reader) block(length uint32, read int) ([]byte, error) {
+	if length < uint32(read)+4 || length%4 != 0 || length > maxCaptureLength {
+		return nil, fmt.Errorf("corrupt capture: block of %d bytes", length)
+	}
+	body := make([]byte, int(length)-read)
+	if err := r.read(body, false); err != nil {
+		return nil, err
+	}
+	if r.order.Uint32(body[len(body)-4:]) != length {
+		return nil, errors.New("corrupt capture: block length mismatch")
+	}
+	return body[:len(body)-4], nil
+}
+
+// addInterface adds the interface described by an interface description block
+func (r *Reader) addInterface(body []byte) error {
+	if len(body) < 8 {
+		return errors.New("corrupt capture: short interface description block")
+	}
+	i := iface{
+		link:  uint32(r.order.Uint16(body[0:2])),
+		units: 1000000,
+	}
+
+	options := body[8:]
+	for len(options) >= 4 {
+		code, length := r.order.Uint16(options[0:2]), int(r.order.Uint16(options[2:4]))
+		padded := (length + 3) &^ 3
+		if code == optionEnd || len(options) < 4+padded {
+			break
+		}
+		if code == optionTSResol && length >= 1 {
+			v := options[4]
+			switch {
+			case v&0x80 != 0 && v&0x7f < 64:
+				i.units = 1 << (v & 0x7f)
+			case v&0x80 == 0 && v < 20:
+				i.units = 1
+				for ; v > 0; v-- {
+					i.units *= 10
+				}
+			default:
+				return fmt.Errorf("corrupt capture: invalid timestamp resolution %#x", options[4])
+			}
+		}
+		options = options[4+padded:]
+	}
+
+	r.interfaces = append(r.interfaces, i)
+	return nil
+}
+
+// timestamp converts a pcapng timestamp in units per second to a time
+func timestamp(ts, units uint64) time.Time {
+	hi, lo := bits.Mul64(ts%units, uint64(time.Second))
+	nanos, _ := bits.Div64(hi, lo, units)
+	return time.Unix(int64(ts/units), int64(nanos))
+}
+
+// read fills b, an io.EOF before the first byte is returned as is when atStart
+func (r *Reader) read(b []byte, atStart bool) error {
+	_, err := io.ReadFull(r.r, b)
+	if err == io.EOF && atStart {
+		return io.EOF
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return fmt.Errorf("corrupt capture: %v", err)
+	}
+	return nil
+}
+
+// Replay feeds the Art-Net packets of a capture into a replay in order, packets
+// that are not valid Art-Net are skipped
+func Replay(r *artnet.Replay, packets []*Packet) error {
+	for _, p := range packets {
+		if p.Err != nil {
+			continue
+		}
+		if err := r.Receive(p.Time, p.Src, p.Dst, p.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+)
+
+// snapLength is the maximum packet length written to the capture header
+const snapLength = 65535
+
+// Writer writes UDP packets to a pcap capture. Packets are written as Ethernet
+// frames with MAC addresses derived from the IP addresses, so the captures open in
+// Wireshark and other tools.
+type Writer struct {
+	w  io.Writer
+	id uint16
+}
+
+// NewWriter writes the header of a pcap capture with microsecond timestamps
+func NewWriter(w io.Writer) (*Writer, error) {
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header[0:4], magicMicros)
+	binary.LittleEndian.PutUint16(header[4:6], 2)
+	binary.LittleEndian.PutUint16(header[6:8], 4)
+	binary.LittleEndian.PutUint32(header[16:20], snapLength)
+	binary.LittleEndian.PutUint32(header[20:24], linkEthernet)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w}, nil
+}
+
+// WritePacket writes a UDP packet with payload data. Addresses that are not IPv4
+// are written as 0.0.0.0, a port of 0 as the Art-Net port.
+func (w *Writer) WritePacket(ts time.Time, src, dst net.UDPAddr, data []byte) error {
+	if len(data) > snapLength-14-20-8 {
+		return fmt.Errorf("packet of %d bytes too large", len(data))
+	}
+	srcIP, dstIP := ipv4(src.IP), ipv4(dst.IP)
+	length := 14 + 20 + 8 + len(data)
+
+	b := make([]byte, 16+length)
+	binary.LittleEndian.PutUint32(b[0:4], uint32(ts.Unix()))
+	binary.LittleEndian.PutUint32(b[4:8], uint32(ts.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(b[8:12], uint32(length))
+	binary.LittleEndian.PutUint32(b[12:16], uint32(length))
+
+	// Ethernet
+	frame := b[16:]
+	copy(frame[0:6], mac(dstIP))
+	copy(frame[6:12], mac(srcIP))
+	binary.BigEndian.PutUint16(frame[12:14], 0x0800)
+
+	// IPv4 without options, the checksum is calculated over the header
+	ip := frame[14:]
+	ip[0] = 0x45
+	binary.BigEndian.PutUint16(ip[2:4], uint16(20+8+len(data)))
+	w.id++
+	binary.BigEndian.PutUint16(ip[4:6], w.id)
+	binary.BigEndian.PutUint16(ip[6:8], 0x4000) // don't fragment
+	ip[8] = 64
+	ip[9] = 17
+	copy(ip[12:16], srcIP)
+	copy(ip[16:20], dstIP)
+	binary.BigEndian.PutUint16(ip[10:12], checksum(ip[:20]))
+
+	// UDP, the checksum is optional over IPv4
+	udp := ip[20:]
+	binary.BigEndian.PutUint16(udp[0:2], port(src.Port))
+	binary.BigEndian.PutUint16(udp[2:4], port(dst.Port))
+	binary.BigEndian.PutUint16(udp[4:6], uint16(8+len(data)))
+	copy(udp[8:], data)
+
+	_, err := w.w.Write(b)
+	return err
+}
+
+// Capture writes all packets src sends or receives to w as a pcap capture until
+// stop is called. Stop returns the first error that occurred while writing.
+func Capture(src artnet.Tapper, w io.Writer) (stop func() error, err error) {
+	pw, err := NewWriter(w)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock sync.Mutex
+	var werr error
+	remove := src.AddTap(func(ts time.Time, src, dst net.UDPAddr, data []byte) {
+		lock.Lock()
+		defer lock.Unlock()
+		if werr == nil {
+			werr = pw.WritePacket(ts, src, dst, data)
+		}
+	})
+
+	return func() error {
+		remove()
+		lock.Lock()
+		defer lock.Unlock()
+		return werr
+	}, nil
+}
+
+// ipv4 returns the 4 byte form of ip, or 0.0.0.0
+func ipv4(ip net.IP) net.IP {
+	if v4 := ip.To4(); v4 != nil {
+		return v4
+	}
+	return net.IPv4zero.To4()
+}
+
+// mac returns a locally administered MAC address for ip, or the broadcast address
+// when ip looks like a broadcast address
+func mac(ip net.IP) net.HardwareAddr {
+	if ip[3] == 0xff {
+		return net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	}
+	return net.HardwareAddr{0x02, 0x00, ip[0], ip[1], ip[2], ip[3]}
+}
+
+// port returns p, or the Art-Net port when it is 0
+func port(p int) uint16 {
+	if p == 0 {
+		return Port
+	}
+	return uint16(p)
+}
+
+// checksum returns the internet checksum of b
+func checksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(b[i:]))
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum > 0xffff {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
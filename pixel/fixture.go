@@ -0,0 +1,144 @@
+package pixel
+
+import "github.com/jsimonetti/go-artnet"
+
+// Point is a position on the canvas of a Mapper, 0,0 is the top left corner
+type Point struct {
+	X, Y float64
+}
+
+// Fixture is a set of pixels in the order they are wired
+type Fixture struct {
+	Name   string
+	Format Format
+	Points []Point
+
+	// Universe and Channel hold the address of the first pixel once the fixture
+	// has been added to a Mapper
+	Universe artnet.Address
+	Channel  int
+}
+
+// Points returns a fixture of pixels at arbitrary points
+func Points(name string, format Format, points []Point) *Fixture {
+	return &Fixture{
+		Name:   name,
+		Format: format,
+		Points: append([]Point(nil), points...),
+	}
+}
+
+// Strip returns a fixture of pixels evenly spaced on the line from the first to
+// the last pixel
+func Strip(name string, format Format, pixels int, from, to Point) *Fixture {
+	f := &Fixture{
+		Name:   name,
+		Format: format,
+		Points: make([]Point, pixels),
+	}
+	for i := range f.Points {
+		t := 0.0
+		if pixels > 1 {
+			t = float64(i) / float64(pixels-1)
+		}
+		f.Points[i] = Point{
+			X: from.X + t*(to.X-from.X),
+			Y: from.Y + t*(to.Y-from.Y),
+		}
+	}
+	return f
+}
+
+// Corner is the corner of a matrix the first pixel is wired at
+type Corner int
+
+// corners of a matrix
+const (
+	TopLeft Corner = iota
+	TopRight
+	BottomLeft
+	BottomRight
+)
+
+// matrix holds the wiring of a matrix
+type matrix struct {
+	origin     Point
+	start      Corner
+	columns    bool
+	serpentine bool
+}
+
+// MatrixOption is a functional option for Matrix
+type MatrixOption func(*matrix)
+
+// Origin places the top left pixel of the matrix at x,y; defaults to 0,0
+func Origin(x, y float64) MatrixOption {
+	return func(m *matrix) {
+		m.origin = Point{X: x, Y: y}
+	}
+}
+
+// Start sets the corner the first pixel is wired at; defaults to TopLeft
+func Start(corner Corner) MatrixOption {
+	return func(m *matrix) {
+		m.start = corner
+	}
+}
+
+// Columns wires the matrix column by column instead of row by row
+func Columns() MatrixOption {
+	return func(m *matrix) {
+		m.columns = true
+	}
+}
+
+// Serpentine wires every other row, or column, in the opposite direction, zig-zag
+// instead of returning to the same side at the end of every row
+func Serpentine() MatrixOption {
+	return func(m *matrix) {
+		m.serpentine = true
+	}
+}
+
+// Matrix returns a fixture of width by height pixels, one canvas unit apart. Pixels
+// are placed at the center of their unit, the top left pixel at 0.5,0.5 from the
+// origin.
+func Matrix(name string, format Format, width, height int, opts ...MatrixOption) *Fixture {
+	m := &matrix{}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	f := &Fixture{
+		Name:   name,
+		Format: format,
+		Points: make([]Point, 0, width*height),
+	}
+	lines, length := height, width
+	if m.columns {
+		lines, length = width, height
+	}
+	for line := 0; line < lines; line++ {
+		for i := 0; i < length; i++ {
+			pos := i
+			if m.serpentine && line%2 == 1 {
+				pos = length - 1 - i
+			}
+			col, row := pos, line
+			if m.columns {
+				col, row = line, pos
+			}
+			if m.start == TopRight || m.start == BottomRight {
+				col = width - 1 - col
+			}
+			if m.start == BottomLeft || m.start == BottomRight {
+				row = height - 1 - row
+			}
+			f.Points = append(f.Points, Point{
+				X: m.origin.X + float64(col) + 0.5,
+				Y: m.origin.Y + float64(row) + 0.5,
+			})
+		}
+	}
+	return f
+}
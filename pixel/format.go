@@ -0,0 +1,104 @@
+// Package pixel maps images onto LED fixtures driven over Art-Net.
+//
+// Fixtures are strips, matrices or arbitrary lists of points on a canvas, whose
+// pixels are patched onto consecutive channels of one or more universes. Every
+// frame, a Mapper samples an image scaled to the canvas at the position of each
+// pixel and writes the colors into the universes of a Controller. A GIFPlayer
+// plays animated GIFs through a Mapper.
+package pixel
+
+import (
+	"fmt"
+	"image/color"
+)
+
+// Format is the order and depth of the channels of a pixel
+type Format int
+
+// pixel formats, W formats take the white channel out of the RGB channels
+const (
+	RGB Format = iota
+	GRB
+	BGR
+	RGBW
+	GRBW
+	RGB16
+)
+
+var formats = [...]struct {
+	name  string
+	order string
+	wide  bool
+}{
+	RGB:   {name: "RGB", order: "rgb"},
+	GRB:   {name: "GRB", order: "grb"},
+	BGR:   {name: "BGR", order: "bgr"},
+	RGBW:  {name: "RGBW", order: "rgbw"},
+	GRBW:  {name: "GRBW", order: "grbw"},
+	RGB16: {name: "RGB16", order: "rgb", wide: true},
+}
+
+// String returns the name of the format
+func (f Format) String() string {
+	if !f.valid() {
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+	return formats[f].name
+}
+
+// Channels returns the number of channels of a pixel
+func (f Format) Channels() int {
+	if !f.valid() {
+		return 0
+	}
+	if formats[f].wide {
+		return 2 * len(formats[f].order)
+	}
+	return len(formats[f].order)
+}
+
+// valid indicates if f is a known format
+func (f Format) valid() bool {
+	return f >= 0 && int(f) < len(formats)
+}
+
+// encode writes the channels of color c to dst
+func (f Format) encode(c color.Color, dst []byte) {
+	r, g, b, _ := c.RGBA()
+	var w uint32
+	format := formats[f]
+	for _, ch := range format.order {
+		if ch == 'w' {
+			w = r
+			if g < w {
+				w = g
+			}
+			if b < w {
+				w = b
+			}
+			r, g, b = r-w, g-w, b-w
+			break
+		}
+	}
+
+	for _, ch := range format.order {
+		var v uint32
+		switch ch {
+		case 'r':
+			v = r
+		case 'g':
+			v = g
+		case 'b':
+			v = b
+		case 'w':
+			v = w
+		}
+		if format.wide {
+			dst[0], dst[1] = uint8(v>>8), uint8(v)
+			dst = dst[2:]
+			continue
+		}
+		dst[0] = uint8(v >> 8)
+		dst = dst[1:]
+	}
+}
@@ -0,0 +1,131 @@
+package pixel
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"io"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+)
+
+// minDelay is the delay used for GIF frames without a delay, or one too short to
+// be shown as is, like browsers do
+const minDelay = 100 * time.Millisecond
+
+// GIFPlayer plays an animated GIF through a mapper, the GIF is scaled to the canvas
+// of the mapper. Frames are drawn at the start of the controller frame they fall
+// into.
+type GIFPlayer struct {
+	*artnet.Playback
+	m      *Mapper
+	log    artnet.Logger
+	frames []*image.RGBA
+	delays []time.Duration
+	total  time.Duration
+
+	current int
+}
+
+// LoadGIF decodes the GIF in r and returns a player for it
+func LoadGIF(m *Mapper, r io.Reader, log artnet.Logger) (*GIFPlayer, error) {
+	g, err := gif.DecodeAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode GIF: %v", err)
+	}
+	return NewGIFPlayer(m, g, log)
+}
+
+// NewGIFPlayer returns a player for g. The frames are composed up front, so they can
+// be drawn as is while playing.
+func NewGIFPlayer(m *Mapper, g *gif.GIF, log artnet.Logger) (*GIFPlayer, error) {
+	if len(g.Image) == 0 {
+		return nil, errors.New("GIF without frames")
+	}
+
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() {
+		for _, img := range g.Image {
+			bounds = bounds.Union(img.Bounds())
+		}
+	}
+
+	p := &GIFPlayer{
+		m:       m,
+		log:     log.With(artnet.Fields{"type": "GIF"}),
+		frames:  make([]*image.RGBA, len(g.Image)),
+		delays:  make([]time.Duration, len(g.Image)),
+		current: -1,
+	}
+
+	canvas := image.NewRGBA(bounds)
+	for i, img := range g.Image {
+		disposal := byte(gif.DisposalNone)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = copyRGBA(canvas)
+		}
+
+		draw.Draw(canvas, img.Bounds(), img, img.Bounds().Min, draw.Over)
+		p.frames[i] = copyRGBA(canvas)
+
+		p.delays[i] = minDelay
+		if i < len(g.Delay) && g.Delay[i] > 1 {
+			p.delays[i] = time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		}
+		p.total += p.delays[i]
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, img.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	// the loop count is the number of times the GIF is repeated after the first
+	// time, 0 repeats forever and -1 plays it once
+	p.Playback = artnet.NewPlayback(m.c, p.total, p.play)
+	switch {
+	case g.LoopCount == 0:
+		p.SetLoops(-1)
+	case g.LoopCount > 0:
+		p.SetLoops(g.LoopCount)
+	}
+	return p, nil
+}
+
+// play draws the frame at position
+func (p *GIFPlayer) play(position time.Duration, looped bool) {
+	if looped {
+		p.current = -1
+	}
+	frame := len(p.frames) - 1
+	if position < p.total {
+		frame = 0
+		for end := p.delays[0]; end <= position; end += p.delays[frame] {
+			frame++
+		}
+	}
+	if frame == p.current {
+		return
+	}
+	p.current = frame
+
+	if err := p.m.Draw(p.frames[frame]); err != nil {
+		p.log.With(artnet.Fields{"frame": frame, "err": err}).Error("error drawing frame")
+	}
+}
+
+// copyRGBA returns a copy of img
+func copyRGBA(img *image.RGBA) *image.RGBA {
+	c := *img
+	c.Pix = append([]uint8(nil), img.Pix...)
+	return &c
+}
@@ -0,0 +1,201 @@
+package pixel
+
+import (
+	"fmt"
+	"image"
+	"sync"
+
+	"github.com/jsimonetti/go-artnet"
+)
+
+// pixel is a patched pixel of a fixture
+type pixel struct {
+	point   Point
+	format  Format
+	channel int
+}
+
+// Mapper maps images onto the fixtures added to it
+type Mapper struct {
+	c             *artnet.Controller
+	width, height float64
+
+	lock     sync.Mutex
+	fixtures []*Fixture
+	pixels   map[artnet.Address][]pixel
+	used     map[artnet.Address]*[512]bool
+
+	// next is the address following the last fixture added
+	next        artnet.Address
+	nextChannel int
+}
+
+// NewMapper returns a mapper for a canvas of width by height units, images are
+// scaled to the canvas
+func NewMapper(c *artnet.Controller, width, height float64) (*Mapper, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid canvas size %vx%v", width, height)
+	}
+	return &Mapper{
+		c:           c,
+		width:       width,
+		height:      height,
+		pixels:      make(map[artnet.Address][]pixel),
+		used:        make(map[artnet.Address]*[512]bool),
+		nextChannel: 1,
+	}, nil
+}
+
+// Add patches the pixels of a fixture from channel of universe. A pixel that does
+// not fit in the rest of a universe starts at channel 1 of the next one, pixels are
+// never split across universes.
+func (m *Mapper) Add(f *Fixture, universe artnet.Address, channel int) error {
+	if !f.Format.valid() {
+		return fmt.Errorf("fixture %s: unknown pixel format %v", f.Name, f.Format)
+	}
+	if len(f.Points) == 0 {
+		return fmt.Errorf("fixture %s: no pixels", f.Name)
+	}
+	if universe.Net > 0x7f {
+		return fmt.Errorf("fixture %s: invalid net %d for universe, must be 0-127", f.Name, universe.Net)
+	}
+	if channel < 1 || channel > 512 {
+		return fmt.Errorf("fixture %s: channel %d out of range 1-512", f.Name, channel)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// patch all pixels before changing anything, so an error leaves the mapper as is
+	n := f.Format.Channels()
+	address, ch := universe, channel
+	addresses := make([]artnet.Address, len(f.Points))
+	channels := make([]int, len(f.Points))
+	used := make(map[artnet.Address]*[512]bool)
+	for i := range f.Points {
+		if ch+n-1 > 512 {
+			next, err := nextUniverse(address)
+			if err != nil {
+				return fmt.Errorf("fixture %s: %v", f.Name, err)
+			}
+			address, ch = next, 1
+		}
+		if used[address] == nil {
+			used[address] = new([512]bool)
+			if u := m.used[address]; u != nil {
+				*used[address] = *u
+			}
+		}
+		for c := ch - 1; c < ch-1+n; c++ {
+			if used[address][c] {
+				return fmt.Errorf("fixture %s: channel %d of universe %s is already patched", f.Name, c+1, address)
+			}
+			used[address][c] = true
+		}
+		addresses[i], channels[i] = address, ch
+		ch += n
+	}
+
+	for address, u := range used {
+		m.used[address] = u
+	}
+	for i, p := range f.Points {
+		m.pixels[addresses[i]] = append(m.pixels[addresses[i]], pixel{
+			point:   p,
+			format:  f.Format,
+			channel: channels[i],
+		})
+	}
+	f.Universe, f.Channel = addresses[0], channels[0]
+	m.fixtures = append(m.fixtures, f)
+	m.next, m.nextChannel = address, ch
+	return nil
+}
+
+// Append patches the pixels of a fixture directly after the last fixture added, or
+// from channel 1 of universe 0:0.0 for the first fixture
+func (m *Mapper) Append(f *Fixture) error {
+	m.lock.Lock()
+	address, channel := m.next, m.nextChannel
+	m.lock.Unlock()
+
+	if channel > 512 {
+		next, err := nextUniverse(address)
+		if err != nil {
+			return fmt.Errorf("fixture %s: %v", f.Name, err)
+		}
+		address, channel = next, 1
+	}
+	return m.Add(f, address, channel)
+}
+
+// Fixtures returns the fixtures in the order they were added
+func (m *Mapper) Fixtures() []*Fixture {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return append([]*Fixture(nil), m.fixtures...)
+}
+
+// Universes returns the universes the fixtures are patched on
+func (m *Mapper) Universes() []artnet.Address {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	addresses := make([]artnet.Address, 0, len(m.pixels))
+	for address := range m.pixels {
+		addresses = append(addresses, address)
+	}
+	return addresses
+}
+
+// Draw samples img at the position of every pixel and writes the colors to the
+// universes. The image is scaled to the canvas and sampled at the nearest pixel.
+// All channels of a universe are changed in the same frame.
+func (m *Mapper) Draw(img image.Image) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return fmt.Errorf("empty image")
+	}
+	sx, sy := float64(bounds.Dx())/m.width, float64(bounds.Dy())/m.height
+
+	for address, pixels := range m.pixels {
+		u, err := m.c.Universe(address)
+		if err != nil {
+			return err
+		}
+		err = u.Update(func(f *artnet.Frame) error {
+			for _, p := range pixels {
+				x := bounds.Min.X + clamp(int(p.point.X*sx), bounds.Dx())
+				y := bounds.Min.Y + clamp(int(p.point.Y*sy), bounds.Dy())
+				p.format.encode(img.At(x, y), f[p.channel-1:])
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clamp limits v to 0 up to n
+func clamp(v, n int) int {
+	if v < 0 {
+		return 0
+	}
+	if v >= n {
+		return n - 1
+	}
+	return v
+}
+
+// nextUniverse returns the universe following address
+func nextUniverse(address artnet.Address) (artnet.Address, error) {
+	n := address.Integer() + 1
+	if n > 0x7fff {
+		return artnet.Address{}, fmt.Errorf("no universe after %s", address)
+	}
+	return artnet.Address{Net: uint8(n >> 8), SubUni: uint8(n)}, nil
+}
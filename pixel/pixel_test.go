@@ -0,0 +1,194 @@
+package pixel
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+)
+
+func TestFormat(t *testing.T) {
+	c := color.RGBA{R: 0x30, G: 0x20, B: 0x10, A: 0xff}
+
+	tests := []struct {
+		format Format
+		want   []byte
+	}{
+		{format: RGB, want: []byte{0x30, 0x20, 0x10}},
+		{format: GRB, want: []byte{0x20, 0x30, 0x10}},
+		{format: BGR, want: []byte{0x10, 0x20, 0x30}},
+		{format: RGBW, want: []byte{0x20, 0x10, 0x00, 0x10}},
+		{format: GRBW, want: []byte{0x10, 0x20, 0x00, 0x10}},
+		{format: RGB16, want: []byte{0x30, 0x30, 0x20, 0x20, 0x10, 0x10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format.String(), func(t *testing.T) {
+			if want, got := len(tt.want), tt.format.Channels(); want != got {
+				t.Fatalf("unexpected channels:\n- want: %d\n-  got: %d", want, got)
+			}
+			got := make([]byte, tt.format.Channels())
+			tt.format.encode(c, got)
+			if want := tt.want; !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected channels:\n- want: %v\n-  got: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestMatrix(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []MatrixOption
+		want []Point
+	}{
+		{
+			name: "Rows",
+			want: []Point{{0.5, 0.5}, {1.5, 0.5}, {0.5, 1.5}, {1.5, 1.5}},
+		},
+		{
+			name: "Serpentine",
+			opts: []MatrixOption{Serpentine()},
+			want: []Point{{0.5, 0.5}, {1.5, 0.5}, {1.5, 1.5}, {0.5, 1.5}},
+		},
+		{
+			name: "Columns",
+			opts: []MatrixOption{Columns()},
+			want: []Point{{0.5, 0.5}, {0.5, 1.5}, {1.5, 0.5}, {1.5, 1.5}},
+		},
+		{
+			name: "BottomRight",
+			opts: []MatrixOption{Start(BottomRight), Serpentine(), Origin(10, 20)},
+			want: []Point{{11.5, 21.5}, {10.5, 21.5}, {10.5, 20.5}, {11.5, 20.5}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if want, got := tt.want, Matrix("m", RGB, 2, 2, tt.opts...).Points; !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected points:\n- want: %v\n-  got: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestMapper(t *testing.T) {
+	c := artnet.NewController("test", net.IP{2, 0, 0, 1}, artnet.NewDefaultLogger())
+	m, err := NewMapper(c, 200, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 171 RGB pixels do not fit in a single universe
+	strip := Strip("strip", RGB, 171, Point{0.5, 0.5}, Point{170.5, 0.5})
+	if err := m.Append(strip); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rgbw := Points("rgbw", RGBW, []Point{{199.5, 0.5}})
+	if err := m.Append(rgbw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := (artnet.Address{SubUni: 1}), rgbw.Universe; want != got {
+		t.Fatalf("unexpected universe:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := 4, rgbw.Channel; want != got {
+		t.Fatalf("unexpected channel:\n- want: %d\n-  got: %d", want, got)
+	}
+
+	if err := m.Add(Points("overlap", RGB, []Point{{0, 0}}), artnet.Address{}, 510); err == nil {
+		t.Fatal("expected error for overlapping fixture")
+	}
+	if err := m.Add(Points("invalid", Format(-1), []Point{{0, 0}}), artnet.Address{SubUni: 2}, 1); err == nil {
+		t.Fatal("expected error for invalid format")
+	}
+	if want, got := []*Fixture{strip, rgbw}, m.Fixtures(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected fixtures:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// left half red, right half white
+	img := image.NewRGBA(image.Rect(0, 0, 4, 1))
+	img.Set(0, 0, color.RGBA{R: 0xff, A: 0xff})
+	img.Set(1, 0, color.RGBA{R: 0xff, A: 0xff})
+	img.Set(2, 0, color.White)
+	img.Set(3, 0, color.White)
+	if err := m.Draw(img); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	u1, _ := c.Universe(artnet.Address{})
+	u2, _ := c.Universe(artnet.Address{SubUni: 1})
+	f1, f2 := u1.Frame(), u2.Frame()
+	if want, got := []byte{0xff, 0, 0}, f1[0:3]; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected first pixel:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := []byte{0xff, 0xff, 0xff, 0, 0}, f1[507:512]; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected end of first universe:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := []byte{0xff, 0xff, 0xff, 0, 0, 0, 0xff}, f2[0:7]; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected second universe:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestGIFPlayer(t *testing.T) {
+	c := artnet.NewController("test", net.IP{2, 0, 0, 1}, artnet.NewDefaultLogger())
+	m, _ := NewMapper(c, 1, 1)
+	if err := m.Append(Points("pixel", RGB, []Point{{0.5, 0.5}})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	u, _ := c.Universe(artnet.Address{})
+
+	palette := color.Palette{color.Transparent, color.RGBA{R: 1, A: 0xff}, color.RGBA{R: 2, A: 0xff}}
+	frame := func(index uint8) *image.Paletted {
+		img := image.NewPaletted(image.Rect(0, 0, 1, 1), palette)
+		img.SetColorIndex(0, 0, index)
+		return img
+	}
+	g := &gif.GIF{
+		// the second frame is disposed to the previous one, so the transparent last frame
+		// shows the first one
+		Image:     []*image.Paletted{frame(1), frame(2), frame(0)},
+		Delay:     []int{0, 5, 5},
+		Disposal:  []byte{gif.DisposalNone, gif.DisposalPrevious, gif.DisposalNone},
+		LoopCount: 1,
+	}
+	p, err := NewGIFPlayer(m, g, artnet.NewDefaultLogger())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := 200*time.Millisecond, p.Duration(); want != got {
+		t.Fatalf("unexpected duration:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	r, err := artnet.NewControllerReplay(c, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p.Start()
+	defer p.Stop()
+	tests := []struct {
+		name string
+		step time.Duration
+		want byte
+	}{
+		// playback starts on the first frame of the controller
+		{name: "Start", step: time.Millisecond, want: 1},
+		{name: "Second", step: 100 * time.Millisecond, want: 2},
+		{name: "Disposed", step: 50 * time.Millisecond, want: 1},
+		{name: "Loop", step: 150 * time.Millisecond, want: 2},
+		{name: "End", step: 200 * time.Millisecond, want: 1},
+	}
+	for _, tt := range tests {
+		r.Advance(tt.step)
+		if want, got := tt.want, u.Frame()[0]; want != got {
+			t.Fatalf("%s: unexpected output:\n- want: %d\n-  got: %d", tt.name, want, got)
+		}
+	}
+	if p.Playing() {
+		t.Fatal("expected player to stop after the last loop")
+	}
+}
@@ -0,0 +1,180 @@
+package artnet
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PlaybackFn outputs the frame of a player at position. looped is true when the
+// position went back to the start since the last call, because playback looped or
+// started over.
+type PlaybackFn func(position time.Duration, looped bool)
+
+// Playback is the transport of a player running on the frame clock of a controller.
+// It advances the position of the player with the frames while playing, handles
+// looping and stops at the end, and calls the player to output the frame at the
+// position. Players embed it and only implement the output of their format.
+type Playback struct {
+	c        *Controller
+	duration time.Duration
+	fn       PlaybackFn
+
+	lock     sync.Mutex
+	remove   func()
+	playing  bool
+	lastTick time.Time
+	position time.Duration
+	speed    float64
+	// loops is the number of times playback is repeated after the first time, -1
+	// to repeat forever, played the number of times it has been repeated
+	loops  int
+	played int
+	looped bool
+}
+
+// NewPlayback returns the transport of a player of duration, which outputs its
+// frames with fn
+func NewPlayback(c *Controller, duration time.Duration, fn PlaybackFn) *Playback {
+	return &Playback{
+		c:        c,
+		duration: duration,
+		fn:       fn,
+		speed:    1,
+	}
+}
+
+// Duration returns the duration of a single play
+func (p *Playback) Duration() time.Duration {
+	return p.duration
+}
+
+// Position returns the current position
+func (p *Playback) Position() time.Duration {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.position
+}
+
+// Playing returns whether the player is playing, it stops at the end unless it loops
+func (p *Playback) Playing() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.playing
+}
+
+// SetSpeed sets the playback speed, 1 plays at the original speed
+func (p *Playback) SetSpeed(speed float64) error {
+	if speed <= 0 {
+		return fmt.Errorf("invalid speed %v, must be greater than 0", speed)
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.speed = speed
+	return nil
+}
+
+// SetLoop sets whether playback restarts at the beginning after the end
+func (p *Playback) SetLoop(loop bool) {
+	if loop {
+		p.SetLoops(-1)
+	} else {
+		p.SetLoops(0)
+	}
+}
+
+// SetLoops sets the number of times playback is repeated after the first time, -1
+// repeats forever
+func (p *Playback) SetLoops(loops int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.loops = loops
+}
+
+// Start starts or resumes playback at the current position, a player that has
+// ended starts over
+func (p *Playback) Start() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.remove != nil {
+		return
+	}
+	if p.position >= p.duration {
+		p.position, p.played, p.looped = 0, 0, true
+	}
+	p.playing = true
+	p.lastTick = time.Time{}
+	p.remove = p.c.OnFrame(p.step)
+}
+
+// Stop pauses playback at the current position
+func (p *Playback) Stop() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.stop()
+}
+
+// stop removes the frame hook of the player
+func (p *Playback) stop() {
+	p.playing = false
+	if p.remove != nil {
+		p.remove()
+		p.remove = nil
+	}
+}
+
+// Seek moves playback to position d, limited to the duration, and calls fn with
+// the new position before the next frame is played. fn may be nil.
+func (p *Playback) Seek(d time.Duration, fn func(position time.Duration)) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if d < 0 {
+		d = 0
+	}
+	if d > p.duration {
+		d = p.duration
+	}
+	p.position = d
+	if fn != nil {
+		fn(d)
+	}
+}
+
+// Sync moves playback to position d when it is off by tolerance or more, to follow
+// an external clock without jumping on every small drift
+func (p *Playback) Sync(d, tolerance time.Duration) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if drift := d - p.position; drift < tolerance && drift > -tolerance {
+		return
+	}
+	p.position = d
+}
+
+// step advances playback to now and outputs the frame at that position
+func (p *Playback) step(now time.Time) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if !p.playing {
+		return
+	}
+	if !p.lastTick.IsZero() {
+		p.position += time.Duration(float64(now.Sub(p.lastTick)) * p.speed)
+	}
+	p.lastTick = now
+
+	for p.position >= p.duration {
+		if p.duration == 0 || (p.loops >= 0 && p.played >= p.loops) {
+			// hold the end
+			p.position = p.duration
+			p.stop()
+			break
+		}
+		p.position -= p.duration
+		p.played++
+		p.looped = true
+	}
+	looped := p.looped
+	p.looped = false
+	p.fn(p.position, looped)
+}
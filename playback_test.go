@@ -0,0 +1,81 @@
+package artnet
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPlayback(t *testing.T) {
+	c := NewController("test", net.IP{2, 0, 0, 1}, NewDefaultLogger())
+
+	type call struct {
+		position time.Duration
+		looped   bool
+	}
+	var got call
+	calls := 0
+	p := NewPlayback(c, 100*time.Millisecond, func(position time.Duration, looped bool) {
+		got = call{position: position, looped: looped}
+		calls++
+	})
+
+	now := time.Unix(0, 0)
+	tests := []struct {
+		name  string
+		setup func()
+		step  time.Duration
+		want  call
+		// stopped is whether playback stopped at the end
+		stopped bool
+	}{
+		{name: "Start", setup: p.Start},
+		{name: "Play", step: 40 * time.Millisecond, want: call{position: 40 * time.Millisecond}},
+		{name: "Speed", setup: func() { p.SetSpeed(2) }, step: 20 * time.Millisecond, want: call{position: 80 * time.Millisecond}},
+		{name: "Loop", setup: func() { p.SetSpeed(1); p.SetLoops(1) }, step: 30 * time.Millisecond, want: call{position: 10 * time.Millisecond, looped: true}},
+		{name: "End", step: 200 * time.Millisecond, want: call{position: 100 * time.Millisecond}, stopped: true},
+		{name: "StartOver", setup: p.Start, step: 10 * time.Millisecond, want: call{looped: true}},
+	}
+	for _, tt := range tests {
+		if tt.setup != nil {
+			tt.setup()
+		}
+		now = now.Add(tt.step)
+		p.step(now)
+		if tt.want != got {
+			t.Fatalf("%s: unexpected call:\n- want: %+v\n-  got: %+v", tt.name, tt.want, got)
+		}
+		if want, got := !tt.stopped, p.Playing(); want != got {
+			t.Fatalf("%s: unexpected playing:\n- want: %v\n-  got: %v", tt.name, want, got)
+		}
+	}
+
+	// a stopped player does not play
+	p.Stop()
+	before := calls
+	p.step(now.Add(10 * time.Millisecond))
+	if calls != before {
+		t.Fatal("unexpected call while stopped")
+	}
+
+	if err := p.SetSpeed(0); err == nil {
+		t.Fatal("expected error for speed 0")
+	}
+
+	var seeked time.Duration
+	p.Seek(time.Second, func(position time.Duration) { seeked = position })
+	if want, got := 100*time.Millisecond, seeked; want != got {
+		t.Fatalf("unexpected seek position:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// small drifts of an external clock are ignored
+	p.Seek(50*time.Millisecond, nil)
+	p.Sync(55*time.Millisecond, 10*time.Millisecond)
+	if want, got := 50*time.Millisecond, p.Position(); want != got {
+		t.Fatalf("unexpected position after small drift:\n- want: %v\n-  got: %v", want, got)
+	}
+	p.Sync(70*time.Millisecond, 10*time.Millisecond)
+	if want, got := 70*time.Millisecond, p.Position(); want != got {
+		t.Fatalf("unexpected position after sync:\n- want: %v\n-  got: %v", want, got)
+	}
+}
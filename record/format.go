@@ -0,0 +1,303 @@
+// Package record records ArtDmx traffic into a compact file and plays it back.
+//
+// A recording starts with the 8 byte magic "ARTNREC" followed by the format
+// version 1, and the start time as nanoseconds since the Unix epoch (int64, big
+// endian). Every frame that follows is encoded as:
+//
+//	uvarint  microseconds since the previous frame (or the start)
+//	uvarint  universe, the 15 bit Port-Address
+//	byte     sequence number
+//	uvarint  number of channels, 1 to 512
+//	byte     encoding, 0 for a full frame, 1 for a delta
+//	...      the channels of a full frame, or the runs of a delta
+//
+// A delta holds the changes against the previous frame of the same universe as a
+// uvarint number of runs, each a uvarint number of unchanged channels to skip, a
+// uvarint number of changed channels and the values of those channels. The
+// smaller of both encodings is written.
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+)
+
+const (
+	magic   = "ARTNREC"
+	version = 1
+
+	encodingFull  = 0
+	encodingDelta = 1
+)
+
+// Frame is a recorded ArtDmx frame
+type Frame struct {
+	Time     time.Time
+	Universe artnet.Address
+	Sequence uint8
+	Data     []byte
+}
+
+// Writer writes frames to a recording
+type Writer struct {
+	w    *bufio.Writer
+	prev time.Time
+	last map[artnet.Address][]byte
+	buf  bytes.Buffer
+}
+
+// NewWriter writes the header of a recording starting at start
+func NewWriter(w io.Writer, start time.Time) (*Writer, error) {
+	bw := bufio.NewWriter(w)
+	header := make([]byte, 16)
+	copy(header, magic)
+	header[7] = version
+	binary.BigEndian.PutUint64(header[8:], uint64(start.UnixNano()))
+	if _, err := bw.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &Writer{
+		w:    bw,
+		prev: start,
+		last: make(map[artnet.Address][]byte),
+	}, nil
+}
+
+// Write writes a frame. Frames must be written in chronological order.
+func (w *Writer) Write(f Frame) error {
+	if len(f.Data) < 1 || len(f.Data) > 512 {
+		return fmt.Errorf("frame of %d channels out of range 1-512", len(f.Data))
+	}
+	if f.Time.Before(w.prev) {
+		return fmt.Errorf("frame at %s before previous frame at %s", f.Time, w.prev)
+	}
+
+	var tmp [binary.MaxVarintLen64]byte
+	uvarint := func(v uint64) {
+		w.buf.Write(tmp[:binary.PutUvarint(tmp[:], v)])
+	}
+
+	w.buf.Reset()
+	uvarint(uint64(f.Time.Sub(w.prev) / time.Microsecond))
+	uvarint(uint64(f.Universe.Integer()))
+	w.buf.WriteByte(f.Sequence)
+	uvarint(uint64(len(f.Data)))
+
+	delta := encodeDelta(w.last[f.Universe], f.Data)
+	if delta != nil && len(delta) < len(f.Data) {
+		w.buf.WriteByte(encodingDelta)
+		w.buf.Write(delta)
+	} else {
+		w.buf.WriteByte(encodingFull)
+		w.buf.Write(f.Data)
+	}
+
+	if _, err := w.w.Write(w.buf.Bytes()); err != nil {
+		return err
+	}
+
+	// keep the time at microsecond precision, like the reader sees it
+	w.prev = w.prev.Add(f.Time.Sub(w.prev) / time.Microsecond * time.Microsecond)
+	w.last[f.Universe] = append(w.last[f.Universe][:0], f.Data...)
+	return nil
+}
+
+// Flush writes buffered frames to the underlying writer
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// encodeDelta encodes the changes from prev to data, it returns nil when prev has
+// a different length
+func encodeDelta(prev, data []byte) []byte {
+	if len(prev) != len(data) {
+		return nil
+	}
+
+	var runs bytes.Buffer
+	var tmp [binary.MaxVarintLen64]byte
+	n := 0
+	for i := 0; i < len(data); {
+		start := i
+		for i < len(data) && data[i] == prev[i] {
+			i++
+		}
+		if i == len(data) {
+			break
+		}
+		skip := i - start
+		start = i
+		for i < len(data) && data[i] != prev[i] {
+			i++
+		}
+		runs.Write(tmp[:binary.PutUvarint(tmp[:], uint64(skip))])
+		runs.Write(tmp[:binary.PutUvarint(tmp[:], uint64(i-start))])
+		runs.Write(data[start:i])
+		n++
+	}
+
+	b := make([]byte, binary.PutUvarint(tmp[:], uint64(n)), binary.MaxVarintLen64+runs.Len())
+	copy(b, tmp[:])
+	return append(b, runs.Bytes()...)
+}
+
+// Reader reads frames from a recording
+type Reader struct {
+	r     *bufio.Reader
+	start time.Time
+	prev  time.Time
+	last  map[artnet.Address][]byte
+}
+
+// NewReader reads the header of a recording
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("failed to read recording header: %v", err)
+	}
+	if string(header[:7]) != magic {
+		return nil, errors.New("not a recording")
+	}
+	if header[7] != version {
+		return nil, fmt.Errorf("unsupported recording version %d", header[7])
+	}
+
+	start := time.Unix(0, int64(binary.BigEndian.Uint64(header[8:])))
+	return &Reader{
+		r:     br,
+		start: start,
+		prev:  start,
+		last:  make(map[artnet.Address][]byte),
+	}, nil
+}
+
+// Start returns the start time of the recording
+func (r *Reader) Start() time.Time {
+	return r.start
+}
+
+// Next returns the next frame, or io.EOF at the end of the recording
+func (r *Reader) Next() (Frame, error) {
+	dt, err := binary.ReadUvarint(r.r)
+	if err == io.EOF {
+		return Frame{}, io.EOF
+	}
+	if err != nil {
+		return Frame{}, r.corrupt(err)
+	}
+
+	universe, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return Frame{}, r.corrupt(err)
+	}
+	seq, err := r.r.ReadByte()
+	if err != nil {
+		return Frame{}, r.corrupt(err)
+	}
+	length, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return Frame{}, r.corrupt(err)
+	}
+	if universe > 0x7fff || length < 1 || length > 512 {
+		return Frame{}, r.corrupt(fmt.Errorf("invalid universe %d or length %d", universe, length))
+	}
+
+	f := Frame{
+		Time:     r.prev.Add(time.Duration(dt) * time.Microsecond),
+		Universe: artnet.Address{Net: uint8(universe >> 8), SubUni: uint8(universe)},
+		Sequence: seq,
+		Data:     make([]byte, length),
+	}
+
+	encoding, err := r.r.ReadByte()
+	if err != nil {
+		return Frame{}, r.corrupt(err)
+	}
+	switch encoding {
+	case encodingFull:
+		if _, err := io.ReadFull(r.r, f.Data); err != nil {
+			return Frame{}, r.corrupt(err)
+		}
+	case encodingDelta:
+		if err := r.delta(f); err != nil {
+			return Frame{}, r.corrupt(err)
+		}
+	default:
+		return Frame{}, r.corrupt(fmt.Errorf("unknown encoding %d", encoding))
+	}
+
+	r.prev = f.Time
+	r.last[f.Universe] = append(r.last[f.Universe][:0], f.Data...)
+	return f, nil
+}
+
+// delta decodes the runs of a delta into f
+func (r *Reader) delta(f Frame) error {
+	prev := r.last[f.Universe]
+	if len(prev) != len(f.Data) {
+		return fmt.Errorf("delta for universe %s without previous frame", f.Universe)
+	}
+	copy(f.Data, prev)
+
+	runs, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return err
+	}
+	i := uint64(0)
+	for ; runs > 0; runs-- {
+		skip, err := binary.ReadUvarint(r.r)
+		if err != nil {
+			return err
+		}
+		n, err := binary.ReadUvarint(r.r)
+		if err != nil {
+			return err
+		}
+		i += skip
+		if i+n > uint64(len(f.Data)) {
+			return errors.New("delta out of range")
+		}
+		if _, err := io.ReadFull(r.r, f.Data[i:i+n]); err != nil {
+			return err
+		}
+		i += n
+	}
+	return nil
+}
+
+// corrupt returns an error for a corrupt recording
+func (r *Reader) corrupt(err error) error {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return fmt.Errorf("corrupt recording: %v", err)
+}
+
+// ReadAll reads all frames of a recording
+func ReadAll(r io.Reader) ([]Frame, error) {
+	rr, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var frames []Frame
+	for {
+		f, err := rr.Next()
+		if err == io.EOF {
+			return frames, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, f)
+	}
+}
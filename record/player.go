@@ -0,0 +1,129 @@
+package record
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+	"github.com/jsimonetti/go-artnet/packet"
+)
+
+// Player plays a recording back through a controller. Frames are applied at the
+// start of the controller frame they fall into, so the timing of a recording is
+// quantised to the frame rate of the controller.
+type Player struct {
+	*artnet.Playback
+	c      *artnet.Controller
+	frames []Frame
+
+	lock  sync.Mutex
+	next  int
+	remap map[artnet.Address]artnet.Address
+}
+
+// NewPlayer returns a player for frames, which must be in chronological order.
+// Positions are relative to the first frame.
+func NewPlayer(c *artnet.Controller, frames []Frame) *Player {
+	p := &Player{
+		c:      c,
+		frames: frames,
+		remap:  make(map[artnet.Address]artnet.Address),
+	}
+	var duration time.Duration
+	if len(frames) > 0 {
+		duration = p.offset(len(frames) - 1)
+	}
+	p.Playback = artnet.NewPlayback(c, duration, p.play)
+	return p
+}
+
+// offset returns the position of frame i
+func (p *Player) offset(i int) time.Duration {
+	return p.frames[i].Time.Sub(p.frames[0].Time)
+}
+
+// Remap plays the frames recorded on universe from on universe to instead
+func (p *Player) Remap(from, to artnet.Address) error {
+	if _, err := p.c.Universe(to); err != nil {
+		return err
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.remap[from] = to
+	return nil
+}
+
+// Seek moves playback to position d and outputs the last recorded frame of every
+// universe at that position
+func (p *Player) Seek(d time.Duration) {
+	p.Playback.Seek(d, func(position time.Duration) {
+		pending := make(map[artnet.Address][]byte)
+		p.next = 0
+		p.advance(position, pending)
+		p.apply(pending)
+	})
+}
+
+// play outputs the frames up to position
+func (p *Player) play(position time.Duration, looped bool) {
+	pending := make(map[artnet.Address][]byte)
+	if looped {
+		// finish the previous play before starting over
+		p.advance(p.Duration(), pending)
+		p.next = 0
+	}
+	p.advance(position, pending)
+	p.apply(pending)
+}
+
+// advance collects the frames up to position in pending, keyed by the universe
+// they are output on
+func (p *Player) advance(position time.Duration, pending map[artnet.Address][]byte) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for p.next < len(p.frames) && p.offset(p.next) <= position {
+		f := p.frames[p.next]
+		address := f.Universe
+		if to, ok := p.remap[address]; ok {
+			address = to
+		}
+		pending[address] = f.Data
+		p.next++
+	}
+}
+
+// apply outputs the collected frames
+func (p *Player) apply(pending map[artnet.Address][]byte) {
+	for address, data := range pending {
+		u, err := p.c.Universe(address)
+		if err != nil {
+			continue
+		}
+		u.Update(func(f *artnet.Frame) error {
+			copy(f[:], data)
+			return nil
+		})
+	}
+}
+
+// Replay feeds frames into a replay in order, as ArtDmx packets sent from src to
+// dst
+func Replay(r *artnet.Replay, frames []Frame, src, dst net.UDPAddr) error {
+	for _, f := range frames {
+		p := &packet.ArtDMXPacket{
+			Sequence: f.Sequence,
+			SubUni:   f.Universe.SubUni,
+			Net:      f.Universe.Net,
+		}
+		copy(p.Data[:], f.Data)
+		b, err := p.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		if err := r.Receive(f.Time, src, dst, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
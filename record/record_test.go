@@ -0,0 +1,171 @@
+package record
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+	"github.com/jsimonetti/go-artnet/packet"
+)
+
+func TestFormat(t *testing.T) {
+	start := time.Unix(1760000000, 0)
+	a1, a2 := artnet.Address{SubUni: 1}, artnet.Address{Net: 2, SubUni: 0x13}
+
+	full := make([]byte, 512)
+	full[0], full[511] = 0xff, 0x01
+	changed := append([]byte(nil), full...)
+	changed[10], changed[11], changed[200] = 0x10, 0x20, 0x30
+	frames := []Frame{
+		{Time: start, Universe: a1, Sequence: 1, Data: full},
+		{Time: start.Add(25 * time.Millisecond), Universe: a2, Sequence: 1, Data: []byte{1, 2}},
+		{Time: start.Add(50 * time.Millisecond), Universe: a1, Sequence: 2, Data: changed},
+		{Time: start.Add(75 * time.Millisecond), Universe: a1, Sequence: 3, Data: changed},
+		{Time: start.Add(100 * time.Millisecond), Universe: a2, Sequence: 2, Data: []byte{1, 2, 3, 4}},
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, start)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, f := range frames {
+		if err := w.Write(f); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := w.Write(frames[0]); err == nil {
+		t.Fatal("expected error for frame out of order")
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the deltas of the unchanged and changed frames take a few bytes each
+	if max, got := 16+512+600/10+60, buf.Len(); got > max {
+		t.Fatalf("expected recording of at most %d bytes, got %d", max, got)
+	}
+
+	got, err := ReadAll(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := range got {
+		got[i].Time = got[i].Time.In(start.Location())
+	}
+	if !reflect.DeepEqual(frames, got) {
+		t.Fatalf("unexpected frames:\n- want: %v\n-  got: %v", frames, got)
+	}
+
+	if _, err := ReadAll(bytes.NewReader(buf.Bytes()[:buf.Len()-1])); err == nil {
+		t.Fatal("expected error for truncated recording")
+	}
+	if _, err := NewReader(bytes.NewReader([]byte("not a recording!"))); err == nil {
+		t.Fatal("expected error for invalid header")
+	}
+}
+
+type tapper struct {
+	fn artnet.TapFn
+}
+
+func (t *tapper) AddTap(fn artnet.TapFn) func() {
+	t.fn = fn
+	return func() { t.fn = nil }
+}
+
+func TestRecorder(t *testing.T) {
+	src := &tapper{}
+	var buf bytes.Buffer
+	r, err := NewRecorder(src, &buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	send := func(seq uint8, values ...byte) {
+		p := &packet.ArtDMXPacket{Sequence: seq, SubUni: 1, Length: uint16(len(values))}
+		copy(p.Data[:], values)
+		b, err := p.MarshalBinary()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		src.fn(time.Now(), net.UDPAddr{}, net.UDPAddr{}, b)
+	}
+	send(1, 0xff, 0x00)
+	send(1, 0xff, 0x00) // same packet to a second node
+	send(2, 0xff, 0x80)
+	src.fn(time.Now(), net.UDPAddr{}, net.UDPAddr{}, []byte("not art-net"))
+
+	if err := r.Stop(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if src.fn != nil {
+		t.Fatal("expected tap to be removed")
+	}
+
+	frames, err := ReadAll(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := 2, len(frames); want != got {
+		t.Fatalf("unexpected frames:\n- want: %d\n-  got: %d", want, got)
+	}
+	if want, got := []byte{0xff, 0x80}, frames[1].Data[:2]; !bytes.Equal(want, got) {
+		t.Fatalf("unexpected data:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestPlayer(t *testing.T) {
+	c := artnet.NewController("test", net.IP{2, 0, 0, 1}, artnet.NewDefaultLogger())
+	a1, a2 := artnet.Address{SubUni: 1}, artnet.Address{SubUni: 2}
+	start := time.Unix(1760000000, 0)
+	p := NewPlayer(c, []Frame{
+		{Time: start, Universe: a1, Data: []byte{10}},
+		{Time: start.Add(100 * time.Millisecond), Universe: a1, Data: []byte{20}},
+		{Time: start.Add(200 * time.Millisecond), Universe: a1, Data: []byte{30}},
+	})
+	if err := p.Remap(a1, a2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p.SetSpeed(0); err == nil {
+		t.Fatal("expected error for speed 0")
+	}
+
+	u, _ := c.Universe(a2)
+	channel := func() uint8 {
+		v, _ := u.Channel(1)
+		return v
+	}
+
+	// speed, looping and stopping at the end are handled by the Playback, the
+	// player outputs the frames up to the position it is called with
+	tests := []struct {
+		name     string
+		position time.Duration
+		looped   bool
+		want     uint8
+	}{
+		{name: "Start", want: 10},
+		{name: "Before", position: 50 * time.Millisecond, want: 10},
+		{name: "Frame", position: 100 * time.Millisecond, want: 20},
+		{name: "Loop", position: 10 * time.Millisecond, looped: true, want: 10},
+		{name: "End", position: 200 * time.Millisecond, want: 30},
+	}
+	for _, tt := range tests {
+		p.play(tt.position, tt.looped)
+		if want, got := tt.want, channel(); want != got {
+			t.Fatalf("%s: unexpected value:\n- want: %d\n-  got: %d", tt.name, want, got)
+		}
+	}
+
+	p.Seek(150 * time.Millisecond)
+	if want, got := uint8(20), channel(); want != got {
+		t.Fatalf("unexpected value after seek:\n- want: %d\n-  got: %d", want, got)
+	}
+	if want, got := 150*time.Millisecond, p.Position(); want != got {
+		t.Fatalf("unexpected position:\n- want: %v\n-  got: %v", want, got)
+	}
+}
@@ -0,0 +1,106 @@
+package record
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+	"github.com/jsimonetti/go-artnet/packet"
+)
+
+// Recorder records the ArtDmx packets a node sends or receives
+type Recorder struct {
+	lock   sync.Mutex
+	w      *Writer
+	remove func()
+	err    error
+	frames int
+
+	// last holds the last recorded frame of every universe
+	last map[artnet.Address]Frame
+}
+
+// NewRecorder starts recording the ArtDmx traffic of src to w. The recording
+// starts at the current time.
+func NewRecorder(src artnet.Tapper, w io.Writer) (*Recorder, error) {
+	fw, err := NewWriter(w, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{
+		w:    fw,
+		last: make(map[artnet.Address]Frame),
+	}
+	r.remove = src.AddTap(r.tap)
+	return r, nil
+}
+
+// tap records a packet if it is an ArtDmx packet
+func (r *Recorder) tap(ts time.Time, src, dst net.UDPAddr, data []byte) {
+	p, err := packet.Unmarshal(data)
+	if err != nil {
+		return
+	}
+	dmx, ok := p.(*packet.ArtDMXPacket)
+	if !ok || dmx.Length < 1 || dmx.Length > 512 {
+		return
+	}
+
+	f := Frame{
+		Time:     ts,
+		Universe: artnet.Address{Net: dmx.Net, SubUni: dmx.SubUni},
+		Sequence: dmx.Sequence,
+		Data:     append([]byte(nil), dmx.Data[:dmx.Length]...),
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.err != nil || r.w == nil {
+		return
+	}
+
+	// a controller sends the same packet to every node subscribed to a universe
+	last, ok := r.last[f.Universe]
+	if ok && f.Sequence != 0 && f.Sequence == last.Sequence && bytes.Equal(f.Data, last.Data) {
+		return
+	}
+
+	// taps of the send and receive loop may race, keep the recording in order
+	if f.Time.Before(r.w.prev) {
+		f.Time = r.w.prev
+	}
+
+	if r.err = r.w.Write(f); r.err != nil {
+		return
+	}
+	r.last[f.Universe] = f
+	r.frames++
+}
+
+// Frames returns the number of frames recorded
+func (r *Recorder) Frames() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.frames
+}
+
+// Stop stops recording and flushes the recording to the writer. It returns the
+// first error that occurred while recording.
+func (r *Recorder) Stop() error {
+	r.remove()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.w == nil {
+		return r.err
+	}
+	if r.err == nil {
+		r.err = r.w.Flush()
+	}
+	r.w = nil
+	return r.err
+}
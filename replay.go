@@ -0,0 +1,213 @@
+package artnet
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/jsimonetti/go-artnet/packet"
+)
+
+// ReplayPacket is a packet sent by a node during a replay
+type ReplayPacket struct {
+	Time time.Time
+	Dst  net.UDPAddr
+	Data []byte
+}
+
+// Packet unmarshals the data of the packet
+func (p ReplayPacket) Packet() (packet.ArtNetPacket, error) {
+	return packet.Unmarshal(p.Data)
+}
+
+// Replay feeds captured or recorded traffic into a Node or Controller as if it
+// arrived from the network, on a virtual clock. Nothing is sent to the network,
+// the packets the node sends are collected instead. The timers of the node, like
+// polling, frames, merge expiry and input keep-alives, run as the clock advances.
+// ArtPollReplies are sent without the random delay, so a replay is deterministic.
+//
+// A node must not be started when it is replayed, and cannot be started after. A
+// Replay is not safe for concurrent use.
+type Replay struct {
+	n      *Node
+	c      *Controller
+	now    time.Time
+	timers []*replayTimer
+
+	sent    []ReplayPacket
+	outputs map[int]nodeFrame
+
+	// lastReply holds the last ArtPollReply sent
+	lastReply []byte
+}
+
+// replayTimer runs fn every interval on the virtual clock
+type replayTimer struct {
+	next     time.Time
+	interval time.Duration
+	fn       func(now time.Time)
+}
+
+// NewReplay starts a replay of traffic into node n at start
+func NewReplay(n *Node, start time.Time) (*Replay, error) {
+	r, err := newReplay(n, start)
+	if err != nil {
+		return nil, err
+	}
+
+	// announce ourselves on power up
+	r.lastReply = n.sendPollReply()
+	return r, nil
+}
+
+// NewControllerReplay starts a replay of traffic into controller c at start
+func NewControllerReplay(c *Controller, start time.Time) (*Replay, error) {
+	if c.maxFPS <= 0 {
+		return nil, fmt.Errorf("invalid frame rate %d", c.maxFPS)
+	}
+	r, err := newReplay(c.cNode, start)
+	if err != nil {
+		return nil, err
+	}
+	r.c = c
+	r.timer(c.frameInterval(), c.frame)
+	r.timer(pollInterval, func(time.Time) { c.poll() })
+	r.timer(pollInterval, func(time.Time) { c.gcNode() })
+
+	r.lastReply = c.cNode.sendPollReply()
+	c.poll()
+	return r, nil
+}
+
+// newReplay sets up node n for a replay
+func newReplay(n *Node, start time.Time) (*Replay, error) {
+	r := &Replay{
+		n:       n,
+		now:     start,
+		outputs: make(map[int]nodeFrame),
+	}
+	if err := n.init(); err != nil {
+		return nil, fmt.Errorf("failed to replay node: %v", err)
+	}
+	n.clock = r
+	n.transport = r
+
+	// keep the outputs of the node
+	outputFn := n.outputFn
+	n.outputFn = func(port int, address Address, dmx [512]byte) {
+		r.outputs[port] = nodeFrame{address: address, dmx: dmx}
+		if outputFn != nil {
+			outputFn(port, address, dmx)
+		}
+	}
+
+	r.timer(time.Second, n.expire)
+	r.timer(inputPollInterval, n.pollInputs)
+	return r, nil
+}
+
+// timer adds a timer, timers due at the same time run in the order they were added
+func (r *Replay) timer(interval time.Duration, fn func(now time.Time)) {
+	r.timers = append(r.timers, &replayTimer{
+		next:     r.now.Add(interval),
+		interval: interval,
+		fn:       fn,
+	})
+}
+
+// Now returns the time of the virtual clock
+func (r *Replay) Now() time.Time {
+	return r.now
+}
+
+// Advance advances the virtual clock by d
+func (r *Replay) Advance(d time.Duration) {
+	r.AdvanceTo(r.now.Add(d))
+}
+
+// AdvanceTo advances the virtual clock to t and runs the timers that are due on
+// the way. The clock never goes back.
+func (r *Replay) AdvanceTo(t time.Time) {
+	for {
+		var next *replayTimer
+		for _, tm := range r.timers {
+			if next == nil || tm.next.Before(next.next) {
+				next = tm
+			}
+		}
+		if next == nil || next.next.After(t) {
+			break
+		}
+		r.now = next.next
+		next.next = next.next.Add(next.interval)
+		next.fn(r.now)
+	}
+	if t.After(r.now) {
+		r.now = t
+	}
+}
+
+// Receive advances the clock to ts and handles the packet as if it was received
+// from src. Packets sent by the node itself, and packets addressed to another IP
+// than that of the node or its broadcast address are ignored.
+func (r *Replay) Receive(ts time.Time, src, dst net.UDPAddr, data []byte) error {
+	r.AdvanceTo(ts)
+
+	if r.n.localAddr.IP.Equal(src.IP) || !r.accepts(dst.IP) {
+		return nil
+	}
+	r.n.tap(r.now, src, r.n.localAddr, data)
+
+	p, err := packet.Unmarshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse packet from %s: %v", src.IP, err)
+	}
+	r.n.handlePacket(p, src)
+	return nil
+}
+
+// accepts indicates if the node receives packets sent to ip
+func (r *Replay) accepts(ip net.IP) bool {
+	return len(ip) == 0 || ip.IsUnspecified() || ip.Equal(net.IPv4bcast) ||
+		ip.Equal(r.n.localAddr.IP) || ip.Equal(r.n.broadcastAddr.IP)
+}
+
+// Sent returns the packets sent since the previous call
+func (r *Replay) Sent() []ReplayPacket {
+	sent := r.sent
+	r.sent = nil
+	return sent
+}
+
+// Output returns the last DMX data output on an output port of the node
+func (r *Replay) Output(port int) (Address, [512]byte, bool) {
+	out, ok := r.outputs[port]
+	return out.address, out.dmx, ok
+}
+
+// send collects a packet sent by the node
+func (r *Replay) send(address net.UDPAddr, data []byte) {
+	r.n.tap(r.now, r.n.localAddr, address, data)
+	r.sent = append(r.sent, ReplayPacket{
+		Time: r.now,
+		Dst:  address,
+		Data: append([]byte(nil), data...),
+	})
+}
+
+// pollReply sends an ArtPollReply
+func (r *Replay) pollReply() {
+	r.lastReply = r.n.sendPollReply()
+}
+
+// change sends an ArtPollReply if the conditions of the node changed
+func (r *Replay) change() {
+	r.lastReply = r.n.sendChangedPollReply(r.lastReply)
+}
+
+// forwardPollReply hands an ArtPollReply to the controller
+func (r *Replay) forwardPollReply(p packet.ArtPollReplyPacket) {
+	if r.c != nil {
+		r.c.handlePollReply(p)
+	}
+}
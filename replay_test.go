@@ -0,0 +1,142 @@
+package artnet
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jsimonetti/go-artnet/packet"
+	"github.com/jsimonetti/go-artnet/packet/code"
+)
+
+// sentPackets unmarshals the packets sent during a replay
+func sentPackets(t *testing.T, r *Replay) []packet.ArtNetPacket {
+	var packets []packet.ArtNetPacket
+	for _, s := range r.Sent() {
+		p, err := s.Packet()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		packets = append(packets, p)
+	}
+	return packets
+}
+
+func TestReplayController(t *testing.T) {
+	start := time.Unix(1760000000, 0)
+	c := NewController("controller", net.IP{2, 0, 0, 1}, NewDefaultLogger(), MaxFPS(40))
+	r, err := NewControllerReplay(c, start)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := sentPackets(t, r)[1].(*packet.ArtPollPacket); !ok {
+		t.Fatal("expected ArtPoll on start")
+	}
+
+	node := net.UDPAddr{IP: net.IP{2, 0, 0, 10}, Port: packet.ArtNetPort}
+	reply := marshal(t, ArtPollReplyFromConfig(NodeConfig{
+		Name: "node",
+		Type: code.StNode,
+		IP:   node.IP,
+		OutputPorts: []OutputPort{
+			{Address: Address{SubUni: 1}, Type: new(code.PortType).WithType("DMX512").WithOutput(true)},
+		},
+	}))
+
+	// unicast to another controller is not received
+	if err := r.Receive(start.Add(50*time.Millisecond), node, net.UDPAddr{IP: net.IP{2, 0, 0, 2}}, reply); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := 0, len(c.Nodes); want != got {
+		t.Fatalf("unexpected nodes:\n- want: %d\n-  got: %d", want, got)
+	}
+	if err := r.Receive(start.Add(100*time.Millisecond), node, defaultBroadcastAddr, reply); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := 1, len(c.Nodes); want != got {
+		t.Fatalf("unexpected nodes:\n- want: %d\n-  got: %d", want, got)
+	}
+
+	u, _ := c.Universe(Address{SubUni: 1})
+	u.SetChannel(1, 0xff)
+	r.Sent()
+	r.Advance(25 * time.Millisecond)
+	sent := r.Sent()
+	if len(sent) != 1 || !sent[0].Dst.IP.Equal(node.IP) {
+		t.Fatalf("expected ArtDmx to the node, got %v", sent)
+	}
+	p, _ := sent[0].Packet()
+	if dmx, ok := p.(*packet.ArtDMXPacket); !ok || dmx.Data[0] != 0xff {
+		t.Fatalf("unexpected packet %#v", p)
+	}
+	if want, got := start.Add(125*time.Millisecond), sent[0].Time; !want.Equal(got) {
+		t.Fatalf("unexpected time:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// the node is forgotten when it stops replying
+	r.Advance(10 * time.Second)
+	if want, got := 0, len(c.Nodes); want != got {
+		t.Fatalf("unexpected nodes:\n- want: %d\n-  got: %d", want, got)
+	}
+}
+
+func TestReplayNode(t *testing.T) {
+	start := time.Unix(1760000000, 0)
+	controller := net.UDPAddr{IP: net.IP{2, 0, 0, 1}, Port: packet.ArtNetPort}
+	n := NewNode("node", code.StNode, net.IP{2, 0, 0, 10}, NewDefaultLogger())
+	n.Config.OutputPorts = []OutputPort{
+		{Address: Address{SubUni: 1}, Type: new(code.PortType).WithType("DMX512").WithOutput(true)},
+	}
+	r, err := NewReplay(n, start)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := sentPackets(t, r)[0].(*packet.ArtPollReplyPacket); !ok {
+		t.Fatal("expected ArtPollReply on start")
+	}
+
+	dmx := func(value byte) []byte {
+		p := &packet.ArtDMXPacket{SubUni: 1}
+		p.Data[0] = value
+		return marshal(t, p)
+	}
+	output := func() byte {
+		_, data, _ := r.Output(0)
+		return data[0]
+	}
+	receive := func(ts time.Time, data []byte) {
+		t.Helper()
+		if err := r.Receive(ts, controller, defaultBroadcastAddr, data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	receive(start.Add(time.Second), dmx(10))
+	if want, got := byte(10), output(); want != got {
+		t.Fatalf("unexpected output:\n- want: %d\n-  got: %d", want, got)
+	}
+
+	receive(start.Add(2*time.Second), marshal(t, &packet.ArtPollPacket{}))
+	if packets := sentPackets(t, r); len(packets) != 1 {
+		t.Fatalf("expected a single ArtPollReply, got %v", packets)
+	}
+
+	// after an ArtSync the data is held until the next ArtSync, or until it times out
+	receive(start.Add(3*time.Second), marshal(t, &packet.ArtSyncPacket{}))
+	receive(start.Add(3*time.Second), dmx(20))
+	if want, got := byte(10), output(); want != got {
+		t.Fatalf("unexpected output before ArtSync:\n- want: %d\n-  got: %d", want, got)
+	}
+	r.Advance(3 * time.Second)
+	if want, got := byte(10), output(); want != got {
+		t.Fatalf("unexpected output before timeout:\n- want: %d\n-  got: %d", want, got)
+	}
+	r.Advance(time.Second)
+	if want, got := byte(20), output(); want != got {
+		t.Fatalf("unexpected output after timeout:\n- want: %d\n-  got: %d", want, got)
+	}
+
+	if err := r.Receive(r.Now(), controller, defaultBroadcastAddr, []byte("invalid")); err == nil {
+		t.Fatal("expected error for invalid packet")
+	}
+}
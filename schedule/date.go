@@ -0,0 +1,95 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+)
+
+// Date is a calendar date. A date without a year recurs every year.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// DateOf returns the date of t in its location
+func DateOf(t time.Time) Date {
+	y, m, d := t.Date()
+	return Date{Year: y, Month: m, Day: d}
+}
+
+// ParseDate parses a date in the form 2006-01-02, or 01-02 for a date that recurs
+// every year
+func ParseDate(s string) (Date, error) {
+	layout := "2006-01-02"
+	if len(s) == len("01-02") {
+		layout = "01-02"
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid date %q, expected 2006-01-02 or 01-02", s)
+	}
+
+	d := DateOf(t)
+	if layout == "01-02" {
+		d.Year = 0
+	}
+	return d, nil
+}
+
+// MustParseDate is like ParseDate but panics when the date is invalid
+func MustParseDate(s string) Date {
+	d, err := ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// String returns a string representation of Date
+func (d Date) String() string {
+	if d.Year == 0 {
+		return fmt.Sprintf("%02d-%02d", d.Month, d.Day)
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// IsZero returns whether the date is unset
+func (d Date) IsZero() bool {
+	return d.Month == 0
+}
+
+// matches returns whether the date falls on day
+func (d Date) matches(day Date) bool {
+	return d.Month == day.Month && d.Day == day.Day && (d.Year == 0 || d.Year == day.Year)
+}
+
+// compare compares the dates, ignoring the year when either has none
+func (d Date) compare(other Date) int {
+	a, b := int(d.Month)*100+d.Day, int(other.Month)*100+other.Day
+	if d.Year != 0 && other.Year != 0 {
+		a, b = a+d.Year*10000, b+other.Year*10000
+	}
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// inRange returns whether day falls in the range from until, both inclusive and
+// optional. A range of recurring dates may wrap around the end of the year.
+func inRange(day, from, until Date) bool {
+	if !from.IsZero() && !until.IsZero() && from.Year == 0 && from.compare(until) > 0 {
+		return day.compare(from) >= 0 || day.compare(until) <= 0
+	}
+	if !from.IsZero() && day.compare(from) < 0 {
+		return false
+	}
+	if !until.IsZero() && day.compare(until) > 0 {
+		return false
+	}
+	return true
+}
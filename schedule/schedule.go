@@ -0,0 +1,294 @@
+// Package schedule triggers actions on an artnet.Controller at times of day.
+//
+// Entries fire on cron expressions or at sunrise and sunset, computed from the
+// coordinates of the installation. They can be limited to a range of dates and
+// exclude holidays. When the scheduler starts, entries marked for catch-up restore
+// what should currently be active: of each group, the entry that would have fired
+// last runs right away. The time is taken from an injectable clock, so schedules
+// can be tested deterministically.
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+	"github.com/jsimonetti/go-artnet/cue"
+	"github.com/jsimonetti/go-artnet/snapshot"
+)
+
+// catchUpWindow is how far back catch-up looks for entries that should have fired,
+// and the longest gap between two checks before the scheduler catches up instead
+// of firing every missed entry
+const catchUpWindow = 8 * 24 * time.Hour
+
+// Action is run when an entry fires
+type Action func() error
+
+// Recall returns an action recalling a snapshot on the controller
+func Recall(c *artnet.Controller, s *snapshot.Snapshot, fade time.Duration) Action {
+	return func() error {
+		return s.Recall(c, fade)
+	}
+}
+
+// Go returns an action starting the next cue of a player
+func Go(p *cue.Player) Action {
+	return p.Go
+}
+
+// Goto returns an action starting a cue of a player
+func Goto(p *cue.Player, number float64) Action {
+	return func() error {
+		return p.Goto(number)
+	}
+}
+
+// Entry is an action scheduled at the times of a trigger
+type Entry struct {
+	Name    string
+	Trigger Trigger
+	Action  Action
+
+	// From and Until limit the entry to a range of dates, both inclusive
+	From  Date
+	Until Date
+	// Except holds dates on which the entry does not fire, such as holidays
+	Except []Date
+
+	// CatchUp runs the entry when the scheduler starts, if it is the entry of its
+	// Group that would have fired last
+	CatchUp bool
+	Group   string
+}
+
+// active returns whether the entry fires on the date of t
+func (e *Entry) active(t time.Time) bool {
+	d := DateOf(t)
+	for _, ex := range e.Except {
+		if ex.matches(d) {
+			return false
+		}
+	}
+	return inRange(d, e.From, e.Until)
+}
+
+// Option is a functional option handler for Scheduler
+type Option func(*Scheduler) error
+
+// Now sets the clock of the scheduler, it defaults to time.Now. The location of the
+// returned times is used for cron expressions and dates.
+func Now(now func() time.Time) Option {
+	return func(s *Scheduler) error {
+		s.now = now
+		return nil
+	}
+}
+
+// Coordinates sets the latitude and longitude of the installation in degrees, north
+// and east positive. They are required for sunrise and sunset triggers.
+func Coordinates(lat, lon float64) Option {
+	return func(s *Scheduler) error {
+		if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			return fmt.Errorf("invalid coordinates %v, %v", lat, lon)
+		}
+		s.lat, s.lon = lat, lon
+		s.located = true
+		return nil
+	}
+}
+
+// Scheduler runs scheduled entries on the frame clock of a controller
+type Scheduler struct {
+	c      *artnet.Controller
+	log    artnet.Logger
+	now    func() time.Time
+	remove func()
+
+	lat, lon float64
+	located  bool
+
+	lock    sync.Mutex
+	entries []*Entry
+	last    time.Time
+}
+
+// New returns a scheduler for the controller
+func New(c *artnet.Controller, log artnet.Logger, opts ...Option) (*Scheduler, error) {
+	s := &Scheduler{
+		c:   c,
+		log: log,
+		now: time.Now,
+	}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Add adds an entry to the schedule
+func (s *Scheduler) Add(e Entry) error {
+	if e.Trigger.IsZero() {
+		return fmt.Errorf("entry %q has no trigger", e.Name)
+	}
+	if e.Action == nil {
+		return fmt.Errorf("entry %q has no action", e.Name)
+	}
+	if e.Trigger.sun != 0 && !s.located {
+		return fmt.Errorf("entry %q: trigger %s requires coordinates", e.Name, e.Trigger)
+	}
+	if !e.From.IsZero() && !e.Until.IsZero() && (e.From.Year == 0) != (e.Until.Year == 0) {
+		return fmt.Errorf("entry %q: from %s and until %s must both have or both lack a year", e.Name, e.From, e.Until)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, other := range s.entries {
+		if other.Name == e.Name {
+			return fmt.Errorf("entry %q already scheduled", e.Name)
+		}
+	}
+	s.entries = append(s.entries, &e)
+	return nil
+}
+
+// Remove removes an entry from the schedule
+func (s *Scheduler) Remove(name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for i, e := range s.entries {
+		if e.Name == name {
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("entry %q not scheduled", name)
+}
+
+// Next returns the next time the entry fires, within the catch-up window
+func (s *Scheduler) Next(name string) (time.Time, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.now()
+	for _, e := range s.entries {
+		if e.Name == name {
+			times := s.fires(e, now, now.Add(catchUpWindow))
+			if len(times) > 0 {
+				return times[0], true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// Start starts the scheduler on the frame clock of the controller
+func (s *Scheduler) Start() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.remove == nil {
+		s.remove = s.c.OnFrame(func(time.Time) {
+			s.Tick()
+		})
+	}
+}
+
+// Stop stops the scheduler. Starting it again catches up.
+func (s *Scheduler) Stop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.remove != nil {
+		s.remove()
+		s.remove = nil
+	}
+	s.last = time.Time{}
+}
+
+// firing is an entry firing at a time
+type firing struct {
+	e     *Entry
+	t     time.Time
+	index int
+}
+
+// Tick runs the entries that fired since the last tick. The first tick, and a tick
+// after the clock jumped, catches up instead. Ticks less than a second after the
+// last one are ignored. Start calls Tick every frame; with an injected clock it can
+// be called directly.
+func (s *Scheduler) Tick() {
+	s.lock.Lock()
+	now := s.now()
+	if !s.last.IsZero() && now.Sub(s.last) < time.Second && now.After(s.last) {
+		s.lock.Unlock()
+		return
+	}
+
+	var run []firing
+	if s.last.IsZero() || now.Sub(s.last) > catchUpWindow || now.Before(s.last) {
+		run = s.catchUp(now)
+	} else {
+		for i, e := range s.entries {
+			for _, t := range s.fires(e, s.last, now) {
+				run = append(run, firing{e: e, t: t, index: i})
+			}
+		}
+	}
+	s.last = now
+	s.lock.Unlock()
+
+	sort.SliceStable(run, func(i, j int) bool {
+		if !run[i].t.Equal(run[j].t) {
+			return run[i].t.Before(run[j].t)
+		}
+		return run[i].index < run[j].index
+	})
+	for _, f := range run {
+		if err := f.e.Action(); err != nil {
+			s.log.With(artnet.Fields{"entry": f.e.Name, "err": err}).Error("error running scheduled entry")
+		}
+	}
+}
+
+// catchUp returns, of every group, the catch-up entry that fired last before now.
+// The caller must hold the lock.
+func (s *Scheduler) catchUp(now time.Time) []firing {
+	latest := make(map[string]firing)
+	for i, e := range s.entries {
+		if !e.CatchUp {
+			continue
+		}
+		times := s.fires(e, now.Add(-catchUpWindow), now)
+		if len(times) == 0 {
+			continue
+		}
+		t := times[len(times)-1]
+		if f, ok := latest[e.Group]; !ok || !t.Before(f.t) {
+			latest[e.Group] = firing{e: e, t: t, index: i}
+		}
+	}
+
+	run := make([]firing, 0, len(latest))
+	for _, f := range latest {
+		run = append(run, f)
+	}
+	return run
+}
+
+// fires returns the times the entry fires after from up to and including to
+func (s *Scheduler) fires(e *Entry, from, to time.Time) []time.Time {
+	var times []time.Time
+	for _, t := range e.Trigger.between(from, to, s.lat, s.lon) {
+		if e.active(t) {
+			times = append(times, t)
+		}
+	}
+	return times
+}
@@ -0,0 +1,185 @@
+package schedule
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+)
+
+func TestParseTrigger(t *testing.T) {
+	at := func(s string) time.Time {
+		tm, _ := time.Parse("2006-01-02 15:04", s)
+		return tm
+	}
+
+	tests := []struct {
+		spec  string
+		ok    bool
+		match []time.Time
+		miss  []time.Time
+	}{
+		{
+			spec:  "30 18 * * 1-5",
+			ok:    true,
+			match: []time.Time{at("2026-10-16 18:30")},
+			miss:  []time.Time{at("2026-10-17 18:30"), at("2026-10-16 18:31")},
+		},
+		{
+			spec:  "*/15 8-10 * * *",
+			ok:    true,
+			match: []time.Time{at("2026-10-17 08:45"), at("2026-10-17 10:00")},
+			miss:  []time.Time{at("2026-10-17 08:50"), at("2026-10-17 11:00")},
+		},
+		{
+			// day of month and day of week restricted, either one matches
+			spec:  "0 0 1 * 7",
+			ok:    true,
+			match: []time.Time{at("2026-10-01 00:00"), at("2026-10-18 00:00")},
+			miss:  []time.Time{at("2026-10-17 00:00")},
+		},
+		{spec: "@sunset-15m", ok: true},
+		{spec: "@sunrise+1h30m", ok: true},
+		{spec: "@noon"},
+		{spec: "@sunset+soon"},
+		{spec: "60 * * * *"},
+		{spec: "* * * *"},
+		{spec: "5-1 * * * *"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			tr, err := ParseTrigger(tt.spec)
+			if want, got := tt.ok, err == nil; want != got {
+				t.Fatalf("unexpected parse result:\n- want: %v\n-  got: %v (%v)", want, got, err)
+			}
+			for _, tm := range tt.match {
+				if !tr.matches(tm) {
+					t.Fatalf("expected %s to match %s", tt.spec, tm)
+				}
+			}
+			for _, tm := range tt.miss {
+				if tr.matches(tm) {
+					t.Fatalf("expected %s not to match %s", tt.spec, tm)
+				}
+			}
+		})
+	}
+}
+
+func TestSunTimes(t *testing.T) {
+	// Amsterdam at the summer solstice
+	rise, set, ok := sunTimes(time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), 52.37, 4.90)
+	if !ok {
+		t.Fatal("expected sunrise and sunset")
+	}
+	for _, tt := range []struct {
+		want, got time.Time
+	}{
+		{time.Date(2024, 6, 21, 3, 18, 0, 0, time.UTC), rise},
+		{time.Date(2024, 6, 21, 20, 6, 0, 0, time.UTC), set},
+	} {
+		if d := tt.got.Sub(tt.want); d < -2*time.Minute || d > 2*time.Minute {
+			t.Fatalf("unexpected sun time:\n- want: %v\n-  got: %v", tt.want, tt.got)
+		}
+	}
+
+	// Svalbard has midnight sun
+	if _, _, ok := sunTimes(time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), 78.2, 15.6); ok {
+		t.Fatal("expected no sunset during midnight sun")
+	}
+}
+
+func TestDateRange(t *testing.T) {
+	tests := []struct {
+		from, until string
+		day         string
+		in          bool
+	}{
+		{from: "2026-10-01", until: "2026-10-31", day: "2026-10-18", in: true},
+		{from: "2026-10-01", until: "2026-10-31", day: "2026-11-01"},
+		{from: "11-01", until: "02-28", day: "2026-12-24", in: true},
+		{from: "11-01", until: "02-28", day: "2027-01-15", in: true},
+		{from: "11-01", until: "02-28", day: "2026-10-18"},
+	}
+
+	for _, tt := range tests {
+		if want, got := tt.in, inRange(MustParseDate(tt.day), MustParseDate(tt.from), MustParseDate(tt.until)); want != got {
+			t.Fatalf("unexpected result for %s in %s..%s:\n- want: %v\n-  got: %v", tt.day, tt.from, tt.until, want, got)
+		}
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	c := artnet.NewController("test", net.IP{2, 0, 0, 1}, artnet.NewDefaultLogger())
+	loc := time.FixedZone("CEST", 2*60*60)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, loc)
+
+	s, err := New(c, artnet.NewDefaultLogger(), Coordinates(52.37, 4.90), Now(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var fired []string
+	add := func(name, trigger string, catchUp bool, except ...Date) {
+		err := s.Add(Entry{
+			Name:    name,
+			Trigger: MustParseTrigger(trigger),
+			Action: func() error {
+				fired = append(fired, name)
+				return nil
+			},
+			Except:  except,
+			CatchUp: catchUp,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	add("on", "@sunset", true)
+	add("off", "0 23 * * *", true)
+	add("morning", "0 7 * * *", true, MustParseDate("10-19"))
+	add("chime", "0 * * * *", false)
+
+	// at noon the morning entry fired last
+	s.Tick()
+	if want, got := []string{"morning"}, fired; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected catch-up:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	next, ok := s.Next("on")
+	if want := time.Date(2026, 10, 18, 18, 37, 0, 0, loc); !ok || next.Sub(want) < -5*time.Minute || next.Sub(want) > 5*time.Minute {
+		t.Fatalf("unexpected next sunset:\n- want: about %v\n-  got: %v", want, next)
+	}
+
+	// run the clock to the next afternoon, the morning entry is excluded on 10-19
+	fired = nil
+	for end := now.Add(26 * time.Hour); now.Before(end); now = now.Add(10 * time.Minute) {
+		s.Tick()
+	}
+	var chimes int
+	var got []string
+	for _, name := range fired {
+		if name == "chime" {
+			chimes++
+			continue
+		}
+		got = append(got, name)
+	}
+	if want := []string{"on", "off"}; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected entries fired:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := 25, chimes; want != got {
+		t.Fatalf("unexpected chimes:\n- want: %d\n-  got: %d", want, got)
+	}
+
+	if err := s.Add(Entry{Name: "on", Trigger: MustParseTrigger("@sunrise"), Action: func() error { return nil }}); err == nil {
+		t.Fatal("expected error for duplicate entry")
+	}
+	s, _ = New(c, artnet.NewDefaultLogger())
+	if err := s.Add(Entry{Name: "on", Trigger: MustParseTrigger("@sunrise"), Action: func() error { return nil }}); err == nil {
+		t.Fatal("expected error for sun trigger without coordinates")
+	}
+}
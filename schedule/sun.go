@@ -0,0 +1,60 @@
+package schedule
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// j2000 is the Julian date of 2000-01-01 12:00 UTC
+	j2000 = 2451545.0
+	// unixEpoch is the Julian date of 1970-01-01 00:00 UTC
+	unixEpoch = 2440587.5
+
+	// sunAltitude is the altitude of the center of the sun at sunrise and sunset in
+	// degrees, correcting for refraction and the radius of the sun
+	sunAltitude = -0.833
+	// obliquity is the tilt of the axis of the earth in degrees
+	obliquity = 23.4397
+)
+
+// sunTimes returns the sunrise and sunset at latitude and longitude (degrees, east
+// positive) on the calendar date of day. It returns false when the sun does not
+// rise or set that day, near the poles. The times are accurate to a few minutes.
+func sunTimes(day time.Time, lat, lon float64) (rise, set time.Time, ok bool) {
+	noon := time.Date(day.Year(), day.Month(), day.Day(), 12, 0, 0, 0, time.UTC)
+	n := math.Round(julian(noon) - j2000 + 0.0008)
+
+	// mean solar time at the longitude
+	meanTime := n - lon/360
+	anomaly := math.Mod(357.5291+0.98560028*meanTime, 360)
+	m := rad(anomaly)
+	center := 1.9148*math.Sin(m) + 0.0200*math.Sin(2*m) + 0.0003*math.Sin(3*m)
+	ecliptic := rad(math.Mod(anomaly+center+180+102.9372, 360))
+	transit := j2000 + meanTime + 0.0053*math.Sin(m) - 0.0069*math.Sin(2*ecliptic)
+
+	sinDecl := math.Sin(ecliptic) * math.Sin(rad(obliquity))
+	cosDecl := math.Cos(math.Asin(sinDecl))
+	cosHour := (math.Sin(rad(sunAltitude)) - math.Sin(rad(lat))*sinDecl) / (math.Cos(rad(lat)) * cosDecl)
+	if cosHour < -1 || cosHour > 1 {
+		return time.Time{}, time.Time{}, false
+	}
+
+	hour := math.Acos(cosHour) / (2 * math.Pi)
+	return fromJulian(transit - hour), fromJulian(transit + hour), true
+}
+
+// julian returns the Julian date of t
+func julian(t time.Time) float64 {
+	return float64(t.Unix())/86400 + unixEpoch
+}
+
+// fromJulian returns the time of a Julian date, rounded to the second
+func fromJulian(j float64) time.Time {
+	return time.Unix(int64(math.Round((j-unixEpoch)*86400)), 0).UTC()
+}
+
+// rad converts degrees to radians
+func rad(deg float64) float64 {
+	return deg * math.Pi / 180
+}
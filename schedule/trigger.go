@@ -0,0 +1,203 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sunEvent is the event of a sun trigger
+type sunEvent uint8
+
+const (
+	sunrise sunEvent = iota + 1
+	sunset
+)
+
+// Trigger defines when an entry fires. It is either a cron expression or a sun
+// event with an optional offset.
+type Trigger struct {
+	spec string
+
+	// minute, hour, dom, month and dow hold the allowed values of the cron fields
+	minute, hour, dom, month, dow uint64
+	domAll, dowAll                bool
+
+	sun    sunEvent
+	offset time.Duration
+}
+
+// cronFields holds the range of each field of a cron expression
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// ParseTrigger parses a trigger. It is either a cron expression with five fields,
+// "minute hour day-of-month month day-of-week", each holding "*", a value, a range
+// "1-5", a step "*/15" or a comma separated list of those, or a sun event
+// "@sunrise" or "@sunset" with an optional offset such as "@sunset-15m".
+func ParseTrigger(spec string) (Trigger, error) {
+	t := Trigger{spec: spec}
+
+	if strings.HasPrefix(spec, "@") {
+		event := spec[1:]
+		i := strings.IndexAny(event, "+-")
+		if i >= 0 {
+			offset, err := time.ParseDuration(event[i:])
+			if err != nil {
+				return Trigger{}, fmt.Errorf("invalid trigger %q: %v", spec, err)
+			}
+			t.offset = offset
+			event = event[:i]
+		}
+		switch event {
+		case "sunrise":
+			t.sun = sunrise
+		case "sunset":
+			t.sun = sunset
+		default:
+			return Trigger{}, fmt.Errorf("invalid trigger %q, expected @sunrise or @sunset", spec)
+		}
+		return t, nil
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return Trigger{}, fmt.Errorf("invalid trigger %q, expected 5 cron fields", spec)
+	}
+	values := []*uint64{&t.minute, &t.hour, &t.dom, &t.month, &t.dow}
+	for i, f := range fields {
+		v, err := parseField(f, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return Trigger{}, fmt.Errorf("invalid trigger %q: %s: %v", spec, cronFields[i].name, err)
+		}
+		*values[i] = v
+	}
+	// day of week 7 is sunday
+	if t.dow&(1<<7) != 0 {
+		t.dow |= 1
+	}
+	t.domAll = fields[2] == "*"
+	t.dowAll = fields[4] == "*"
+	return t, nil
+}
+
+// MustParseTrigger is like ParseTrigger but panics when the trigger is invalid
+func MustParseTrigger(spec string) Trigger {
+	t, err := ParseTrigger(spec)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// parseField parses a cron field into a bit set of the allowed values
+func parseField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:i]
+		}
+
+		from, to := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if from, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			to = from
+			if len(bounds) == 2 {
+				if to, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value %q", part)
+				}
+			} else if step > 1 {
+				to = max
+			}
+		}
+		if from < min || to > max || from > to {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := from; v <= to; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// String returns the trigger as it was parsed
+func (t Trigger) String() string {
+	return t.spec
+}
+
+// IsZero returns whether the trigger is unset
+func (t Trigger) IsZero() bool {
+	return t.spec == ""
+}
+
+// matches returns whether a cron trigger fires in the minute of t
+func (t Trigger) matches(tm time.Time) bool {
+	if t.minute&(1<<uint(tm.Minute())) == 0 ||
+		t.hour&(1<<uint(tm.Hour())) == 0 ||
+		t.month&(1<<uint(tm.Month())) == 0 {
+		return false
+	}
+
+	// like cron, when both days are restricted either one has to match
+	dom := t.dom&(1<<uint(tm.Day())) != 0
+	dow := t.dow&(1<<uint(tm.Weekday())) != 0
+	if !t.domAll && !t.dowAll {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// between returns the times the trigger fires after from up to and including to, in
+// the location of from. Sun triggers are computed at latitude and longitude.
+func (t Trigger) between(from, to time.Time, lat, lon float64) []time.Time {
+	var times []time.Time
+	loc := from.Location()
+
+	if t.sun == 0 {
+		m := from.Truncate(time.Minute).Add(time.Minute)
+		for ; !m.After(to); m = m.Add(time.Minute) {
+			if t.matches(m) {
+				times = append(times, m)
+			}
+		}
+		return times
+	}
+
+	// the offset and time zone may move the event to a neighbouring day
+	start := from.Add(-t.offset).In(loc).AddDate(0, 0, -1)
+	end := to.Add(-t.offset).In(loc).AddDate(0, 0, 1)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	for ; !day.After(end); day = day.AddDate(0, 0, 1) {
+		rise, set, ok := sunTimes(day, lat, lon)
+		if !ok {
+			continue
+		}
+		tm := rise
+		if t.sun == sunset {
+			tm = set
+		}
+		tm = tm.Add(t.offset).In(loc)
+		if tm.After(from) && !tm.After(to) {
+			times = append(times, tm)
+		}
+	}
+	return times
+}
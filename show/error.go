@@ -0,0 +1,195 @@
+package show
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Error is an error at a location in a show file
+type Error struct {
+	File   string
+	Line   int
+	Column int
+
+	// Path is the path of the value in the file, such as fixtures[2].type
+	Path string
+	Err  error
+}
+
+// Error implements error
+func (e *Error) Error() string {
+	var b strings.Builder
+	if e.File != "" {
+		b.WriteString(e.File + ":")
+	}
+	fmt.Fprintf(&b, "%d:%d: ", e.Line, e.Column)
+	if e.Path != "" {
+		b.WriteString(e.Path + ": ")
+	}
+	b.WriteString(e.Err.Error())
+	return b.String()
+}
+
+// source locates values in the data of a show file
+type source struct {
+	file string
+	data []byte
+	pos  map[string]int
+}
+
+// newSource scans valid JSON data for the offsets of all values
+func newSource(file string, data []byte) *source {
+	s := &source{file: file, data: data, pos: make(map[string]int)}
+	sc := &scanner{data: data, pos: s.pos}
+	sc.value("")
+	return s
+}
+
+// errorf returns an error at the value at path. When the value is absent from the
+// file, the error points at the closest value containing it.
+func (s *source) errorf(path, format string, args ...interface{}) error {
+	return s.errorAt(path, fmt.Errorf(format, args...))
+}
+
+// errorAt returns err at the value at path
+func (s *source) errorAt(path string, err error) error {
+	p := path
+	off, ok := s.pos[p]
+	for !ok && p != "" {
+		i := strings.LastIndexAny(p, ".[")
+		if i < 0 {
+			i = 0
+		}
+		p = p[:i]
+		off, ok = s.pos[p]
+	}
+	return s.errorOffset(path, off, err)
+}
+
+// errorOffset returns err at a byte offset
+func (s *source) errorOffset(path string, off int, err error) error {
+	if off > len(s.data) {
+		off = len(s.data)
+	}
+	line := bytes.Count(s.data[:off], []byte("\n")) + 1
+	col := off - bytes.LastIndexByte(s.data[:off], '\n')
+	return &Error{File: s.file, Line: line, Column: col, Path: path, Err: err}
+}
+
+// decodeError converts an error of the JSON decoder into an error at its location
+func (s *source) decodeError(err error) error {
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		return s.errorOffset("", int(e.Offset), err)
+	case *json.UnmarshalTypeError:
+		// the offset is the end of the value, point at its start when it is known
+		off, ok := s.pos[e.Field]
+		if !ok {
+			off = int(e.Offset)
+		}
+		return s.errorOffset(e.Field, off, fmt.Errorf("cannot use %s as %s", e.Value, e.Type))
+	}
+
+	// the decoder does not report where unknown fields are, find the first one
+	if name := strings.TrimPrefix(err.Error(), "json: unknown field "); name != err.Error() {
+		name, _ = strconv.Unquote(name)
+		path, off := "", len(s.data)
+		for p, o := range s.pos {
+			if (p == name || strings.HasSuffix(p, "."+name)) && o < off {
+				path, off = p, o
+			}
+		}
+		if path != "" {
+			return s.errorOffset(path, off, fmt.Errorf("unknown field %q", name))
+		}
+	}
+	return &Error{File: s.file, Line: 1, Column: 1, Err: err}
+}
+
+// scanner records the offset of every value of valid JSON data by its path
+type scanner struct {
+	data []byte
+	i    int
+	pos  map[string]int
+}
+
+func (s *scanner) space() {
+	for s.i < len(s.data) && strings.IndexByte(" \t\r\n", s.data[s.i]) >= 0 {
+		s.i++
+	}
+}
+
+func (s *scanner) value(path string) {
+	s.space()
+	if s.i >= len(s.data) {
+		return
+	}
+	s.pos[path] = s.i
+
+	switch s.data[s.i] {
+	case '{':
+		s.i++
+		for {
+			s.space()
+			if s.i >= len(s.data) || s.data[s.i] == '}' {
+				s.i++
+				return
+			}
+			if s.data[s.i] == ',' {
+				s.i++
+				continue
+			}
+			key := s.str()
+			s.space()
+			s.i++ // colon
+			if path != "" {
+				key = path + "." + key
+			}
+			s.value(key)
+		}
+	case '[':
+		s.i++
+		for n := 0; ; n++ {
+			s.space()
+			if s.i >= len(s.data) || s.data[s.i] == ']' {
+				s.i++
+				return
+			}
+			if s.data[s.i] == ',' {
+				s.i++
+			}
+			s.value(path + "[" + strconv.Itoa(n) + "]")
+		}
+	case '"':
+		s.str()
+	default:
+		start := s.i
+		for s.i < len(s.data) && strings.IndexByte(",]} \t\r\n", s.data[s.i]) < 0 {
+			s.i++
+		}
+		if s.i == start {
+			// invalid data, skip it
+			s.i++
+		}
+	}
+}
+
+// str scans a string and returns its value
+func (s *scanner) str() string {
+	start := s.i
+	for s.i++; s.i < len(s.data) && s.data[s.i] != '"'; s.i++ {
+		if s.data[s.i] == '\\' {
+			s.i++
+		}
+	}
+	s.i++
+
+	var v string
+	if s.i <= len(s.data) {
+		json.Unmarshal(s.data[start:s.i], &v)
+	}
+	return v
+}
@@ -0,0 +1,99 @@
+package show
+
+import (
+	"encoding/json"
+)
+
+// jsonShow is the saved form of a show
+type jsonShow struct {
+	Controller   jsonController      `json:"controller"`
+	Universes    []jsonUniverse      `json:"universes,omitempty"`
+	FixtureTypes []jsonType          `json:"fixtureTypes,omitempty"`
+	Fixtures     []jsonFixture       `json:"fixtures,omitempty"`
+	Groups       map[string][]string `json:"groups,omitempty"`
+	Snapshots    []json.RawMessage   `json:"snapshots,omitempty"`
+	CueLists     []jsonCueList       `json:"cueLists,omitempty"`
+}
+
+type jsonController struct {
+	Name               string `json:"name"`
+	IP                 string `json:"ip"`
+	Listen             string `json:"listen,omitempty"`
+	Broadcast          string `json:"broadcast,omitempty"`
+	FPS                int    `json:"fps,omitempty"`
+	SyncOutput         bool   `json:"syncOutput,omitempty"`
+	MatchMAC           bool   `json:"matchMAC,omitempty"`
+	BroadcastThreshold int    `json:"broadcastThreshold,omitempty"`
+	BroadcastUnknown   bool   `json:"broadcastUnknown,omitempty"`
+	KeepAlive          string `json:"keepAlive,omitempty"`
+}
+
+type jsonUniverse struct {
+	Address   string `json:"address"`
+	Intensity []int  `json:"intensity,omitempty"`
+}
+
+// jsonType is a fixture type, read from the mode of a GDTF or OFL file or defined
+// inline. Fixtures refer to it by name.
+type jsonType struct {
+	Name string `json:"name"`
+	GDTF string `json:"gdtf,omitempty"`
+	OFL  string `json:"ofl,omitempty"`
+	Mode string `json:"mode,omitempty"`
+
+	Footprint int           `json:"footprint,omitempty"`
+	Channels  []jsonChannel `json:"channels,omitempty"`
+}
+
+type jsonChannel struct {
+	Attribute    string           `json:"attribute"`
+	Offsets      []int            `json:"offsets"`
+	Default      uint32           `json:"default,omitempty"`
+	Capabilities []jsonCapability `json:"capabilities,omitempty"`
+}
+
+type jsonCapability struct {
+	Name         string  `json:"name"`
+	From         uint32  `json:"from"`
+	To           uint32  `json:"to"`
+	PhysicalFrom float64 `json:"physicalFrom,omitempty"`
+	PhysicalTo   float64 `json:"physicalTo,omitempty"`
+	Unit         string  `json:"unit,omitempty"`
+}
+
+type jsonFixture struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Universe string `json:"universe"`
+	Address  int    `json:"address"`
+}
+
+type jsonCueList struct {
+	Name     string    `json:"name"`
+	Tracking bool      `json:"tracking,omitempty"`
+	Cues     []jsonCue `json:"cues"`
+}
+
+type jsonCue struct {
+	Number     float64            `json:"number"`
+	Name       string             `json:"name,omitempty"`
+	FadeIn     string             `json:"fadeIn,omitempty"`
+	FadeOut    string             `json:"fadeOut,omitempty"`
+	Delay      string             `json:"delay,omitempty"`
+	Follow     string             `json:"follow,omitempty"`
+	AutoFollow bool               `json:"autoFollow,omitempty"`
+	Channels   []jsonChannelValue `json:"channels,omitempty"`
+	Fixtures   []jsonFixtureValue `json:"fixtures,omitempty"`
+}
+
+type jsonChannelValue struct {
+	Universe string `json:"universe"`
+	Channel  int    `json:"channel"`
+	Value    uint8  `json:"value"`
+}
+
+type jsonFixtureValue struct {
+	Fixture   string `json:"fixture"`
+	Attribute string `json:"attribute"`
+	Value     uint32 `json:"value"`
+}
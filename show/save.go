@@ -0,0 +1,188 @@
+package show
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/jsimonetti/go-artnet/cue"
+	"github.com/jsimonetti/go-artnet/fixture"
+	"github.com/jsimonetti/go-artnet/internal/atomicfile"
+)
+
+// Encode writes the current state of the show as indented JSON. Fixture types read
+// from files are written as references to those files, with their paths as they
+// were loaded, other fixture types inline.
+func (s *Show) Encode(w io.Writer) error {
+	js := jsonShow{
+		Controller: s.controller,
+		Groups:     s.Groups,
+	}
+
+	for _, address := range s.Controller.Universes() {
+		u, err := s.Controller.Universe(address)
+		if err != nil {
+			return err
+		}
+		js.Universes = append(js.Universes, jsonUniverse{
+			Address:   address.String(),
+			Intensity: u.IntensityChannels(),
+		})
+	}
+
+	// name the types of fixtures patched after loading
+	names := make(map[*fixture.Type]string, len(s.Types))
+	for name, t := range s.Types {
+		names[t] = name
+	}
+	types := make(map[string]*fixture.Type, len(s.Types))
+	for name, t := range s.Types {
+		types[name] = t
+	}
+	fixtures := s.Patch.Fixtures()
+	for _, f := range fixtures {
+		if _, ok := names[f.Type]; ok {
+			continue
+		}
+		name := f.Type.Name
+		for i := 2; types[name] != nil; i++ {
+			name = fmt.Sprintf("%s %d", f.Type.Name, i)
+		}
+		names[f.Type] = name
+		types[name] = f.Type
+	}
+
+	typeNames := make([]string, 0, len(types))
+	for name := range types {
+		typeNames = append(typeNames, name)
+	}
+	sort.Strings(typeNames)
+	for _, name := range typeNames {
+		if jt, ok := s.types[name]; ok && s.Types[name] == types[name] {
+			js.FixtureTypes = append(js.FixtureTypes, jt)
+			continue
+		}
+		js.FixtureTypes = append(js.FixtureTypes, typeJSON(name, types[name]))
+	}
+
+	for _, f := range fixtures {
+		js.Fixtures = append(js.Fixtures, jsonFixture{
+			Name:     f.Name,
+			Type:     names[f.Type],
+			Universe: f.Universe.String(),
+			Address:  f.Address,
+		})
+	}
+
+	snapshotNames := make([]string, 0, len(s.Snapshots))
+	for name := range s.Snapshots {
+		snapshotNames = append(snapshotNames, name)
+	}
+	sort.Strings(snapshotNames)
+	for _, name := range snapshotNames {
+		raw, err := json.Marshal(s.Snapshots[name])
+		if err != nil {
+			return err
+		}
+		js.Snapshots = append(js.Snapshots, raw)
+	}
+
+	listNames := make([]string, 0, len(s.CueLists))
+	for name := range s.CueLists {
+		listNames = append(listNames, name)
+	}
+	sort.Strings(listNames)
+	for _, name := range listNames {
+		js.CueLists = append(js.CueLists, cueListJSON(s.CueLists[name]))
+	}
+
+	b, err := json.MarshalIndent(js, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// Save writes the current state of the show to a file. The file is replaced
+// atomically, so a crash while saving leaves the previous show file intact.
+func (s *Show) Save(path string) error {
+	if err := atomicfile.Write(path, s.Encode); err != nil {
+		return fmt.Errorf("failed to save show: %v", err)
+	}
+	return nil
+}
+
+// typeJSON returns an inline definition of a fixture type
+func typeJSON(name string, t *fixture.Type) jsonType {
+	jt := jsonType{
+		Name:      name,
+		Mode:      t.Mode,
+		Footprint: t.Footprint,
+	}
+	for _, c := range t.Channels {
+		jc := jsonChannel{
+			Attribute: c.Attribute,
+			Offsets:   c.Offsets,
+			Default:   c.Default,
+		}
+		for _, capability := range c.Capabilities {
+			jc.Capabilities = append(jc.Capabilities, jsonCapability(capability))
+		}
+		jt.Channels = append(jt.Channels, jc)
+	}
+	return jt
+}
+
+// cueListJSON returns the saved form of a cue list
+func cueListJSON(list *cue.List) jsonCueList {
+	jl := jsonCueList{
+		Name:     list.Name,
+		Tracking: list.Tracking,
+		Cues:     []jsonCue{},
+	}
+	for _, c := range list.Cues {
+		jc := jsonCue{
+			Number:     c.Number,
+			Name:       c.Name,
+			FadeIn:     durationJSON(c.FadeIn),
+			FadeOut:    durationJSON(c.FadeOut),
+			Delay:      durationJSON(c.Delay),
+			Follow:     durationJSON(c.Follow),
+			AutoFollow: c.AutoFollow,
+		}
+		channels := make([]cue.Channel, 0, len(c.Channels))
+		for ch := range c.Channels {
+			channels = append(channels, ch)
+		}
+		sort.Slice(channels, func(i, j int) bool {
+			a, b := channels[i], channels[j]
+			if a.Universe != b.Universe {
+				return a.Universe.Integer() < b.Universe.Integer()
+			}
+			return a.Channel < b.Channel
+		})
+		for _, ch := range channels {
+			jc.Channels = append(jc.Channels, jsonChannelValue{
+				Universe: ch.Universe.String(),
+				Channel:  ch.Channel,
+				Value:    c.Channels[ch],
+			})
+		}
+		for _, fv := range c.Fixtures {
+			jc.Fixtures = append(jc.Fixtures, jsonFixtureValue(fv))
+		}
+		jl.Cues = append(jl.Cues, jc)
+	}
+	return jl
+}
+
+// durationJSON returns the saved form of a duration, empty when it is zero
+func durationJSON(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
@@ -0,0 +1,471 @@
+// Package show loads and saves complete controller setups from a single show file.
+//
+// A show file is a JSON document describing the controller settings, the universes,
+// fixture types and their patch, fixture groups, snapshots and cue lists:
+//
+//	{
+//	  "controller": {"name": "foh", "ip": "2.0.0.1", "fps": 44, "keepAlive": "900ms"},
+//	  "universes": [{"address": "0:0.1", "intensity": [1, 2, 3]}],
+//	  "fixtureTypes": [
+//	    {"name": "spot", "gdtf": "types/spot.gdtf", "mode": "Standard"},
+//	    {"name": "bar", "ofl": "types/led-bar.json", "mode": "8ch"},
+//	    {"name": "dimmer", "footprint": 1, "channels": [{"attribute": "intensity", "offsets": [0]}]}
+//	  ],
+//	  "fixtures": [{"name": "spot-1", "type": "spot", "universe": "0:0.1", "address": 1}],
+//	  "groups": {"front": ["spot-1"]},
+//	  "snapshots": [],
+//	  "cueLists": [{"name": "main", "cues": [{"number": 1, "fadeIn": "3s", "fixtures": [
+//	    {"fixture": "spot-1", "attribute": "intensity", "value": 255}
+//	  ]}]}]
+//	}
+//
+// Snapshots use the format of package snapshot. Durations are strings such as
+// "1.5s", paths of fixture type files are relative to the show file. Errors in a
+// show file are reported with their line and column. Show files are JSON only, YAML
+// would add a dependency to the module.
+package show
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+	"github.com/jsimonetti/go-artnet/cue"
+	"github.com/jsimonetti/go-artnet/fixture"
+	"github.com/jsimonetti/go-artnet/fixture/gdtf"
+	"github.com/jsimonetti/go-artnet/fixture/ofl"
+	"github.com/jsimonetti/go-artnet/snapshot"
+)
+
+// Show is a controller with its patch, groups, snapshots and cue lists
+type Show struct {
+	Controller *artnet.Controller
+	Patch      *fixture.Patch
+
+	// Types holds the fixture types by name
+	Types map[string]*fixture.Type
+	// Groups holds the names of the fixtures of each group
+	Groups    map[string][]string
+	Snapshots map[string]*snapshot.Snapshot
+	CueLists  map[string]*cue.List
+	// Players holds a stopped player for every cue list
+	Players map[string]*cue.Player
+
+	// controller holds the controller settings, types the files types were read from
+	controller jsonController
+	types      map[string]jsonType
+}
+
+// Load reads a show file and builds its controller, ready to be started
+func Load(path string, log artnet.Logger) (*Show, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open show: %v", err)
+	}
+	return decode(path, filepath.Dir(path), data, log)
+}
+
+// Decode reads a show and builds its controller. Paths of fixture type files are
+// relative to the working directory.
+func Decode(r io.Reader, log artnet.Logger) (*Show, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read show: %v", err)
+	}
+	return decode("", ".", data, log)
+}
+
+// loader builds a show from a show file
+type loader struct {
+	*source
+	dir string
+	log artnet.Logger
+	s   *Show
+}
+
+func decode(file, dir string, data []byte, log artnet.Logger) (*Show, error) {
+	src := newSource(file, data)
+
+	var js jsonShow
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&js); err != nil {
+		return nil, src.decodeError(err)
+	}
+
+	l := &loader{
+		source: src,
+		dir:    dir,
+		log:    log,
+		s: &Show{
+			Types:      make(map[string]*fixture.Type),
+			Groups:     make(map[string][]string),
+			Snapshots:  make(map[string]*snapshot.Snapshot),
+			CueLists:   make(map[string]*cue.List),
+			Players:    make(map[string]*cue.Player),
+			controller: js.Controller,
+			types:      make(map[string]jsonType),
+		},
+	}
+
+	for _, step := range []func(*jsonShow) error{
+		l.controller,
+		l.universes,
+		l.fixtureTypes,
+		l.fixtures,
+		l.groups,
+		l.snapshots,
+		l.cueLists,
+	} {
+		if err := step(&js); err != nil {
+			return nil, err
+		}
+	}
+	return l.s, nil
+}
+
+// controller creates the controller
+func (l *loader) controller(js *jsonShow) error {
+	jc := js.Controller
+
+	ip := net.ParseIP(jc.IP)
+	if ip == nil {
+		return l.errorf("controller.ip", "invalid IP address %q", jc.IP)
+	}
+	name := jc.Name
+	if name == "" {
+		name = "go-artnet"
+	}
+	c := artnet.NewController(name, ip, l.log)
+
+	var opts []artnet.Option
+	paths := []string{}
+	if jc.Listen != "" {
+		addr, err := net.ResolveUDPAddr("udp", jc.Listen)
+		if err != nil {
+			return l.errorf("controller.listen", "invalid listen address: %v", err)
+		}
+		opts, paths = append(opts, artnet.ListenAddress(*addr)), append(paths, "controller.listen")
+	}
+	if jc.Broadcast != "" {
+		addr, err := net.ResolveUDPAddr("udp", jc.Broadcast)
+		if err != nil {
+			return l.errorf("controller.broadcast", "invalid broadcast address: %v", err)
+		}
+		opts, paths = append(opts, artnet.BroadcastAddr(*addr)), append(paths, "controller.broadcast")
+	}
+	if jc.FPS != 0 {
+		if jc.FPS < 1 {
+			return l.errorf("controller.fps", "fps %d must be positive", jc.FPS)
+		}
+		opts, paths = append(opts, artnet.MaxFPS(jc.FPS)), append(paths, "controller.fps")
+	}
+	if jc.BroadcastThreshold != 0 {
+		opts, paths = append(opts, artnet.BroadcastThreshold(jc.BroadcastThreshold)), append(paths, "controller.broadcastThreshold")
+	}
+	if jc.KeepAlive != "" {
+		d, err := l.duration("controller.keepAlive", jc.KeepAlive)
+		if err != nil {
+			return err
+		}
+		opts, paths = append(opts, artnet.KeepAlive(d)), append(paths, "controller.keepAlive")
+	}
+	opts = append(opts, artnet.SyncOutput(jc.SyncOutput), artnet.MatchMAC(jc.MatchMAC), artnet.BroadcastUnknown(jc.BroadcastUnknown))
+	paths = append(paths, "controller.syncOutput", "controller.matchMAC", "controller.broadcastUnknown")
+
+	for i, opt := range opts {
+		if err := c.SetOption(opt); err != nil {
+			return l.errorAt(paths[i], err)
+		}
+	}
+
+	l.s.Controller = c
+	l.s.Patch = fixture.NewPatch(c)
+	return nil
+}
+
+// universes creates the universes and sets their intensity channels
+func (l *loader) universes(js *jsonShow) error {
+	seen := make(map[artnet.Address]bool)
+	for i, ju := range js.Universes {
+		path := fmt.Sprintf("universes[%d]", i)
+		address, u, err := l.universe(path+".address", ju.Address)
+		if err != nil {
+			return err
+		}
+		if seen[address] {
+			return l.errorf(path+".address", "universe %s defined more than once", address)
+		}
+		seen[address] = true
+
+		if ju.Intensity != nil {
+			if err := u.SetIntensityChannels(ju.Intensity...); err != nil {
+				return l.errorAt(path+".intensity", err)
+			}
+		}
+	}
+	return nil
+}
+
+// universe parses an address and returns the universe of the controller
+func (l *loader) universe(path, s string) (artnet.Address, *artnet.Universe, error) {
+	address, err := artnet.ParseAddress(s)
+	if err != nil {
+		return artnet.Address{}, nil, l.errorAt(path, err)
+	}
+	u, err := l.s.Controller.Universe(address)
+	if err != nil {
+		return artnet.Address{}, nil, l.errorAt(path, err)
+	}
+	return address, u, nil
+}
+
+// fixtureTypes reads the fixture types
+func (l *loader) fixtureTypes(js *jsonShow) error {
+	for i, jt := range js.FixtureTypes {
+		path := fmt.Sprintf("fixtureTypes[%d]", i)
+		if jt.Name == "" {
+			return l.errorf(path, "fixture type without name")
+		}
+		if _, ok := l.s.Types[jt.Name]; ok {
+			return l.errorf(path+".name", "fixture type %q defined more than once", jt.Name)
+		}
+
+		var t *fixture.Type
+		var err error
+		switch {
+		case jt.GDTF != "" && jt.OFL != "":
+			return l.errorf(path, "fixture type %q: gdtf and ofl are mutually exclusive", jt.Name)
+		case jt.GDTF != "":
+			var ft *gdtf.FixtureType
+			if ft, err = gdtf.Open(l.path(jt.GDTF)); err != nil {
+				return l.errorAt(path+".gdtf", err)
+			}
+			if t, err = ft.Mode(jt.Mode); err != nil {
+				return l.errorAt(path+".mode", err)
+			}
+			l.s.types[jt.Name] = jsonType{Name: jt.Name, GDTF: jt.GDTF, Mode: jt.Mode}
+		case jt.OFL != "":
+			var f *ofl.Fixture
+			if f, err = ofl.Open(l.path(jt.OFL)); err != nil {
+				return l.errorAt(path+".ofl", err)
+			}
+			if t, err = f.Mode(jt.Mode); err != nil {
+				return l.errorAt(path+".mode", err)
+			}
+			l.s.types[jt.Name] = jsonType{Name: jt.Name, OFL: jt.OFL, Mode: jt.Mode}
+		default:
+			t = inlineType(jt)
+			if err := t.Validate(); err != nil {
+				return l.errorAt(path, err)
+			}
+		}
+		l.s.Types[jt.Name] = t
+	}
+	return nil
+}
+
+// path returns a path relative to the show file
+func (l *loader) path(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(l.dir, p)
+}
+
+// fixtures patches the fixtures
+func (l *loader) fixtures(js *jsonShow) error {
+	for i, jf := range js.Fixtures {
+		path := fmt.Sprintf("fixtures[%d]", i)
+		t, ok := l.s.Types[jf.Type]
+		if !ok {
+			return l.errorf(path+".type", "unknown fixture type %q", jf.Type)
+		}
+		address, _, err := l.universe(path+".universe", jf.Universe)
+		if err != nil {
+			return err
+		}
+		if _, err := l.s.Patch.Add(jf.Name, t, address, jf.Address); err != nil {
+			return l.errorAt(path, err)
+		}
+	}
+	return nil
+}
+
+// groups checks the fixtures of the groups
+func (l *loader) groups(js *jsonShow) error {
+	names := make([]string, 0, len(js.Groups))
+	for name := range js.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fixtures := js.Groups[name]
+		for i, f := range fixtures {
+			if _, ok := l.s.Patch.Fixture(f); !ok {
+				return l.errorf(fmt.Sprintf("groups.%s[%d]", name, i), "group %q: fixture %q not patched", name, f)
+			}
+		}
+		l.s.Groups[name] = fixtures
+	}
+	return nil
+}
+
+// snapshots decodes the snapshots
+func (l *loader) snapshots(js *jsonShow) error {
+	for i, raw := range js.Snapshots {
+		path := fmt.Sprintf("snapshots[%d]", i)
+		s := &snapshot.Snapshot{}
+		if err := json.Unmarshal(raw, s); err != nil {
+			return l.errorAt(path, err)
+		}
+		if _, ok := l.s.Snapshots[s.Name]; ok {
+			return l.errorf(path+".name", "snapshot %q defined more than once", s.Name)
+		}
+		l.s.Snapshots[s.Name] = s
+	}
+	return nil
+}
+
+// cueLists builds the cue lists and their players
+func (l *loader) cueLists(js *jsonShow) error {
+	for i, jl := range js.CueLists {
+		path := fmt.Sprintf("cueLists[%d]", i)
+		if _, ok := l.s.CueLists[jl.Name]; ok {
+			return l.errorf(path+".name", "cue list %q defined more than once", jl.Name)
+		}
+
+		list := &cue.List{Name: jl.Name, Tracking: jl.Tracking}
+		for j, jc := range jl.Cues {
+			c, err := l.cue(fmt.Sprintf("%s.cues[%d]", path, j), jc)
+			if err != nil {
+				return err
+			}
+			list.Cues = append(list.Cues, c)
+		}
+
+		p, err := cue.NewPlayer(l.s.Controller, l.s.Patch, list)
+		if err != nil {
+			return l.errorAt(path, err)
+		}
+		l.s.CueLists[jl.Name] = list
+		l.s.Players[jl.Name] = p
+	}
+	return nil
+}
+
+// cue builds a cue
+func (l *loader) cue(path string, jc jsonCue) (*cue.Cue, error) {
+	c := &cue.Cue{
+		Number:     jc.Number,
+		Name:       jc.Name,
+		Channels:   make(map[cue.Channel]uint8, len(jc.Channels)),
+		AutoFollow: jc.AutoFollow,
+	}
+
+	for _, d := range []struct {
+		name string
+		s    string
+		d    *time.Duration
+	}{
+		{"fadeIn", jc.FadeIn, &c.FadeIn},
+		{"fadeOut", jc.FadeOut, &c.FadeOut},
+		{"delay", jc.Delay, &c.Delay},
+		{"follow", jc.Follow, &c.Follow},
+	} {
+		if d.s == "" {
+			continue
+		}
+		v, err := l.duration(path+"."+d.name, d.s)
+		if err != nil {
+			return nil, err
+		}
+		*d.d = v
+	}
+
+	for i, jv := range jc.Channels {
+		vpath := fmt.Sprintf("%s.channels[%d]", path, i)
+		address, err := artnet.ParseAddress(jv.Universe)
+		if err != nil {
+			return nil, l.errorAt(vpath+".universe", err)
+		}
+		if jv.Channel < 1 || jv.Channel > 512 {
+			return nil, l.errorf(vpath+".channel", "channel %d out of range 1-512", jv.Channel)
+		}
+		c.Channels[cue.Channel{Universe: address, Channel: jv.Channel}] = jv.Value
+	}
+
+	for i, jv := range jc.Fixtures {
+		vpath := fmt.Sprintf("%s.fixtures[%d]", path, i)
+		f, ok := l.s.Patch.Fixture(jv.Fixture)
+		if !ok {
+			return nil, l.errorf(vpath+".fixture", "fixture %q not patched", jv.Fixture)
+		}
+		if _, err := f.ChannelValues(jv.Attribute, jv.Value); err != nil {
+			return nil, l.errorAt(vpath, err)
+		}
+		c.Fixtures = append(c.Fixtures, cue.FixtureValue{
+			Fixture:   jv.Fixture,
+			Attribute: jv.Attribute,
+			Value:     jv.Value,
+		})
+	}
+	return c, nil
+}
+
+// duration parses a duration
+func (l *loader) duration(path, s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, l.errorf(path, "invalid duration %q", s)
+	}
+	if d < 0 {
+		return 0, l.errorf(path, "negative duration %q", s)
+	}
+	return d, nil
+}
+
+// inlineType converts an inline fixture type definition
+func inlineType(jt jsonType) *fixture.Type {
+	t := &fixture.Type{
+		Name:      jt.Name,
+		Mode:      jt.Mode,
+		Footprint: jt.Footprint,
+	}
+	for _, jc := range jt.Channels {
+		c := fixture.Channel{
+			Attribute: jc.Attribute,
+			Offsets:   jc.Offsets,
+			Default:   jc.Default,
+		}
+		for _, jcap := range jc.Capabilities {
+			c.Capabilities = append(c.Capabilities, fixture.Capability(jcap))
+		}
+		t.Channels = append(t.Channels, c)
+	}
+	return t
+}
+
+// Group returns the fixtures of a group
+func (s *Show) Group(name string) ([]*fixture.Fixture, bool) {
+	names, ok := s.Groups[name]
+	if !ok {
+		return nil, false
+	}
+
+	fixtures := make([]*fixture.Fixture, 0, len(names))
+	for _, n := range names {
+		if f, ok := s.Patch.Fixture(n); ok {
+			fixtures = append(fixtures, f)
+		}
+	}
+	return fixtures, true
+}
@@ -0,0 +1,175 @@
+package show
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+	"github.com/jsimonetti/go-artnet/snapshot"
+)
+
+func TestLoad(t *testing.T) {
+	s, err := Load("testdata/show.json", artnet.NewDefaultLogger())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want, got := 3, len(s.Patch.Fixtures()); want != got {
+		t.Fatalf("unexpected fixtures:\n- want: %d\n-  got: %d", want, got)
+	}
+	washes, ok := s.Group("washes")
+	if !ok || len(washes) != 2 || washes[1].Address != 7 {
+		t.Fatalf("unexpected group washes: %v", washes)
+	}
+
+	u, _ := s.Controller.Universe(artnet.Address{SubUni: 2})
+	if want, got := []int{1}, u.IntensityChannels(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected intensity channels:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	list := s.CueLists["main"]
+	if list == nil || len(list.Cues) != 2 || s.Players["main"] == nil {
+		t.Fatal("expected cue list main with a player")
+	}
+	if want, got := 1500*time.Millisecond, list.Cues[1].FadeIn; want != got {
+		t.Fatalf("unexpected fade in:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestSaveRoundTrip(t *testing.T) {
+	s, err := Load("testdata/show.json", artnet.NewDefaultLogger())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// add state after loading
+	u, _ := s.Controller.Universe(artnet.Address{SubUni: 1})
+	u.SetChannel(3, 0x80)
+	snap, _ := snapshot.Capture(s.Controller, "look")
+	s.Snapshots[snap.Name] = snap
+
+	var first bytes.Buffer
+	if err := s.Encode(&first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := Decode(bytes.NewReader(first.Bytes()), artnet.NewDefaultLogger())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var second bytes.Buffer
+	if err := loaded.Encode(&second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := first.String(), second.String(); want != got {
+		t.Fatalf("unexpected show after round trip:\n- want: %s\n-  got: %s", want, got)
+	}
+
+	if want, got := snap.Universes, loaded.Snapshots["look"].Universes; !reflect.DeepEqual(want, got) {
+		t.Fatal("unexpected snapshot after round trip")
+	}
+}
+
+func TestSaveMode(t *testing.T) {
+	s, err := Load("testdata/show.json", artnet.NewDefaultLogger())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dir, err := ioutil.TempDir("", "show")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "show.json")
+
+	// new files are readable by everyone, replaced files keep their mode
+	for _, tt := range []struct {
+		name string
+		mode os.FileMode
+		want os.FileMode
+	}{
+		{name: "New", want: 0644},
+		{name: "Replaced", mode: 0640, want: 0640},
+	} {
+		if tt.mode != 0 {
+			if err := os.Chmod(path, tt.mode); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if err := s.Save(path); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want, got := tt.want, fi.Mode().Perm(); want != got {
+			t.Fatalf("%s: unexpected mode:\n- want: %v\n-  got: %v", tt.name, want, got)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		show string
+		err  string
+	}{
+		{
+			name: "Syntax",
+			show: "{\n  \"controller\": {\"ip\": \"2.0.0.1\",}\n}",
+			err:  "2:35: invalid character '}'",
+		},
+		{
+			name: "Type",
+			show: "{\n  \"controller\": {\"ip\": \"2.0.0.1\", \"fps\": \"fast\"}\n}",
+			err:  "2:42: controller.fps: cannot use string as int",
+		},
+		{
+			name: "UnknownField",
+			show: "{\n  \"controller\": {\"ip\": \"2.0.0.1\"},\n  \"fixturez\": []\n}",
+			err:  "3:15: fixturez: unknown field \"fixturez\"",
+		},
+		{
+			name: "IP",
+			show: "{\n  \"controller\": {\"ip\": \"localhost\"}\n}",
+			err:  "2:24: controller.ip: invalid IP address \"localhost\"",
+		},
+		{
+			name: "KeepAlive",
+			show: "{\n  \"controller\": {\"ip\": \"2.0.0.1\", \"keepAlive\": \"5s\"}\n}",
+			err:  "2:48: controller.keepAlive:",
+		},
+		{
+			name: "FixtureType",
+			show: "{\n  \"controller\": {\"ip\": \"2.0.0.1\"},\n  \"fixtures\": [\n    {\"name\": \"spot-1\", \"type\": \"spot\", \"universe\": \"0:0.1\", \"address\": 1}\n  ]\n}",
+			err:  "4:32: fixtures[0].type: unknown fixture type \"spot\"",
+		},
+		{
+			name: "Duration",
+			show: "{\n  \"controller\": {\"ip\": \"2.0.0.1\"},\n  \"cueLists\": [{\"name\": \"main\", \"cues\": [{\"number\": 1, \"fadeIn\": \"3\"}]}]\n}",
+			err:  "3:66: cueLists[0].cues[0].fadeIn: invalid duration \"3\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(strings.NewReader(tt.show), artnet.NewDefaultLogger())
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if _, ok := err.(*Error); !ok {
+				t.Fatalf("unexpected error type %T: %v", err, err)
+			}
+			if !strings.HasPrefix(err.Error(), tt.err) {
+				t.Fatalf("unexpected error:\n- want: %s...\n-  got: %v", tt.err, err)
+			}
+		})
+	}
+}
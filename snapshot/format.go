@@ -0,0 +1,147 @@
+package snapshot
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+	"github.com/jsimonetti/go-artnet/internal/atomicfile"
+)
+
+const (
+	format  = "go-artnet-snapshot"
+	version = 1
+
+	// rowSize is the number of channels per row of universe data
+	rowSize = 16
+)
+
+// jsonSnapshot is the saved form of a snapshot
+type jsonSnapshot struct {
+	Format    string         `json:"format"`
+	Version   int            `json:"version"`
+	Name      string         `json:"name"`
+	Time      time.Time      `json:"time"`
+	Source    string         `json:"source,omitempty"`
+	Universes []jsonUniverse `json:"universes"`
+}
+
+type jsonUniverse struct {
+	Address string   `json:"address"`
+	Data    []string `json:"data"`
+}
+
+// Open reads a snapshot from a file
+func Open(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %v", err)
+	}
+	defer f.Close()
+
+	return Decode(f)
+}
+
+// Decode decodes a snapshot
+func Decode(r io.Reader) (*Snapshot, error) {
+	s := &Snapshot{}
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %v", err)
+	}
+	return s, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, reading the snapshot file format
+func (s *Snapshot) UnmarshalJSON(b []byte) error {
+	var js jsonSnapshot
+	if err := json.Unmarshal(b, &js); err != nil {
+		return err
+	}
+	if js.Format != format {
+		return fmt.Errorf("unknown snapshot format %q", js.Format)
+	}
+	if js.Version != version {
+		return fmt.Errorf("unsupported snapshot version %d", js.Version)
+	}
+
+	universes := make(map[artnet.Address]artnet.Frame, len(js.Universes))
+	for _, ju := range js.Universes {
+		address, err := artnet.ParseAddress(ju.Address)
+		if err != nil {
+			return err
+		}
+		if _, ok := universes[address]; ok {
+			return fmt.Errorf("universe %s appears more than once", address)
+		}
+
+		var frame artnet.Frame
+		if len(ju.Data) != len(frame)/rowSize {
+			return fmt.Errorf("universe %s: %d rows of data, expected %d", address, len(ju.Data), len(frame)/rowSize)
+		}
+		for i, row := range ju.Data {
+			b, err := hex.DecodeString(strings.Replace(row, " ", "", -1))
+			if err != nil || len(b) != rowSize {
+				return fmt.Errorf("universe %s: invalid data in row %d: %q", address, i+1, row)
+			}
+			copy(frame[i*rowSize:], b)
+		}
+		universes[address] = frame
+	}
+
+	*s = Snapshot{
+		Name:      js.Name,
+		Time:      js.Time,
+		Source:    js.Source,
+		Universes: universes,
+	}
+	return nil
+}
+
+// Encode writes the snapshot as indented JSON
+func (s *Snapshot) Encode(w io.Writer) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// MarshalJSON implements json.Marshaler, writing the snapshot file format
+func (s *Snapshot) MarshalJSON() ([]byte, error) {
+	js := jsonSnapshot{
+		Format:    format,
+		Version:   version,
+		Name:      s.Name,
+		Time:      s.Time,
+		Source:    s.Source,
+		Universes: []jsonUniverse{},
+	}
+	for _, address := range s.Addresses() {
+		frame := s.Universes[address]
+		ju := jsonUniverse{Address: address.String()}
+		for i := 0; i < len(frame); i += rowSize {
+			row := make([]string, rowSize)
+			for j := range row {
+				row[j] = hex.EncodeToString(frame[i+j : i+j+1])
+			}
+			ju.Data = append(ju.Data, strings.Join(row, " "))
+		}
+		js.Universes = append(js.Universes, ju)
+	}
+	return json.Marshal(js)
+}
+
+// Save writes the snapshot to a file. The file is replaced atomically, so a crash
+// while saving leaves the previous snapshot intact.
+func (s *Snapshot) Save(path string) error {
+	if err := atomicfile.Write(path, s.Encode); err != nil {
+		return fmt.Errorf("failed to save snapshot: %v", err)
+	}
+	return nil
+}
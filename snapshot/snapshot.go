@@ -0,0 +1,234 @@
+// Package snapshot captures and restores the universes of an artnet.Controller.
+//
+// A snapshot holds the data written to a set of universes, the base below any mixer
+// layers and masters, which stay in effect when a snapshot is recalled. Snapshots
+// are saved as JSON:
+//
+//	{
+//	  "format": "go-artnet-snapshot",
+//	  "version": 1,
+//	  "name": "preshow",
+//	  "time": "2026-10-18T20:15:00Z",
+//	  "source": "foh-controller",
+//	  "universes": [
+//	    {
+//	      "address": "0:0.1",
+//	      "data": [
+//	        "ff ff 00 00 80 00 00 00 00 00 00 00 00 00 00 00",
+//	        ...
+//	      ]
+//	    }
+//	  ]
+//	}
+//
+// Universes are ordered by address and their 512 channels are written as 32 rows
+// of 16 hexadecimal values, so a line based diff of two snapshot files shows which
+// channels changed. Diff compares two snapshots channel by channel.
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+)
+
+// Snapshot holds the data of one or more universes at a point in time
+type Snapshot struct {
+	Name string
+	Time time.Time
+
+	// Source describes where the snapshot was captured, it defaults to the host name
+	Source string
+
+	Universes map[artnet.Address]artnet.Frame
+}
+
+// Capture captures the given universes of the controller, or all its universes when
+// none are given. It captures the data written to the universes, not their output:
+// Recall writes the snapshot back as that data, so capturing the output would bake
+// the mixer layers, masters and running effects into it and apply them twice.
+func Capture(c *artnet.Controller, name string, addresses ...artnet.Address) (*Snapshot, error) {
+	if len(addresses) == 0 {
+		addresses = c.Universes()
+	}
+
+	s := &Snapshot{
+		Name:      name,
+		Time:      time.Now().UTC(),
+		Universes: make(map[artnet.Address]artnet.Frame, len(addresses)),
+	}
+	s.Source, _ = os.Hostname()
+
+	for _, address := range addresses {
+		u, err := c.Universe(address)
+		if err != nil {
+			return nil, err
+		}
+		s.Universes[address] = u.Frame()
+	}
+	return s, nil
+}
+
+// Addresses returns the addresses of the universes in the snapshot in ascending order
+func (s *Snapshot) Addresses() []artnet.Address {
+	addresses := make([]artnet.Address, 0, len(s.Universes))
+	for address := range s.Universes {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].Integer() < addresses[j].Integer()
+	})
+	return addresses
+}
+
+// Recall writes the snapshot to the universes of the controller. With a fade the
+// universes crossfade from their current data to the snapshot, otherwise the
+// snapshot is output on the next frame. Universes not in the snapshot are left alone.
+func (s *Snapshot) Recall(c *artnet.Controller, fade time.Duration) error {
+	for _, address := range s.Addresses() {
+		u, err := c.Universe(address)
+		if err != nil {
+			return err
+		}
+
+		frame := s.Universes[address]
+		if fade > 0 {
+			u.FadeTo(frame, fade, artnet.CurveLinear)
+			continue
+		}
+		if err := u.Update(func(f *artnet.Frame) error {
+			*f = frame
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Change is a channel that differs between two snapshots
+type Change struct {
+	Universe artnet.Address
+	Channel  int
+	From     uint8
+	To       uint8
+}
+
+// String returns a string representation of Change
+func (c Change) String() string {
+	return fmt.Sprintf("%s/%d: %d -> %d", c.Universe, c.Channel, c.From, c.To)
+}
+
+// Diff returns the channels that differ from snapshot a to snapshot b, ordered by
+// universe and channel. A universe missing from a snapshot counts as all zero.
+func Diff(a, b *Snapshot) []Change {
+	seen := make(map[artnet.Address]bool)
+	var addresses []artnet.Address
+	for _, s := range []*Snapshot{a, b} {
+		for _, address := range s.Addresses() {
+			if !seen[address] {
+				seen[address] = true
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].Integer() < addresses[j].Integer()
+	})
+
+	var changes []Change
+	for _, address := range addresses {
+		from, to := a.Universes[address], b.Universes[address]
+		for i := range from {
+			if from[i] != to[i] {
+				changes = append(changes, Change{
+					Universe: address,
+					Channel:  i + 1,
+					From:     from[i],
+					To:       to[i],
+				})
+			}
+		}
+	}
+	return changes
+}
+
+// Restore recalls the snapshot saved at path instantly, typically right after
+// creating the controller so nodes receive the last known good look instead of a
+// blackout. When no snapshot has been saved yet it returns nil without error.
+func Restore(c *artnet.Controller, path string) (*Snapshot, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	s, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Recall(c, 0); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// AutoSave captures the given universes of the controller, or all of them, every
+// interval and saves them to path when they changed. Stop saves a final snapshot
+// and returns the last error that occurred.
+func AutoSave(c *artnet.Controller, path string, interval time.Duration, addresses ...artnet.Address) (stop func() error) {
+	var (
+		lock    sync.Mutex
+		last    *Snapshot
+		lastErr error
+	)
+
+	save := func() {
+		lock.Lock()
+		defer lock.Unlock()
+
+		s, err := Capture(c, "autosave", addresses...)
+		if err == nil && last != nil && len(Diff(last, s)) == 0 && len(last.Universes) == len(s.Universes) {
+			return
+		}
+		if err == nil {
+			err = s.Save(path)
+		}
+		if err != nil {
+			lastErr = err
+			return
+		}
+		last = s
+	}
+
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-ticker.C:
+				save()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() error {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+			<-stopped
+			save()
+		})
+
+		lock.Lock()
+		defer lock.Unlock()
+		return lastErr
+	}
+}
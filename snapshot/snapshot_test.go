@@ -0,0 +1,134 @@
+package snapshot
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jsimonetti/go-artnet"
+)
+
+func TestSnapshot(t *testing.T) {
+	c := artnet.NewController("test", net.IP{2, 0, 0, 1}, artnet.NewDefaultLogger())
+	a1, a2 := artnet.Address{SubUni: 1}, artnet.Address{Net: 1}
+	u1, _ := c.Universe(a1)
+	u2, _ := c.Universe(a2)
+	u1.SetRange(1, []byte{0xff, 0x80})
+	u2.SetChannel(512, 0x01)
+
+	s, err := Capture(c, "preshow")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := []artnet.Address{a1, a2}, s.Addresses(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected universes:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	var buf bytes.Buffer
+	if err := s.Encode(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := `"ff 80 00 00 00 00 00 00 00 00 00 00 00 00 00 00"`, buf.String(); !strings.Contains(got, want) {
+		t.Fatalf("expected encoded snapshot to contain %s:\n%s", want, got)
+	}
+
+	decoded, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !decoded.Time.Equal(s.Time) {
+		t.Fatalf("unexpected time:\n- want: %v\n-  got: %v", s.Time, decoded.Time)
+	}
+	decoded.Time = s.Time
+	if want, got := s, decoded; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected decoded snapshot:\n- want: %+v\n-  got: %+v", want.Addresses(), got.Addresses())
+	}
+
+	// changing the output and recalling the snapshot brings it back
+	u1.SetChannel(2, 0x00)
+	u2.SetChannel(1, 0x10)
+	now, _ := Capture(c, "now")
+	want := []Change{
+		{Universe: a1, Channel: 2, From: 0x80, To: 0x00},
+		{Universe: a2, Channel: 1, From: 0x00, To: 0x10},
+	}
+	if got := Diff(s, now); !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected diff:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	if err := s.Recall(c, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := s.Universes[a2], u2.Frame(); want != got {
+		t.Fatal("unexpected universe data after recall")
+	}
+}
+
+func TestRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "last.json")
+
+	c := artnet.NewController("test", net.IP{2, 0, 0, 1}, artnet.NewDefaultLogger())
+	if s, err := Restore(c, path); s != nil || err != nil {
+		t.Fatalf("unexpected restore without snapshot: %v, %v", s, err)
+	}
+
+	u, _ := c.Universe(artnet.Address{SubUni: 1})
+	u.SetChannel(10, 0xaa)
+	stop := AutoSave(c, path, time.Hour)
+	if err := stop(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// new files are readable by everyone, replaced files keep their mode
+	mode := func() os.FileMode {
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return fi.Mode().Perm()
+	}
+	if want, got := os.FileMode(0644), mode(); want != got {
+		t.Fatalf("unexpected mode of new file:\n- want: %v\n-  got: %v", want, got)
+	}
+	if err := os.Chmod(path, 0640); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	snap, _ := Capture(c, "last")
+	if err := snap.Save(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := os.FileMode(0640), mode(); want != got {
+		t.Fatalf("unexpected mode of replaced file:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// a new controller starts with the saved look
+	c = artnet.NewController("test", net.IP{2, 0, 0, 1}, artnet.NewDefaultLogger())
+	if _, err := Restore(c, path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	u, _ = c.Universe(artnet.Address{SubUni: 1})
+	if v, _ := u.Channel(10); v != 0xaa {
+		t.Fatalf("unexpected channel 10 after restore:\n- want: %#x\n-  got: %#x", 0xaa, v)
+	}
+
+	for _, s := range []string{
+		`{"format": "other", "version": 1}`,
+		`{"format": "go-artnet-snapshot", "version": 2}`,
+		`{"format": "go-artnet-snapshot", "version": 1, "universes": [{"address": "0:0.1", "data": ["00"]}]}`,
+	} {
+		if _, err := Decode(strings.NewReader(s)); err == nil {
+			t.Fatalf("expected error decoding %s", s)
+		}
+	}
+}
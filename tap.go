@@ -0,0 +1,63 @@
+package artnet
+
+import (
+	"net"
+	"sort"
+	"time"
+)
+
+// TapFn gets called with the raw data of every packet a node sends or receives,
+// before it is handled. Packets the node sends have the local address as src. The
+// data must not be modified or kept after the call returns.
+type TapFn func(ts time.Time, src, dst net.UDPAddr, data []byte)
+
+// Tapper is implemented by Node and Controller, it lets packet capture and
+// recording work with either
+type Tapper interface {
+	AddTap(fn TapFn) (remove func())
+}
+
+// AddTap adds a function that is called with every packet the node sends or
+// receives, it returns a function to remove it again. Taps are called from the
+// network loops of the node and must return quickly.
+func (n *Node) AddTap(fn TapFn) (remove func()) {
+	n.tapLock.Lock()
+	defer n.tapLock.Unlock()
+
+	if n.taps == nil {
+		n.taps = make(map[int]TapFn)
+	}
+	n.tapID++
+	id := n.tapID
+	n.taps[id] = fn
+
+	return func() {
+		n.tapLock.Lock()
+		defer n.tapLock.Unlock()
+		delete(n.taps, id)
+	}
+}
+
+// AddTap adds a function that is called with every packet the controller sends or
+// receives, it returns a function to remove it again
+func (c *Controller) AddTap(fn TapFn) (remove func()) {
+	return c.cNode.AddTap(fn)
+}
+
+// tap calls the taps of the node in the order they were added
+func (n *Node) tap(ts time.Time, src, dst net.UDPAddr, data []byte) {
+	n.tapLock.Lock()
+	defer n.tapLock.Unlock()
+
+	if len(n.taps) == 0 {
+		return
+	}
+	ids := make([]int, 0, len(n.taps))
+	for id := range n.taps {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		n.taps[id](ts, src, dst, data)
+	}
+}
@@ -0,0 +1,227 @@
+package artnet
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+var _ io.WriterAt = &Universe{}
+var _ io.ReaderAt = &Universe{}
+
+// Frame holds the 512 channels of a DMX universe. Channels are numbered 1 to 512.
+type Frame [512]byte
+
+// Channel returns the value of a channel
+func (f *Frame) Channel(channel int) (uint8, error) {
+	if err := checkChannels(channel, 1); err != nil {
+		return 0, err
+	}
+	return f[channel-1], nil
+}
+
+// SetChannel sets the value of a channel
+func (f *Frame) SetChannel(channel int, value uint8) error {
+	if err := checkChannels(channel, 1); err != nil {
+		return err
+	}
+	f[channel-1] = value
+	return nil
+}
+
+// Channel16 returns the 16 bit value of a coarse channel and the fine channel following it
+func (f *Frame) Channel16(channel int) (uint16, error) {
+	if err := checkChannels(channel, 2); err != nil {
+		return 0, err
+	}
+	return uint16(f[channel-1])<<8 | uint16(f[channel]), nil
+}
+
+// SetChannel16 sets a 16 bit value, the high byte on the coarse channel and the low byte
+// on the fine channel following it
+func (f *Frame) SetChannel16(channel int, value uint16) error {
+	if err := checkChannels(channel, 2); err != nil {
+		return err
+	}
+	f[channel-1] = uint8(value >> 8)
+	f[channel] = uint8(value)
+	return nil
+}
+
+// SetRange sets consecutive channels starting at channel to values
+func (f *Frame) SetRange(channel int, values []byte) error {
+	if err := checkChannels(channel, len(values)); err != nil {
+		return err
+	}
+	copy(f[channel-1:], values)
+	return nil
+}
+
+// checkChannels validates that count channels starting at channel fit in a frame
+func checkChannels(channel, count int) error {
+	if channel < 1 || channel+count-1 > len(Frame{}) {
+		return fmt.Errorf("channels %d-%d out of range 1-512", channel, channel+count-1)
+	}
+	return nil
+}
+
+// Universe is a handle to the output of a single universe of a Controller. It can be
+// written to before any node has subscribed to the universe, the data is sent as soon
+// as one does.
+type Universe struct {
+	c       *Controller
+	address Address
+}
+
+// Universe returns a handle to the universe with the given address
+func (c *Controller) Universe(address Address) (*Universe, error) {
+	if address.Net > 0x7f {
+		return nil, fmt.Errorf("invalid net %d for universe, must be 0-127", address.Net)
+	}
+
+	c.nodeLock.Lock()
+	c.universe(address)
+	c.nodeLock.Unlock()
+
+	return &Universe{c: c, address: address}, nil
+}
+
+// Universes returns the addresses of all universes of the controller in ascending order
+func (c *Controller) Universes() []Address {
+	c.nodeLock.Lock()
+	defer c.nodeLock.Unlock()
+
+	addresses := make([]Address, 0, len(c.universes))
+	for address := range c.universes {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].Integer() < addresses[j].Integer()
+	})
+	return addresses
+}
+
+// universe returns the DMX buffer of a universe, creating it if needed. The
+// caller must hold nodeLock.
+func (c *Controller) universe(address Address) *dmxBuffer {
+	buf, ok := c.universes[address]
+	if !ok {
+		// create an empty DMX buffer. This will blackout the universe entirely
+		buf = &dmxBuffer{address: address, masters: c.masters}
+		c.universes[address] = buf
+	}
+	return buf
+}
+
+// Address returns the address of the universe
+func (u *Universe) Address() Address {
+	return u.address
+}
+
+// Frame returns the data written to the universe. Without mixer layers this is
+// the data that is output.
+func (u *Universe) Frame() Frame {
+	u.c.nodeLock.Lock()
+	defer u.c.nodeLock.Unlock()
+	return u.c.universe(u.address).Data
+}
+
+// Output returns the data output on the universe, with the mixer layers and masters applied
+func (u *Universe) Output() Frame {
+	u.c.nodeLock.Lock()
+	defer u.c.nodeLock.Unlock()
+	buf := u.c.universe(u.address)
+	buf.remix(u.c.cNode.clock.Now())
+	return buf.Output
+}
+
+// Update calls fn with a copy of the data written to the universe. If fn returns without error all
+// changes are sent out together in a single frame, otherwise they are discarded. Changed
+// channels are taken away from the fades running on them.
+// fn must not call back into the Controller.
+func (u *Universe) Update(fn func(f *Frame) error) error {
+	u.c.nodeLock.Lock()
+	defer u.c.nodeLock.Unlock()
+
+	buf := u.c.universe(u.address)
+	f := buf.Data
+	if err := fn(&f); err != nil {
+		return err
+	}
+
+	// identical frames are not sent again until the keep-alive is due
+	now := u.c.cNode.clock.Now()
+	for i, v := range f {
+		if v != buf.Data[i] {
+			// a running fade would overwrite the channel on the next frame
+			u.c.fader.release(u.address, i)
+		}
+		buf.set(i, v, now)
+	}
+	return nil
+}
+
+// Channel returns the data written to a channel of the universe, Output returns
+// the data output with the mixer layers and masters applied
+func (u *Universe) Channel(channel int) (uint8, error) {
+	f := u.Frame()
+	return f.Channel(channel)
+}
+
+// SetChannel sets the output of a channel
+func (u *Universe) SetChannel(channel int, value uint8) error {
+	return u.Update(func(f *Frame) error {
+		return f.SetChannel(channel, value)
+	})
+}
+
+// Channel16 returns the 16 bit data written to a coarse and fine channel of the
+// universe, like Channel it does not include the mixer layers and masters
+func (u *Universe) Channel16(channel int) (uint16, error) {
+	f := u.Frame()
+	return f.Channel16(channel)
+}
+
+// SetChannel16 sets the 16 bit output of a coarse and fine channel
+func (u *Universe) SetChannel16(channel int, value uint16) error {
+	return u.Update(func(f *Frame) error {
+		return f.SetChannel16(channel, value)
+	})
+}
+
+// SetRange sets the output of consecutive channels starting at channel
+func (u *Universe) SetRange(channel int, values []byte) error {
+	return u.Update(func(f *Frame) error {
+		return f.SetRange(channel, values)
+	})
+}
+
+// WriteAt implements io.WriterAt. The offset is zero based, so offset 0 is channel 1.
+func (u *Universe) WriteAt(p []byte, off int64) (n int, err error) {
+	if off < 0 || off >= int64(len(Frame{})) {
+		return 0, fmt.Errorf("offset %d out of range 0-511", off)
+	}
+
+	err = u.Update(func(f *Frame) error {
+		n = copy(f[off:], p)
+		return nil
+	})
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	return n, err
+}
+
+// ReadAt implements io.ReaderAt. The offset is zero based, so offset 0 is channel 1.
+func (u *Universe) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 || off >= int64(len(Frame{})) {
+		return 0, io.EOF
+	}
+
+	f := u.Frame()
+	n = copy(p, f[off:])
+	if n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
@@ -0,0 +1,93 @@
+package artnet
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestFrame(t *testing.T) {
+	var f Frame
+
+	if err := f.SetChannel(1, 0x10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.SetChannel16(511, 0x1234); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.SetRange(10, []byte{0x01, 0x02, 0x03}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want, got := uint8(0x10), f[0]; want != got {
+		t.Fatalf("unexpected channel 1:\n- want: %#x\n-  got: %#x", want, got)
+	}
+	if v, err := f.Channel16(511); err != nil || v != 0x1234 {
+		t.Fatalf("unexpected channel 511:\n- want: %#x\n-  got: %#x (%v)", 0x1234, v, err)
+	}
+	if want, got := [3]byte{0x01, 0x02, 0x03}, [3]byte{f[9], f[10], f[11]}; want != got {
+		t.Fatalf("unexpected range:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	for _, channel := range []int{0, 513} {
+		if err := f.SetChannel(channel, 0); err == nil {
+			t.Fatalf("expected error setting channel %d", channel)
+		}
+	}
+	if err := f.SetChannel16(512, 0); err == nil {
+		t.Fatal("expected error setting 16 bit channel 512")
+	}
+	if err := f.SetRange(510, []byte{1, 2, 3, 4}); err == nil {
+		t.Fatal("expected error setting range past channel 512")
+	}
+}
+
+func TestUniverse(t *testing.T) {
+	c := NewController("test", net.IP{2, 0, 0, 1}, NewDefaultLogger())
+
+	u, err := c.Universe(Address{SubUni: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := u.SetChannel(2, 0xff); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !c.universes[u.Address()].mixDirty {
+		t.Fatal("expected universe to be mixed after write")
+	}
+
+	n, err := u.WriteAt([]byte{0x01, 0x02}, 511)
+	if want, got := io.ErrShortWrite, err; want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := 1, n; want != got {
+		t.Fatalf("unexpected bytes written:\n- want: %d\n-  got: %d", want, got)
+	}
+
+	b := make([]byte, 2)
+	if _, err := u.ReadAt(b, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := []byte{0xff, 0x00}, b; want[0] != got[0] || want[1] != got[1] {
+		t.Fatalf("unexpected read-back:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// a failing batch leaves the output untouched
+	errBatch := errors.New("batch failed")
+	err = u.Update(func(f *Frame) error {
+		f.SetChannel(2, 0x00)
+		return errBatch
+	})
+	if want, got := errBatch, err; want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+	if v, _ := u.Channel(2); v != 0xff {
+		t.Fatalf("unexpected channel 2 after failed batch:\n- want: %#x\n-  got: %#x", 0xff, v)
+	}
+
+	if _, err := c.Universe(Address{Net: 0x80}); err == nil {
+		t.Fatal("expected error for invalid net")
+	}
+}